@@ -0,0 +1,153 @@
+package slacker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	// Slack delivers events again when they are not acknowledged within 3 seconds, after which
+	// retrying to acknowledge them only costs a write
+	ackDeadline         = 3 * time.Second
+	ackRetryDelay       = 200 * time.Millisecond
+	maxAckAttempts      = 3
+	metricAcks          = "slacker_socket_acks_total"
+	ackResultSent       = "sent"
+	ackResultRetried    = "retried"
+	ackResultFailed     = "failed"
+	ackResultDropped    = "dropped"
+	ackResultNoPayload  = "invalid_payload"
+	adminAckKind        = "ack"
+	adminAckFormat      = ":warning: Acknowledging an event went wrong: %v"
+	ackFailedFormat     = "failed acknowledging envelope %s after %d attempts: %v"
+	ackPayloadFormat    = "failed encoding the response to envelope %s, acknowledging it without: %v"
+	ackRetryTraceFormat = "retrying the ack of envelope %s, attempt %d of %d: %v"
+)
+
+// ackTracker remembers when the events being acknowledged were received and how many times their
+// ack was sent, for as long as retrying it can beat Slack's redelivery
+type ackTracker struct {
+	mutex   sync.Mutex
+	clock   Clock
+	pending map[string]*pendingAck
+}
+
+type pendingAck struct {
+	received time.Time
+	attempts int
+}
+
+func newAckTracker(clock Clock) *ackTracker {
+	return &ackTracker{clock: clock, pending: make(map[string]*pendingAck)}
+}
+
+// sent records the first attempt to acknowledge the envelope, forgetting those past the deadline
+func (t *ackTracker) sent(envelopeID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.clock.Now()
+	for id, ack := range t.pending {
+		if now.Sub(ack.received) > ackDeadline {
+			delete(t.pending, id)
+		}
+	}
+	t.pending[envelopeID] = &pendingAck{received: now, attempts: 1}
+}
+
+// retry reports whether the failed ack of the envelope can be sent again, counting the attempt
+func (t *ackTracker) retry(envelopeID string) (int, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ack, ok := t.pending[envelopeID]
+	if !ok {
+		return maxAckAttempts, false
+	}
+	if ack.attempts >= maxAckAttempts || t.clock.Now().Sub(ack.received) > ackDeadline {
+		delete(t.pending, envelopeID)
+		return ack.attempts, false
+	}
+	ack.attempts++
+	return ack.attempts, true
+}
+
+// ack acknowledges the socket mode event, unless fault injection drops the ack. A response that
+// cannot be encoded is left out rather than failing the ack, which would make Slack deliver the
+// event again. The results are counted in the slacker_socket_acks_total metric, by result.
+func (s *Slacker) ack(ctx context.Context, evt socketmode.Event, payload ...interface{}) {
+	if evt.Request == nil {
+		return
+	}
+
+	if s.faults != nil && rand.Float64() < s.faults.DroppedAckRate {
+		fmt.Printf(faultDroppedAckFormat, evt.Type)
+		s.countAck(ackResultDropped)
+		return
+	}
+
+	response := socketmode.Response{EnvelopeID: evt.Request.EnvelopeID}
+	if len(payload) > 0 && payload[0] != nil {
+		if _, err := json.Marshal(payload[0]); err != nil {
+			s.ackFailed(ctx, fmt.Errorf(ackPayloadFormat, response.EnvelopeID, err))
+			s.countAck(ackResultNoPayload)
+		} else {
+			response.Payload = payload[0]
+		}
+	}
+
+	s.acks.sent(response.EnvelopeID)
+	s.socketModeClient.Send(response)
+	s.countAck(ackResultSent)
+}
+
+// handleWriteFailure sends a failed ack again while Slack is still waiting for it, and reports it
+// once it is too late or has failed too many times
+func (s *Slacker) handleWriteFailure(ctx context.Context, failure *socketmode.ErrorWriteFailed) {
+	if failure == nil || failure.Response == nil {
+		return
+	}
+
+	response := *failure.Response
+	attempts, ok := s.acks.retry(response.EnvelopeID)
+	if !ok {
+		s.ackFailed(ctx, fmt.Errorf(ackFailedFormat, response.EnvelopeID, attempts, failure.Cause))
+		s.countAck(ackResultFailed)
+		return
+	}
+
+	s.tracef(ackRetryTraceFormat, response.EnvelopeID, attempts, maxAckAttempts, failure.Cause)
+	s.countAck(ackResultRetried)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.clock.After(ackRetryDelay):
+			s.socketModeClient.Send(response)
+		}
+	}()
+}
+
+// ackFailed surfaces the failure to the handler set with Err and to the admin channel
+func (s *Slacker) ackFailed(ctx context.Context, err error) {
+	fmt.Printf("%v\n", err)
+	s.adminNotifier.notify(ctx, adminAckKind, fmt.Sprintf(adminAckFormat, err))
+
+	s.mutex.RLock()
+	errorHandler := s.errorHandler
+	s.mutex.RUnlock()
+	if errorHandler != nil {
+		errorHandler(err.Error())
+	}
+}
+
+func (s *Slacker) countAck(result string) {
+	if s.metrics != nil {
+		s.metrics.Count(metricAcks, 1, map[string]string{metricLabelResult: result})
+	}
+}
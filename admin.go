@@ -0,0 +1,148 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	adminThrottle           = 5 * time.Minute
+	connectionFailureAlert  = 3
+	adminPanicKind          = "panic"
+	adminConnectionKind     = "connection"
+	adminDroppedKind        = "dropped"
+	adminRateLimitKind      = "rate_limit"
+	adminSlowKind           = "slow_handler"
+	adminBacklogKind        = "event_backlog"
+	adminPanicFormat        = ":rotating_light: The `%s` command panicked: %v\n```%s```"
+	adminJobPanicFormat     = ":rotating_light: The `%s` job panicked: %v\n```%s```"
+	adminWizardPanicFormat  = ":rotating_light: The `%s` wizard panicked: %v\n```%s```"
+	adminConnectionFormat   = ":electric_plug: Connecting to Slack failed %d times in a row"
+	adminDroppedFormat      = ":wastebasket: Dropped an event: %s"
+	adminRateLimitFormat    = ":snail: Rate limited by Slack, retrying after %s"
+	adminSlowFormat         = ":turtle: The `%s` command took %s to run"
+	adminBacklogFormat      = ":inbox_tray: %d of %d events are waiting to be handled"
+	adminSuppressedFormat   = "\n_%d similar notifications were suppressed in the last %s_"
+	droppedCommandEvent     = "the command events channel is full"
+	droppedUnsupportedEvent = "unsupported %s event"
+)
+
+var (
+	errHandlerPanic = errors.New("Something went wrong while running the command")
+)
+
+type adminNotifierKey struct{}
+
+// adminNotifier summarizes internal errors into an ops channel. Notifications of the same kind
+// are sent at most once per throttle period, with a count of those suppressed in between.
+type adminNotifier struct {
+	mutex      sync.Mutex
+	client     *slack.Client
+	clock      Clock
+	channelID  string
+	sent       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newAdminNotifier(client *slack.Client, clock Clock) *adminNotifier {
+	return &adminNotifier{client: client, clock: clock, sent: make(map[string]time.Time), suppressed: make(map[string]int)}
+}
+
+// AdminChannel sends summaries of handler panics, repeated connection failures, dropped events,
+// failed acks and rate limiting to the channel, rather than leaving them in the process logs only
+func (s *Slacker) AdminChannel(channelID string) error {
+	return s.register(func() {
+		s.adminNotifier.mutex.Lock()
+		defer s.adminNotifier.mutex.Unlock()
+
+		s.adminNotifier.channelID = channelID
+	})
+}
+
+func withAdminNotifier(ctx context.Context, notifier *adminNotifier) context.Context {
+	return context.WithValue(ctx, adminNotifierKey{}, notifier)
+}
+
+func adminNotifierFromContext(ctx context.Context) *adminNotifier {
+	notifier, _ := ctx.Value(adminNotifierKey{}).(*adminNotifier)
+	return notifier
+}
+
+// notify posts the message unless one of the same kind was posted during the throttle period
+func (n *adminNotifier) notify(ctx context.Context, kind string, message string) {
+	if n == nil {
+		return
+	}
+
+	n.mutex.Lock()
+	channelID := n.channelID
+	if len(channelID) == 0 {
+		n.mutex.Unlock()
+		return
+	}
+
+	if since(n.clock, n.sent[kind]) < adminThrottle {
+		n.suppressed[kind]++
+		n.mutex.Unlock()
+		return
+	}
+
+	if suppressed := n.suppressed[kind]; suppressed > 0 {
+		message += fmt.Sprintf(adminSuppressedFormat, suppressed, adminThrottle)
+	}
+	n.sent[kind] = n.clock.Now()
+	n.suppressed[kind] = 0
+	n.mutex.Unlock()
+
+	n.send(ctx, message)
+}
+
+// send posts the message to the admin channel right away
+func (n *adminNotifier) send(ctx context.Context, message string) {
+	if n == nil {
+		return
+	}
+
+	n.mutex.Lock()
+	channelID := n.channelID
+	n.mutex.Unlock()
+
+	if len(channelID) == 0 {
+		return
+	}
+
+	// Not retried when rate limited, which would notify about the rate limit in turn
+	_, _, err := n.client.PostMessageContext(ctx, channelID, slack.MsgOptionText(message, false))
+	if err != nil {
+		fmt.Printf("failed sending admin notification: %v\n", err)
+	}
+}
+
+// channel returns the admin channel, empty when it is not set
+func (n *adminNotifier) channel() string {
+	if n == nil {
+		return empty
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.channelID
+}
+
+// enabled reports whether an admin channel is set
+func (n *adminNotifier) enabled() bool {
+	if n == nil {
+		return false
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return len(n.channelID) > 0
+}
@@ -8,8 +8,13 @@ import (
 
 // NewCommandEvent creates a new command event
 func NewCommandEvent(command string, parameters *proper.Properties, event *MessageEvent) *CommandEvent {
+	return newCommandEvent(systemClock{}, command, parameters, event)
+}
+
+// newCommandEvent creates a command event timestamped by the clock
+func newCommandEvent(clock Clock, command string, parameters *proper.Properties, event *MessageEvent) *CommandEvent {
 	return &CommandEvent{
-		Timestamp:  time.Now(),
+		Timestamp:  clock.Now(),
 		Command:    command,
 		Parameters: parameters,
 		Event:      event,
@@ -0,0 +1,230 @@
+package slacker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	approvalKeyPrefix    = "approval"
+	approvalLogKeyPrefix = "approvals"
+	approvalApproveID    = "slacker_approval_approve"
+	approvalRejectID     = "slacker_approval_reject"
+	approvalTTL          = time.Hour
+	approvalLogSize      = 100
+	approvalIDBytes      = 8
+	approvalPromptFormat = "<@%s> wants to run `%s`, which needs the approval of someone else."
+	approvalApproveText  = "Approve"
+	approvalRejectText   = "Reject"
+	approvalDoneFormat   = "<@%s> approved `%s` requested by <@%s>"
+	approvalRejectFormat = "<@%s> rejected `%s` requested by <@%s>"
+	approvalExpiredText  = "This approval request expired"
+)
+
+var (
+	errSelfApproval = errors.New("You cannot approve your own command, someone else has to")
+)
+
+// approvalRequest is a command waiting for a second approver, kept in the store so that its
+// parameters never leave it
+type approvalRequest struct {
+	ID              string            `json:"id"`
+	Usage           string            `json:"usage"`
+	Parameters      map[string]string `json:"parameters"`
+	Requester       string            `json:"requester"`
+	Channel         string            `json:"channel"`
+	TimeStamp       string            `json:"ts"`
+	ThreadTimeStamp string            `json:"thread_ts"`
+	RequestedAt     time.Time         `json:"requested_at"`
+}
+
+// ApprovalEntry is the audit record of a command that needed a second approver
+type ApprovalEntry struct {
+	Timestamp   time.Time         `json:"timestamp"`
+	Usage       string            `json:"usage"`
+	Parameters  map[string]string `json:"parameters"`
+	Channel     string            `json:"channel"`
+	Requester   string            `json:"requester"`
+	Approver    string            `json:"approver"`
+	Approved    bool              `json:"approved"`
+	RequestedAt time.Time         `json:"requested_at"`
+}
+
+type approverKey struct{}
+
+// Approver returns the user who approved the execution of a command requiring a second approver
+func Approver(botCtx BotContext) string {
+	approver, _ := botCtx.Context().Value(approverKey{}).(string)
+	return approver
+}
+
+// Approvals returns the audit records of the commands that needed a second approver in the
+// workspace, the latest first
+func (s *Slacker) Approvals(ctx context.Context, teamID string) ([]*ApprovalEntry, error) {
+	entries := []*ApprovalEntry{}
+	if _, err := s.loadValue(ctx, storeKey(approvalLogKeyPrefix, teamID), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// requestApproval holds the command until a different authorized user approves it. The buttons
+// only carry the ID of the request, so parameter values never leave the store, and the prompt
+// redacts those of Secret parameters.
+func (s *Slacker) requestApproval(botCtx BotContext, response ResponseWriter, cmd BotCommand, parameters *proper.Properties) {
+	id := make([]byte, approvalIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	ev := botCtx.Event()
+	request := &approvalRequest{
+		ID:              hex.EncodeToString(id),
+		Usage:           cmd.Usage(),
+		Parameters:      parameterValues(cmd, parameters),
+		Requester:       ev.User,
+		Channel:         ev.Channel,
+		TimeStamp:       ev.TimeStamp,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		RequestedAt:     s.clock.Now(),
+	}
+	if err := s.saveValue(botCtx.Context(), storeKey(approvalKeyPrefix, ev.TeamID, request.ID), request, approvalTTL); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	prompt := fmt.Sprintf(approvalPromptFormat, ev.User, formatInvocation(request.Usage, redactedValues(cmd, request.Parameters)))
+	approve := slack.NewButtonBlockElement(approvalApproveID, request.ID, slack.NewTextBlockObject(slack.PlainTextType, approvalApproveText, false, false))
+	approve.Style = slack.StylePrimary
+	reject := slack.NewButtonBlockElement(approvalRejectID, request.ID, slack.NewTextBlockObject(slack.PlainTextType, approvalRejectText, false, false))
+	reject.Style = slack.StyleDanger
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, prompt, false, false), nil, nil),
+		slack.NewActionBlock(approvalApproveID, approve, reject),
+	}
+
+	if err := response.Reply(prompt, WithBlocks(blocks), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// handleApprovalAction runs or drops the held command once another authorized user answers.
+// The requester may reject their own request, but not approve it.
+func (s *Slacker) handleApprovalAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	ctx := botCtx.Context()
+	ev := botCtx.Event()
+	key := storeKey(approvalKeyPrefix, ev.TeamID, action.Value)
+
+	request := &approvalRequest{}
+	found, err := s.loadValue(ctx, key, request)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	cmd := s.findCommand(request.Usage)
+	if !found || cmd == nil {
+		s.replaceInteractionMessage(botCtx, callback, approvalExpiredText)
+		return
+	}
+
+	approved := action.ActionID == approvalApproveID
+	if approved && ev.User == request.Requester {
+		response.ReportError(errSelfApproval)
+		return
+	}
+
+	definition := cmd.Definition()
+	if ev.User != request.Requester && definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx, s.newRequest(botCtx, proper.NewProperties(request.Parameters))) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	// Only the first answer counts, even when several users click at once
+	claimed, err := s.claimApproval(ctx, key)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+	if !claimed {
+		s.replaceInteractionMessage(botCtx, callback, approvalExpiredText)
+		return
+	}
+
+	if err := s.recordApproval(ctx, ev.TeamID, cmd, request, ev.User, approved); err != nil {
+		fmt.Printf("failed recording approval: %v\n", err)
+	}
+
+	invocation := formatInvocation(request.Usage, redactedValues(cmd, request.Parameters))
+	if !approved {
+		s.replaceInteractionMessage(botCtx, callback, fmt.Sprintf(approvalRejectFormat, ev.User, invocation, request.Requester))
+		return
+	}
+	s.replaceInteractionMessage(botCtx, callback, fmt.Sprintf(approvalDoneFormat, ev.User, invocation, request.Requester))
+
+	// The command runs as the requester, with the approver available to its handler
+	commandEvent := &MessageEvent{
+		Channel:         request.Channel,
+		User:            request.Requester,
+		Data:            callback,
+		Type:            string(callback.Type),
+		TimeStamp:       request.TimeStamp,
+		ThreadTimeStamp: request.ThreadTimeStamp,
+		TeamID:          ev.TeamID,
+	}
+	commandCtx := s.newBotContext(context.WithValue(ctx, approverKey{}, ev.User), commandEvent)
+	go s.runCommand(commandCtx, s.newResponse(commandCtx), cmd, proper.NewProperties(request.Parameters))
+}
+
+// claimApproval removes the request, reporting whether this call was the one removing it
+func (s *Slacker) claimApproval(ctx context.Context, key string) (bool, error) {
+	current, err := s.store.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil || len(current) == 0 {
+		return false, err
+	}
+
+	// The request is swapped out for an empty value, which a concurrent answer finds instead
+	claimed, err := s.compareAndSwap(ctx, key, current, []byte{}, approvalTTL)
+	if err != nil || !claimed {
+		return false, err
+	}
+	return true, s.store.Delete(ctx, key)
+}
+
+// recordApproval adds the answer to the audit records of the workspace, without the values of
+// Secret parameters
+func (s *Slacker) recordApproval(ctx context.Context, teamID string, cmd BotCommand, request *approvalRequest, approver string, approved bool) error {
+	key := storeKey(approvalLogKeyPrefix, teamID)
+	entries, err := s.Approvals(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	entry := &ApprovalEntry{
+		Timestamp:   s.clock.Now(),
+		Usage:       request.Usage,
+		Parameters:  redactedValues(cmd, request.Parameters),
+		Channel:     request.Channel,
+		Requester:   request.Requester,
+		Approver:    approver,
+		Approved:    approved,
+		RequestedAt: request.RequestedAt,
+	}
+	entries = append([]*ApprovalEntry{entry}, entries...)
+	if len(entries) > approvalLogSize {
+		entries = entries[:approvalLogSize]
+	}
+	return s.saveValue(ctx, key, entries, 0)
+}
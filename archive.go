@@ -0,0 +1,129 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	archiveCommandFormat = "<@%s> ran `%s` in <#%s>"
+	archiveReplyFormat   = "Reply to <@%s> in <#%s>"
+	archiveLinkFormat    = " (<%s|view message>)"
+	archiveQuotePrefix   = "> "
+)
+
+// ArchivePolicy mirrors the replies of some commands into an archive channel, such as all the
+// results of deploys, for teams keeping a single audit channel. Each mirrored reply says who ran
+// which command where, with a link back to the message that ran it.
+type ArchivePolicy struct {
+	// Channel is the ID of the archive channel
+	Channel string
+
+	// Commands are the usages of the commands whose replies are mirrored, exactly or as globs such
+	// as `deploy *`. The replies of every command are mirrored when it is empty.
+	Commands []string
+
+	// Errors mirrors the errors reported by the commands as well
+	Errors bool
+}
+
+// matches reports whether the replies of the command are mirrored
+func (p *ArchivePolicy) matches(cmd BotCommand) bool {
+	if len(p.Commands) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.Commands {
+		if matched, _ := path.Match(pattern, cmd.Usage()); matched || pattern == cmd.Usage() {
+			return true
+		}
+	}
+	return false
+}
+
+type archivePolicyKey struct{}
+
+func withArchivePolicy(ctx context.Context, policy *ArchivePolicy) context.Context {
+	return context.WithValue(ctx, archivePolicyKey{}, policy)
+}
+
+func archivePolicyFromContext(ctx context.Context) *ArchivePolicy {
+	policy, _ := ctx.Value(archivePolicyKey{}).(*ArchivePolicy)
+	return policy
+}
+
+// archiveChannel returns the channel mirroring a message, the one set WithArchive coming before
+// the policy, or nothing when the message is not mirrored
+func (r *response) archiveChannel(defaults *ReplyDefaults, isError bool) string {
+	if len(defaults.Archive) > 0 {
+		return defaults.Archive
+	}
+
+	policy := archivePolicyFromContext(r.botCtx.Context())
+	exec := executionFromContext(r.botCtx.Context())
+	if policy == nil || len(policy.Channel) == 0 || exec == nil || (isError && !policy.Errors) || !policy.matches(exec.command) {
+		return empty
+	}
+	return policy.Channel
+}
+
+// archive mirrors the message posted to the channel into the archive channel, if any
+func (r *response) archive(archiveChannel string, channelID string, timestamp string, message string, defaults *ReplyDefaults) {
+	if len(archiveChannel) == 0 || archiveChannel == channelID {
+		return
+	}
+
+	ctx := r.botCtx.Context()
+	ev := r.botCtx.Event()
+	header := fmt.Sprintf(archiveReplyFormat, ev.User, channelID)
+	if exec := executionFromContext(ctx); exec != nil {
+		header = fmt.Sprintf(archiveCommandFormat, ev.User, archivedInvocation(exec), channelID)
+	}
+
+	// The link goes to the message that ran the command, or to the reply without one, as for
+	// slash commands
+	linkChannel, linkTimestamp := ev.Channel, ev.TimeStamp
+	if len(linkTimestamp) == 0 {
+		linkChannel, linkTimestamp = channelID, timestamp
+	}
+	permalink, err := r.botCtx.Client().GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: linkChannel, Ts: linkTimestamp})
+	if err == nil && len(permalink) > 0 {
+		header += fmt.Sprintf(archiveLinkFormat, permalink)
+	}
+
+	text := header
+	if len(message) > 0 {
+		text += newLine + archiveQuotePrefix + strings.Replace(message, newLine, newLine+archiveQuotePrefix, -1)
+	}
+
+	opts := []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionAttachments(defaults.Attachments...),
+	}
+	if len(defaults.Blocks) > 0 {
+		// Blocks replace the text of messages, so the attribution goes first among them
+		attribution := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, header, false, false), nil, nil)
+		opts = append(opts, slack.MsgOptionBlocks(append([]slack.Block{attribution}, defaults.Blocks...)...))
+	}
+
+	err = withRateLimitRetry(ctx, func() error {
+		_, _, err := r.botCtx.Client().PostMessageContext(ctx, archiveChannel, opts...)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("failed archiving message: %v\n", scopeError(ctx, err, featureMessages, scopeChatWrite))
+	}
+}
+
+// archivedInvocation returns the command as it was run, with the values of secret parameters redacted
+func archivedInvocation(exec *execution) string {
+	if exec.parameters == nil {
+		return exec.command.Usage()
+	}
+
+	return formatInvocation(exec.command.Usage(), redactedValues(exec.command, parameterValues(exec.command, exec.parameters)))
+}
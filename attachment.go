@@ -0,0 +1,115 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	attachmentFieldFormat = "%s: %s"
+)
+
+// MatchSource is the part of a message a command was matched against
+type MatchSource string
+
+const (
+	// MessageSource is the text of the message, the default
+	MessageSource MatchSource = "message"
+	// AttachmentSource is the text of an attachment, such as an alert posted by an integration
+	AttachmentSource MatchSource = "attachment"
+	// ForwardedSource is the text of a message that was forwarded or shared into the conversation
+	ForwardedSource MatchSource = "forwarded"
+)
+
+// MatchOrigin tells where the text a command matched came from. Attachment is the matched
+// attachment, and nil when the command matched the text of the message.
+type MatchOrigin struct {
+	Source     MatchSource
+	Attachment *slack.Attachment
+}
+
+type matchOriginKey struct{}
+
+func withMatchOrigin(ctx context.Context, origin *MatchOrigin) context.Context {
+	return context.WithValue(ctx, matchOriginKey{}, origin)
+}
+
+func matchOriginFromContext(ctx context.Context) *MatchOrigin {
+	origin, _ := ctx.Value(matchOriginKey{}).(*MatchOrigin)
+	return origin
+}
+
+// executeAttachments matches the commands against the attachments of a message when attachment matching
+// is enabled. Messages from other integrations are matched without mentioning the bot, including their
+// text, since integrations cannot address it.
+func (s *Slacker) executeAttachments(ctx context.Context, ev *MessageEvent) bool {
+	if !s.attachmentMatching {
+		return false
+	}
+
+	msg, ok := ev.Data.(*slackevents.MessageEvent)
+	if !ok {
+		return false
+	}
+
+	addressed := isAddressedToBot(ev)
+	if !addressed && !ev.IsBot() {
+		return false
+	}
+
+	if !addressed && len(strings.TrimSpace(ev.Text)) > 0 {
+		if s.executeFrom(ctx, ev, &MatchOrigin{Source: MessageSource}, ev.Text) {
+			return true
+		}
+	}
+
+	for i := range msg.Attachments {
+		attachment := &msg.Attachments[i]
+		text := attachmentText(attachment)
+		if len(text) == 0 {
+			continue
+		}
+
+		origin := &MatchOrigin{Source: AttachmentSource, Attachment: attachment}
+		if isForwarded(attachment) {
+			origin.Source = ForwardedSource
+		}
+		if s.executeFrom(ctx, ev, origin, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Slacker) executeFrom(ctx context.Context, ev *MessageEvent, origin *MatchOrigin, text string) bool {
+	botCtx := s.newBotContext(withMatchOrigin(ctx, origin), ev)
+	response := s.withTranslation(botCtx, s.newResponse(botCtx))
+	return s.executeCommand(botCtx, response, stripStopWords(text, s.stopWords))
+}
+
+// attachmentText returns the text shown by the attachment, falling back to its plain text summary
+func attachmentText(attachment *slack.Attachment) string {
+	lines := []string{}
+	for _, text := range []string{attachment.Pretext, attachment.Title, attachment.Text} {
+		if len(text) > 0 {
+			lines = append(lines, text)
+		}
+	}
+	for _, field := range attachment.Fields {
+		lines = append(lines, fmt.Sprintf(attachmentFieldFormat, field.Title, field.Value))
+	}
+
+	if len(lines) == 0 {
+		return strings.TrimSpace(attachment.Fallback)
+	}
+	return strings.TrimSpace(strings.Join(lines, newLine))
+}
+
+// isForwarded tells shared messages apart from other attachments, as they quote their author and timestamp
+func isForwarded(attachment *slack.Attachment) bool {
+	return len(attachment.AuthorID) > 0 && len(attachment.Ts) > 0
+}
@@ -0,0 +1,208 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	batchSeparator       = "&&"
+	maxBatchCommands     = 20
+	batchSummaryFormat   = "*Batch:* %d of %d commands ran"
+	batchStepFormat      = "\n%d. `%s` %s"
+	batchSucceeded       = ":white_check_mark: succeeded"
+	batchFailedFormat    = ":x: failed: %s"
+	batchSkipped         = ":heavy_minus_sign: skipped"
+	batchTooLargeFormat  = "A message can run %d commands at most"
+	batchErrorTextLength = 200
+)
+
+type batchStepKey struct{}
+
+// batchStep is a command run as part of a batch, remembering the first error it reported
+type batchStep struct {
+	text  string
+	cmd   BotCommand
+	mutex sync.Mutex
+	err   error
+}
+
+func (b *batchStep) fail(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *batchStep) failure() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.err
+}
+
+// splitBatch splits the text on line breaks and on `&&`, leaving code blocks whole so that they
+// can still fill the final parameter of a command
+func splitBatch(text string) []string {
+	parts := []string{}
+	current := &strings.Builder{}
+	flush := func() {
+		if part := strings.TrimSpace(current.String()); len(part) > 0 {
+			parts = append(parts, part)
+		}
+		current.Reset()
+	}
+
+	for i, segment := range strings.Split(text, codeFence) {
+		if i%2 == 1 {
+			current.WriteString(codeFence + segment + codeFence)
+			continue
+		}
+
+		for j, line := range strings.Split(segment, newLine) {
+			if j > 0 {
+				flush()
+			}
+			for k, command := range strings.Split(line, batchSeparator) {
+				if k > 0 {
+					flush()
+				}
+				current.WriteString(command)
+			}
+		}
+	}
+	flush()
+	return parts
+}
+
+// executeBatch runs the commands of a message holding several of them one after the other,
+// replying in its thread, and reports whether it did. A failing command skips those after it.
+// Messages where a part matches no command are left to run as a single command.
+func (s *Slacker) executeBatch(botCtx BotContext, response ResponseWriter, text string) bool {
+	if !s.batchCommands {
+		return false
+	}
+
+	parts := splitBatch(text)
+	if len(parts) < 2 {
+		return false
+	}
+
+	commands := s.commands()
+	steps := make([]*batchStep, 0, len(parts))
+	parameters := make([]*proper.Properties, 0, len(parts))
+	for _, part := range parts {
+		cmd, params := s.matcher.indexFor(commands).match(part)
+		if cmd == nil {
+			cmd, params = s.matchTranslated(botCtx, commands, part)
+		}
+		if cmd == nil {
+			s.tracef("batch part %q matched no command", part)
+			return false
+		}
+		steps = append(steps, &batchStep{text: part, cmd: cmd})
+		parameters = append(parameters, params)
+	}
+
+	if len(steps) > maxBatchCommands {
+		response.ReportError(fmt.Errorf(batchTooLargeFormat, maxBatchCommands), WithThreadError(true))
+		return true
+	}
+
+	ran := 0
+	for i, step := range steps {
+		s.tracef("batch step %d of %d runs `%s`", i+1, len(steps), step.cmd.Usage())
+		stepCtx := s.newBotContext(context.WithValue(botCtx.Context(), batchStepKey{}, step), botCtx.Event())
+		s.runCommand(stepCtx, s.withTranslation(stepCtx, s.withBatch(stepCtx, s.newResponse(stepCtx))), step.cmd, parameters[i])
+
+		ran++
+		if step.failure() != nil {
+			break
+		}
+	}
+
+	if err := response.Reply(formatBatch(steps, ran), WithThreadReply(true)); err != nil {
+		response.ReportError(err, WithThreadError(true))
+	}
+	return true
+}
+
+// withBatch replies in the thread of the message when the command runs as part of a batch,
+// recording the errors it reports
+func (s *Slacker) withBatch(botCtx BotContext, response ResponseWriter) ResponseWriter {
+	step, ok := botCtx.Context().Value(batchStepKey{}).(*batchStep)
+	if !ok {
+		return response
+	}
+	return &batchResponse{ResponseWriter: response, step: step}
+}
+
+// batchResponse sends the replies of a command run as part of a batch to the thread of its message
+type batchResponse struct {
+	ResponseWriter
+	step *batchStep
+}
+
+func (r *batchResponse) Reply(message string, options ...ReplyOption) error {
+	return r.ResponseWriter.Reply(message, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) ReportError(err error, options ...ReportErrorOption) {
+	r.step.fail(err)
+	r.ResponseWriter.ReportError(err, append(options, WithThreadError(true))...)
+}
+
+func (r *batchResponse) FileUpload(title string, comment string, filename string, filetype string, reader io.Reader, options ...ReplyOption) error {
+	return r.ResponseWriter.FileUpload(title, comment, filename, filetype, reader, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) StartTask(title string, options ...ReplyOption) Task {
+	return r.ResponseWriter.StartTask(title, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) StartTimer(title string, until time.Time, options ...ReplyOption) Timer {
+	return r.ResponseWriter.StartTimer(title, until, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) ReplyTable(name string, rows [][]string, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyTable(name, rows, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) ReplyChart(spec *ChartSpec, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyChart(spec, append(options, WithThreadReply(true))...)
+}
+
+func (r *batchResponse) ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyBlocks(blocks, append(options, WithThreadReply(true))...)
+}
+
+// formatBatch summarizes the outcome of each command of the batch
+func formatBatch(steps []*batchStep, ran int) string {
+	summary := fmt.Sprintf(batchSummaryFormat, ran, len(steps))
+	for i, step := range steps {
+		outcome := batchSkipped
+		if i < ran {
+			outcome = batchSucceeded
+			if err := step.failure(); err != nil {
+				outcome = fmt.Sprintf(batchFailedFormat, truncate(err.Error(), batchErrorTextLength))
+			}
+		}
+
+		// Commands spanning several lines are shown by their first
+		text := step.text
+		if end := strings.Index(text, newLine); end >= 0 {
+			text = text[:end] + multiLineSuffix
+		}
+		summary += fmt.Sprintf(batchStepFormat, i+1, text, outcome)
+	}
+	return summary
+}
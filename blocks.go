@@ -0,0 +1,49 @@
+package slacker
+
+import (
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// ReplyBlocks sends the blocks to the current channel, in the thread of the event WithThreadReply,
+// as Reply does. The text of their sections, headers and contexts is the fallback of the message,
+// shown in notifications.
+func (r *response) ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error {
+	return r.Reply(blocksText(blocks), append(options, WithBlocks(blocks))...)
+}
+
+// PostBlocks sends the blocks to another channel, the thread reply option is ignored
+func (r *response) PostBlocks(channelID string, blocks []slack.Block, options ...ReplyOption) error {
+	return r.PostTo(channelID, blocksText(blocks), append(options, WithBlocks(blocks))...)
+}
+
+// blocksText returns the text of the blocks, one line for each block having some
+func blocksText(blocks []slack.Block) string {
+	lines := []string{}
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *slack.HeaderBlock:
+			lines = appendText(lines, block.Text)
+		case *slack.SectionBlock:
+			lines = appendText(lines, block.Text)
+			for _, field := range block.Fields {
+				lines = appendText(lines, field)
+			}
+		case *slack.ContextBlock:
+			for _, element := range block.ContextElements.Elements {
+				if text, ok := element.(*slack.TextBlockObject); ok {
+					lines = appendText(lines, text)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, newLine)
+}
+
+func appendText(lines []string, text *slack.TextBlockObject) []string {
+	if text == nil || len(text.Text) == 0 {
+		return lines
+	}
+	return append(lines, text.Text)
+}
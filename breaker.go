@@ -0,0 +1,169 @@
+package slacker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailures = 5
+	defaultBreakerCooldown = time.Minute
+	statusBreakerFormat    = "\nCircuit breaker: %s"
+	statusBreakerOpen      = "open, probing again in %s"
+)
+
+// CircuitState is the state of the circuit breaker of a command
+type CircuitState string
+
+const (
+	// CircuitClosed lets the command run
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen short-circuits the command until its cool-down is over
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single run through to probe whether the command recovered
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+var (
+	errCommandUnavailable = errors.New("This command is temporarily unavailable, please try again later")
+)
+
+// CircuitBreaker stops running a command whose handler keeps failing, replying that it is unavailable
+// instead. After Failures runs in a row reported an error, the command is short-circuited for the
+// Cooldown. The next run is then a probe: the command runs normally again when it succeeds, or is
+// short-circuited for another cool-down when it fails. Failures defaults to 5 and Cooldown to a minute.
+type CircuitBreaker struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+func (c *CircuitBreaker) failures() int {
+	if c.Failures <= 0 {
+		return defaultBreakerFailures
+	}
+	return c.Failures
+}
+
+func (c *CircuitBreaker) cooldown() time.Duration {
+	if c.Cooldown <= 0 {
+		return defaultBreakerCooldown
+	}
+	return c.Cooldown
+}
+
+// breaker is the state of the circuit breaker of a command
+type breaker struct {
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// breakers holds the state of the circuit breakers by command usage
+type breakers struct {
+	mutex   sync.Mutex
+	clock   Clock
+	byUsage map[string]*breaker
+}
+
+func newBreakers(clock Clock) *breakers {
+	return &breakers{clock: clock, byUsage: make(map[string]*breaker)}
+}
+
+func (b *breakers) get(usage string) *breaker {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	current, ok := b.byUsage[usage]
+	if !ok {
+		current = &breaker{state: CircuitClosed}
+		b.byUsage[usage] = current
+	}
+	return current
+}
+
+// allow reports whether the command may run, moving an open breaker whose cool-down is over to half-open
+func (b *breakers) allow(cmd BotCommand) bool {
+	definition := cmd.Definition().CircuitBreaker
+	if definition == nil {
+		return true
+	}
+
+	current := b.get(cmd.Usage())
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+
+	switch current.state {
+	case CircuitOpen:
+		if since(b.clock, current.openedAt) < definition.cooldown() {
+			return false
+		}
+		current.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		if current.probing {
+			return false
+		}
+	default:
+		return true
+	}
+	current.probing = true
+	return true
+}
+
+// record counts the outcome of the run, opening the breaker after too many failures or a failed probe
+func (b *breakers) record(cmd BotCommand, outcome *outcomeResponse) {
+	definition := cmd.Definition().CircuitBreaker
+	if definition == nil || outcome == nil {
+		return
+	}
+
+	current := b.get(cmd.Usage())
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+
+	current.probing = false
+	if outcome.failure() == nil {
+		current.state, current.failures = CircuitClosed, 0
+		return
+	}
+
+	current.failures++
+	if current.state == CircuitHalfOpen || current.failures >= definition.failures() {
+		current.state, current.openedAt = CircuitOpen, b.clock.Now()
+	}
+}
+
+// state returns the state of the command's breaker, and when an open one starts probing again
+func (b *breakers) state(cmd BotCommand) (CircuitState, time.Time) {
+	definition := cmd.Definition().CircuitBreaker
+	if definition == nil {
+		return empty, time.Time{}
+	}
+
+	current := b.get(cmd.Usage())
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+
+	probeAt := current.openedAt.Add(definition.cooldown())
+	if current.state != CircuitOpen {
+		return current.state, time.Time{}
+	}
+	if !b.clock.Now().Before(probeAt) {
+		// The next run is the probe
+		return CircuitHalfOpen, time.Time{}
+	}
+	return current.state, probeAt
+}
+
+func formatBreaker(state CircuitState, probeAt time.Time, clock Clock) string {
+	if len(state) == 0 {
+		return empty
+	}
+	if state == CircuitOpen {
+		return fmt.Sprintf(statusBreakerFormat, fmt.Sprintf(statusBreakerOpen, probeAt.Sub(clock.Now()).Round(time.Second)))
+	}
+	return fmt.Sprintf(statusBreakerFormat, state)
+}
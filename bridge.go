@@ -0,0 +1,192 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/shomali11/commander"
+)
+
+const (
+	maxBridgeHops     = 8
+	bridgeContentType = "application/json"
+	bridgeStatusError = "bridge endpoint responded with status %d"
+)
+
+var (
+	// ErrBridgeLoop is returned when forwarding an event to a bot it already went through
+	ErrBridgeLoop = errors.New("bridge loop detected")
+	// ErrInvalidBridge is returned when registering a bridge without a target or anything to forward
+	ErrInvalidBridge = errors.New("invalid bridge")
+	// ErrBridgeUnhandled is returned by a bot target when none of its commands matched
+	ErrBridgeUnhandled = errors.New("no command matched the forwarded message")
+)
+
+// BridgeEnvelope is what a bridge forwards: the text to match and the event it came from.
+// Path holds the user IDs of the bots the envelope went through, to detect loops.
+type BridgeEnvelope struct {
+	Text  string        `json:"text"`
+	Event *MessageEvent `json:"event"`
+	Path  []string      `json:"path"`
+}
+
+// A BridgeTarget interface receives the envelopes forwarded by a bridge
+type BridgeTarget interface {
+	Forward(ctx context.Context, envelope *BridgeEnvelope) error
+}
+
+// BridgeDefinition structure contains the definition of a bridge. Messages matching one of the
+// command usages, or the Match function, are forwarded to the target instead of being handled here.
+// With Mirror, they are handled here as well. A failed forward falls back to handling them here.
+type BridgeDefinition struct {
+	Commands []string
+	Match    func(botCtx BotContext, text string) bool
+	Target   BridgeTarget
+	Mirror   bool
+}
+
+type bridge struct {
+	definition *BridgeDefinition
+	commands   []*commander.Command
+}
+
+type bridgeEnvelopeKey struct{}
+
+// Bridge registers a bridge forwarding messages to another bot or an external endpoint,
+// to move commands between bots gradually or route them from a single bot
+func (s *Slacker) Bridge(definition *BridgeDefinition) error {
+	if definition == nil || definition.Target == nil || (len(definition.Commands) == 0 && definition.Match == nil) {
+		return ErrInvalidBridge
+	}
+
+	b := &bridge{definition: definition}
+	for _, usage := range definition.Commands {
+		b.commands = append(b.commands, commander.NewCommand(usage))
+	}
+
+	return s.register(func() {
+		bridges := make([]*bridge, len(s.bridges), len(s.bridges)+1)
+		copy(bridges, s.bridges)
+		s.bridges = append(bridges, b)
+	})
+}
+
+// forward sends the message through the first matching bridge, it reports whether the message
+// was forwarded and should not be handled here
+func (s *Slacker) forward(botCtx BotContext, text string) bool {
+	s.mutex.RLock()
+	bridges := s.bridges
+	s.mutex.RUnlock()
+
+	for _, b := range bridges {
+		if !b.matches(botCtx, text) {
+			continue
+		}
+
+		envelope := &BridgeEnvelope{Text: text, Event: botCtx.Event()}
+		if previous := bridgeEnvelopeFromContext(botCtx.Context()); previous != nil {
+			envelope.Path = append(envelope.Path, previous.Path...)
+		}
+		envelope.Path = append(envelope.Path, s.botUserID)
+
+		if err := forwardEnvelope(botCtx.Context(), b.definition.Target, envelope); err != nil {
+			fmt.Printf("failed forwarding message: %v\n", err)
+			return false
+		}
+		return !b.definition.Mirror
+	}
+	return false
+}
+
+func forwardEnvelope(ctx context.Context, target BridgeTarget, envelope *BridgeEnvelope) error {
+	if len(envelope.Path) > maxBridgeHops {
+		return ErrBridgeLoop
+	}
+	return target.Forward(ctx, envelope)
+}
+
+func (b *bridge) matches(botCtx BotContext, text string) bool {
+	for _, command := range b.commands {
+		if _, isMatch := command.Match(text); isMatch {
+			return true
+		}
+	}
+	return b.definition.Match != nil && b.definition.Match(botCtx, text)
+}
+
+func bridgeEnvelopeFromContext(ctx context.Context) *BridgeEnvelope {
+	envelope, _ := ctx.Value(bridgeEnvelopeKey{}).(*BridgeEnvelope)
+	return envelope
+}
+
+// NewBotTarget creates a target handing forwarded messages to the commands of another bot,
+// which replies with its own identity in the same conversation
+func NewBotTarget(bot *Slacker) BridgeTarget {
+	return &botTarget{bot: bot}
+}
+
+type botTarget struct {
+	bot *Slacker
+}
+
+// Forward runs the message through the bot's commands
+func (t *botTarget) Forward(ctx context.Context, envelope *BridgeEnvelope) error {
+	for _, userID := range envelope.Path {
+		if userID == t.bot.botUserID {
+			return ErrBridgeLoop
+		}
+	}
+
+	botCtx := t.bot.newBotContext(context.WithValue(ctx, bridgeEnvelopeKey{}, envelope), envelope.Event)
+	response := t.bot.withTranslation(botCtx, t.bot.newResponse(botCtx))
+	if !t.bot.executeCommand(botCtx, response, envelope.Text) {
+		return ErrBridgeUnhandled
+	}
+	return nil
+}
+
+// NewHTTPTarget creates a target posting forwarded messages as JSON to an external endpoint.
+// A nil client defaults to http.DefaultClient.
+func NewHTTPTarget(url string, client *http.Client) BridgeTarget {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTarget{url: url, client: client}
+}
+
+type httpTarget struct {
+	url    string
+	client *http.Client
+}
+
+// Forward posts the envelope, failing unless the endpoint responds with a 2xx status
+func (t *httpTarget) Forward(ctx context.Context, envelope *BridgeEnvelope) error {
+	// The raw event is left out, it is not meant to be serialized
+	event := *envelope.Event
+	event.Data = nil
+	body, err := json.Marshal(&BridgeEnvelope{Text: envelope.Text, Event: &event, Path: envelope.Path})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", bridgeContentType)
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(bridgeStatusError, response.StatusCode)
+	}
+	return nil
+}
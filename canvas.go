@@ -0,0 +1,271 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	canvasCreateMethod        = "canvases.create"
+	canvasEditMethod          = "canvases.edit"
+	canvasLookupMethod        = "canvases.sections.lookup"
+	channelCanvasCreateMethod = "conversations.canvases.create"
+	canvasMarkdownType        = "markdown"
+	canvasInsertAtEnd         = "insert_at_end"
+	canvasReplace             = "replace"
+	canvasAnyHeader           = "any_header"
+	canvasTemplateName        = "canvas"
+	featureCanvases           = "editing canvases"
+	scopeCanvasesWrite        = "canvases:write"
+	scopeCanvasesRead         = "canvases:read"
+	defaultCanvasRetryAfter   = time.Second
+	contentTypeJSONUTF8       = "application/json; charset=utf-8"
+)
+
+var (
+	errNoCanvasClient = errors.New("Canvases can only be edited while the bot is listening")
+)
+
+// CanvasContent is the markdown of a canvas or of a section of it, rendered from Template, a
+// text/template, with Data, such as the timeline of an incident or the steps of a runbook
+type CanvasContent struct {
+	Template string
+	Data     interface{}
+}
+
+// render executes the template of the content
+func (c CanvasContent) render() (string, error) {
+	tmpl, err := template.New(canvasTemplateName).Parse(c.Template)
+	if err != nil {
+		return empty, err
+	}
+
+	text := &bytes.Buffer{}
+	if err := tmpl.Execute(text, c.Data); err != nil {
+		return empty, err
+	}
+	return text.String(), nil
+}
+
+// canvasClient calls the canvas methods of the Web API, which the Slack client does not have
+type canvasClient struct {
+	httpClient *http.Client
+	apiURL     string
+	token      string
+}
+
+func newCanvasClient(httpClient *http.Client, apiURL string, token string) *canvasClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if len(apiURL) == 0 {
+		apiURL = slack.APIURL
+	}
+	return &canvasClient{httpClient: httpClient, apiURL: apiURL, token: token}
+}
+
+type canvasClientKey struct{}
+
+func withCanvasClient(ctx context.Context, client *canvasClient) context.Context {
+	return context.WithValue(ctx, canvasClientKey{}, client)
+}
+
+func canvasClientFromContext(ctx context.Context) (*canvasClient, error) {
+	client, ok := ctx.Value(canvasClientKey{}).(*canvasClient)
+	if !ok || client == nil {
+		return nil, errNoCanvasClient
+	}
+	return client, nil
+}
+
+// call posts the parameters to the method as JSON and decodes its response into result, retrying
+// when rate limited
+func (c *canvasClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return withRateLimitRetry(ctx, func() error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+method, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		request.Header.Set(headerContentType, contentTypeJSONUTF8)
+		request.Header.Set(authorizationHeader, fmt.Sprintf(bearerFormat, c.token))
+
+		response, err := c.httpClient.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode == http.StatusTooManyRequests {
+			retryAfter := defaultCanvasRetryAfter
+			if seconds, err := strconv.Atoi(response.Header.Get(retryAfterHeader)); err == nil {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+			return &slack.RateLimitedError{RetryAfter: retryAfter}
+		}
+		if response.StatusCode != http.StatusOK {
+			return slack.StatusCodeError{Code: response.StatusCode, Status: response.Status}
+		}
+
+		raw := &json.RawMessage{}
+		if err := json.NewDecoder(response.Body).Decode(raw); err != nil {
+			return err
+		}
+		status := &slack.SlackResponse{}
+		if err := json.Unmarshal(*raw, status); err != nil {
+			return err
+		}
+		if !status.Ok {
+			return slack.SlackErrorResponse{Err: status.Error, ResponseMetadata: status.ResponseMetadata}
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(*raw, result)
+	})
+}
+
+type canvasDocument struct {
+	Type     string `json:"type"`
+	Markdown string `json:"markdown"`
+}
+
+type canvasChange struct {
+	Operation       string          `json:"operation"`
+	SectionID       string          `json:"section_id,omitempty"`
+	DocumentContent *canvasDocument `json:"document_content,omitempty"`
+}
+
+type canvasCreated struct {
+	CanvasID string `json:"canvas_id"`
+}
+
+type canvasSections struct {
+	Sections []struct {
+		ID string `json:"id"`
+	} `json:"sections"`
+}
+
+// markdown renders the content into the document of a canvas
+func markdown(content CanvasContent) (*canvasDocument, error) {
+	text, err := content.render()
+	if err != nil {
+		return nil, err
+	}
+	return &canvasDocument{Type: canvasMarkdownType, Markdown: text}, nil
+}
+
+// CreateCanvas creates a standalone canvas with the title and the content, returning its ID.
+// It needs the canvases:write scope, like the other canvas helpers, and can be called from
+// handlers and jobs alike with their context.
+func CreateCanvas(ctx context.Context, title string, content CanvasContent) (string, error) {
+	client, err := canvasClientFromContext(ctx)
+	if err != nil {
+		return empty, err
+	}
+	document, err := markdown(content)
+	if err != nil {
+		return empty, err
+	}
+
+	created := &canvasCreated{}
+	params := map[string]interface{}{"title": title, "document_content": document}
+	if err := client.call(ctx, canvasCreateMethod, params, created); err != nil {
+		return empty, scopeError(ctx, err, featureCanvases, scopeCanvasesWrite)
+	}
+	return created.CanvasID, nil
+}
+
+// CreateChannelCanvas creates the canvas of the channel with the content, returning its ID.
+// Channels have a single canvas, which Slack refuses to create again.
+func CreateChannelCanvas(ctx context.Context, channelID string, content CanvasContent) (string, error) {
+	client, err := canvasClientFromContext(ctx)
+	if err != nil {
+		return empty, err
+	}
+	document, err := markdown(content)
+	if err != nil {
+		return empty, err
+	}
+
+	created := &canvasCreated{}
+	params := map[string]interface{}{"channel_id": channelID, "document_content": document}
+	if err := client.call(ctx, channelCanvasCreateMethod, params, created); err != nil {
+		return empty, scopeError(ctx, err, featureCanvases, scopeCanvasesWrite)
+	}
+	return created.CanvasID, nil
+}
+
+// UpdateCanvas replaces the whole content of the canvas
+func UpdateCanvas(ctx context.Context, canvasID string, content CanvasContent) error {
+	document, err := markdown(content)
+	if err != nil {
+		return err
+	}
+	return editCanvas(ctx, canvasID, &canvasChange{Operation: canvasReplace, DocumentContent: document})
+}
+
+// AppendToCanvas adds the content at the end of the canvas, such as an entry of a timeline
+func AppendToCanvas(ctx context.Context, canvasID string, content CanvasContent) error {
+	document, err := markdown(content)
+	if err != nil {
+		return err
+	}
+	return editCanvas(ctx, canvasID, &canvasChange{Operation: canvasInsertAtEnd, DocumentContent: document})
+}
+
+// UpdateCanvasSection replaces the first header of the canvas containing the heading with the
+// content, which should start with the header itself, or adds the content at the end of the
+// canvas when no header contains it. Finding the header needs the canvases:read scope.
+func UpdateCanvasSection(ctx context.Context, canvasID string, heading string, content CanvasContent) error {
+	client, err := canvasClientFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	document, err := markdown(content)
+	if err != nil {
+		return err
+	}
+
+	found := &canvasSections{}
+	params := map[string]interface{}{
+		"canvas_id": canvasID,
+		"criteria":  map[string]interface{}{"section_types": []string{canvasAnyHeader}, "contains_text": heading},
+	}
+	if err := client.call(ctx, canvasLookupMethod, params, found); err != nil {
+		return scopeError(ctx, err, featureCanvases, scopeCanvasesRead)
+	}
+
+	change := &canvasChange{Operation: canvasInsertAtEnd, DocumentContent: document}
+	if len(found.Sections) > 0 {
+		change = &canvasChange{Operation: canvasReplace, SectionID: found.Sections[0].ID, DocumentContent: document}
+	}
+	return editCanvas(ctx, canvasID, change)
+}
+
+// editCanvas applies the change to the canvas
+func editCanvas(ctx context.Context, canvasID string, change *canvasChange) error {
+	client, err := canvasClientFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{"canvas_id": canvasID, "changes": []*canvasChange{change}}
+	if err := client.call(ctx, canvasEditMethod, params, nil); err != nil {
+		return scopeError(ctx, err, featureCanvases, scopeCanvasesWrite)
+	}
+	return nil
+}
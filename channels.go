@@ -0,0 +1,158 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	errorNameTaken        = "name_taken"
+	errorInvalidName      = "invalid_name"
+	errorChannelNotFound  = "channel_not_found"
+	errorUserNotFound     = "user_not_found"
+	errorIsArchived       = "is_archived"
+	errorAlreadyInChannel = "already_in_channel"
+	errorCantInviteSelf   = "cant_invite_self"
+	errorAlreadyArchived  = "already_archived"
+	errorInternal         = "internal_error"
+	errorFatal            = "fatal_error"
+	errorUnavailable      = "service_unavailable"
+	errorRequestTimeout   = "request_timeout"
+)
+
+var (
+	// ErrChannelNameTaken is returned when creating a channel whose name is already used
+	ErrChannelNameTaken = errors.New("channel name already taken")
+	// ErrInvalidChannelName is returned when creating a channel with a name Slack does not accept
+	ErrInvalidChannelName = errors.New("invalid channel name")
+	// ErrChannelNotFound is returned when the channel does not exist or the bot cannot see it
+	ErrChannelNotFound = errors.New("channel not found")
+	// ErrChannelArchived is returned when changing a channel that is archived
+	ErrChannelArchived = errors.New("channel is archived")
+	// ErrUserNotFound is returned when inviting a user that does not exist
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// A ChannelManager interface is used to create and manage channels.
+// Operations whose outcome already holds, such as inviting a member of the channel
+// or archiving an archived channel, succeed without doing anything.
+type ChannelManager interface {
+	Create(name string, private bool) (*slack.Channel, error)
+	SetTopic(channelID string, topic string) error
+	SetPurpose(channelID string, purpose string) error
+	Invite(channelID string, userIDs ...string) error
+	Archive(channelID string) error
+}
+
+// NewChannelManager creates a new channel manager
+func NewChannelManager(ctx context.Context, client *slack.Client) ChannelManager {
+	return &channelManager{ctx: ctx, client: client}
+}
+
+type channelManager struct {
+	ctx    context.Context
+	client *slack.Client
+}
+
+// Create creates a public or private channel
+func (m *channelManager) Create(name string, private bool) (*slack.Channel, error) {
+	var channel *slack.Channel
+	err := withRateLimitRetry(m.ctx, func() (err error) {
+		channel, err = m.client.CreateConversationContext(m.ctx, name, private)
+		return err
+	})
+	return channel, scopeError(m.ctx, channelError(err), featureChannels, scopeChannels)
+}
+
+// SetTopic sets the topic of the channel
+func (m *channelManager) SetTopic(channelID string, topic string) error {
+	err := withRateLimitRetry(m.ctx, func() error {
+		_, err := m.client.SetTopicOfConversationContext(m.ctx, channelID, topic)
+		return err
+	})
+	return scopeError(m.ctx, channelError(err), featureChannels, scopeChannels)
+}
+
+// SetPurpose sets the purpose of the channel
+func (m *channelManager) SetPurpose(channelID string, purpose string) error {
+	err := withRateLimitRetry(m.ctx, func() error {
+		_, err := m.client.SetPurposeOfConversationContext(m.ctx, channelID, purpose)
+		return err
+	})
+	return scopeError(m.ctx, channelError(err), featureChannels, scopeChannels)
+}
+
+// Invite adds the users to the channel, users who are already members are skipped
+func (m *channelManager) Invite(channelID string, userIDs ...string) error {
+	for _, userID := range userIDs {
+		err := withRateLimitRetry(m.ctx, func() error {
+			_, err := m.client.InviteUsersToConversationContext(m.ctx, channelID, userID)
+			return err
+		})
+
+		// Users are invited one at a time so a single member does not fail the whole invite
+		switch slackErrorCode(err) {
+		case errorAlreadyInChannel, errorCantInviteSelf:
+			continue
+		}
+		if err != nil {
+			return scopeError(m.ctx, channelError(err), featureInvites, scopeInvites)
+		}
+	}
+	return nil
+}
+
+// Archive archives the channel
+func (m *channelManager) Archive(channelID string) error {
+	err := withRateLimitRetry(m.ctx, func() error {
+		return m.client.ArchiveConversationContext(m.ctx, channelID)
+	})
+
+	if slackErrorCode(err) == errorAlreadyArchived {
+		return nil
+	}
+	return scopeError(m.ctx, channelError(err), featureChannels, scopeChannels)
+}
+
+// channelError converts the Slack error codes callers are expected to handle into typed errors
+func channelError(err error) error {
+	code := slackErrorCode(err)
+	if strings.HasPrefix(code, errorInvalidName) {
+		return ErrInvalidChannelName
+	}
+
+	switch code {
+	case errorNameTaken:
+		return ErrChannelNameTaken
+	case errorChannelNotFound:
+		return ErrChannelNotFound
+	case errorIsArchived:
+		return ErrChannelArchived
+	case errorUserNotFound:
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// slackErrorCode returns the error code of a failed Slack API call
+func slackErrorCode(err error) string {
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) {
+		return slackErr.Err
+	}
+	return empty
+}
+
+// isPermanentError reports whether a failed Slack API call would fail the same way again, Slack
+// having refused it, as for a message that cannot be deleted or a channel the bot left, rather than
+// failing to handle it
+func isPermanentError(err error) bool {
+	switch code := slackErrorCode(err); code {
+	case empty, errorInternal, errorFatal, errorUnavailable, errorRequestTimeout:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package slacker
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+const (
+	chartDefaultWidth  = 600
+	chartDefaultHeight = 200
+	chartPadding       = 10
+	chartBarGap        = 4
+	chartExtension     = ".png"
+	chartFiletype      = "png"
+)
+
+var (
+	// ErrEmptyChart is returned when rendering a chart without values
+	ErrEmptyChart = errors.New("chart has no values")
+
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartForeground = color.RGBA{R: 0x1d, G: 0x9b, B: 0xd1, A: 0xff}
+	chartAxis       = color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+)
+
+// ChartKind is the kind of chart to render
+type ChartKind string
+
+const (
+	// BarChart draws a bar for each value
+	BarChart ChartKind = "bar"
+	// SparklineChart draws a line through the values
+	SparklineChart ChartKind = "sparkline"
+)
+
+// ChartSpec describes the chart to render. Width and Height are in pixels and have defaults.
+type ChartSpec struct {
+	Title  string
+	Kind   ChartKind
+	Labels []string
+	Values []float64
+	Width  int
+	Height int
+}
+
+// A ChartRenderer interface renders a chart as a PNG image
+type ChartRenderer interface {
+	Render(spec *ChartSpec) ([]byte, error)
+}
+
+// NewChartRenderer creates the built-in renderer, drawing bar charts and sparklines without labels
+func NewChartRenderer() ChartRenderer {
+	return &chartRenderer{}
+}
+
+type chartRenderer struct{}
+
+// Render draws the chart
+func (c *chartRenderer) Render(spec *ChartSpec) ([]byte, error) {
+	if len(spec.Values) == 0 {
+		return nil, ErrEmptyChart
+	}
+
+	width, height := spec.Width, spec.Height
+	if width <= 0 {
+		width = chartDefaultWidth
+	}
+	if height <= 0 {
+		height = chartDefaultHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	// Bars grow from zero, so it is always part of the scale
+	low, high := 0.0, 0.0
+	if spec.Kind == SparklineChart {
+		low, high = spec.Values[0], spec.Values[0]
+	}
+	for _, value := range spec.Values {
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	plot := image.Rect(chartPadding, chartPadding, width-chartPadding, height-chartPadding)
+	y := func(value float64) int {
+		return plot.Max.Y - int(math.Round((value-low)/(high-low)*float64(plot.Dy())))
+	}
+
+	switch spec.Kind {
+	case SparklineChart:
+		drawSparkline(img, plot, spec.Values, y)
+	default:
+		drawBars(img, plot, spec.Values, y(0), y)
+	}
+
+	data := &bytes.Buffer{}
+	if err := png.Encode(data, img); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+func drawBars(img *image.RGBA, plot image.Rectangle, values []float64, zero int, y func(float64) int) {
+	draw.Draw(img, image.Rect(plot.Min.X, zero, plot.Max.X, zero+1), &image.Uniform{C: chartAxis}, image.Point{}, draw.Src)
+
+	barWidth := plot.Dx() / len(values)
+	for i, value := range values {
+		left := plot.Min.X + i*barWidth
+		right := left + barWidth - chartBarGap
+		if right <= left {
+			right = left + 1
+		}
+
+		top, bottom := y(value), zero
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		draw.Draw(img, image.Rect(left, top, right, bottom), &image.Uniform{C: chartForeground}, image.Point{}, draw.Src)
+	}
+}
+
+func drawSparkline(img *image.RGBA, plot image.Rectangle, values []float64, y func(float64) int) {
+	if len(values) == 1 {
+		drawLine(img, plot.Min.X, y(values[0]), plot.Max.X, y(values[0]))
+		return
+	}
+
+	step := float64(plot.Dx()) / float64(len(values)-1)
+	for i := 1; i < len(values); i++ {
+		x0 := plot.Min.X + int(math.Round(float64(i-1)*step))
+		x1 := plot.Min.X + int(math.Round(float64(i)*step))
+		drawLine(img, x0, y(values[i-1]), x1, y(values[i]))
+	}
+}
+
+// drawLine draws a line two pixels thick from one point to the other
+func drawLine(img *image.RGBA, x0 int, y0 int, x1 int, y1 int) {
+	steps := int(math.Max(math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0))))
+	if steps == 0 {
+		steps = 1
+	}
+
+	for i := 0; i <= steps; i++ {
+		x := x0 + int(math.Round(float64((x1-x0)*i)/float64(steps)))
+		y := y0 + int(math.Round(float64((y1-y0)*i)/float64(steps)))
+		img.Set(x, y, chartForeground)
+		img.Set(x, y+1, chartForeground)
+	}
+}
+
+// ReplyChart renders the chart and uploads the image to the current channel
+func (r *response) ReplyChart(spec *ChartSpec, options ...ReplyOption) error {
+	defaults := NewReplyDefaults(options...)
+
+	data, err := defaults.ChartRenderer.Render(spec)
+	if err != nil {
+		return err
+	}
+
+	name := spec.Title
+	if len(name) == 0 {
+		name = string(spec.Kind)
+	}
+	return r.FileUpload(spec.Title, empty, name+chartExtension, chartFiletype, bytes.NewReader(data), options...)
+}
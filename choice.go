@@ -0,0 +1,38 @@
+package slacker
+
+import (
+	"github.com/slack-go/slack"
+)
+
+// NewOption creates an option of an overflow menu, radio buttons, checkboxes or static menu
+func NewOption(value string, text string) *slack.OptionBlockObject {
+	return slack.NewOptionBlockObject(value, slack.NewTextBlockObject(slack.PlainTextType, text, false, false), nil)
+}
+
+// NewOverflowMenu creates a menu of options behind a "..." button
+func NewOverflowMenu(actionID string, options ...*slack.OptionBlockObject) *slack.OverflowBlockElement {
+	return slack.NewOverflowBlockElement(actionID, options...)
+}
+
+// NewRadioButtons creates a group of options of which a single one can be picked
+func NewRadioButtons(actionID string, options ...*slack.OptionBlockObject) *slack.RadioButtonsBlockElement {
+	return slack.NewRadioButtonsBlockElement(actionID, options...)
+}
+
+// NewCheckboxes creates a group of options of which any number can be picked
+func NewCheckboxes(actionID string, options ...*slack.OptionBlockObject) *slack.CheckboxGroupsBlockElement {
+	return slack.NewCheckboxGroupsBlockElement(actionID, options...)
+}
+
+// SelectedValues returns the values of the options picked in an overflow menu, radio buttons,
+// checkboxes or static menu. Checkboxes report every option that is checked after the click.
+func SelectedValues(action *slack.BlockAction) []string {
+	values := []string{}
+	if len(action.SelectedOption.Value) > 0 {
+		values = append(values, action.SelectedOption.Value)
+	}
+	for _, option := range action.SelectedOptions {
+		values = append(values, option.Value)
+	}
+	return values
+}
@@ -0,0 +1,122 @@
+package slacker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock interface tells the time and waits for it to pass. The scheduler, cooldowns, throttles,
+// mutes, quiet hours, tasks, timers, retries and the default store use the clock set WithClock,
+// so that tests can advance a ManualClock instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the clock of the system, the default one
+type systemClock struct{}
+
+// Now returns the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to pass, then sends the current time
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type clockKey struct{}
+
+func withClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, clock)
+}
+
+// clockFromContext returns the clock of the bot, or the system clock outside of Listen
+func clockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return clock
+	}
+	return systemClock{}
+}
+
+// since returns the time elapsed since t according to the clock
+func since(clock Clock, t time.Time) time.Duration {
+	return clock.Now().Sub(t)
+}
+
+// ManualClock is a Clock whose time only changes when it is advanced or set
+type ManualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []*clockWaiter
+}
+
+type clockWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewManualClock creates a clock stopped at the time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// After sends the time of the clock once it was advanced by at least the duration
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	waiter := &clockWaiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		waiter.c <- c.now
+		return waiter.c
+	}
+	c.waiters = append(c.waiters, waiter)
+	return waiter.c
+}
+
+// Advance moves the clock forward by the duration, waking up those waiting until then
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to the time, waking up those waiting until then in the order they are due
+func (c *ManualClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = now
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].at.Before(c.waiters[j].at)
+	})
+
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.c <- now
+	}
+	c.waiters = pending
+}
+
+// Waiters returns how many are waiting for the clock to be advanced, so that tests can wait for
+// goroutines to block on it before advancing it
+func (c *ManualClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
@@ -0,0 +1,55 @@
+package slacker
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// A Codec interface encodes the values slacker's features keep in the Store, such as conversation
+// memory and histories. Values stored with one codec cannot be read with another, so changing the
+// codec of a store that already holds values needs a migration.
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, value interface{}) error
+}
+
+// JSONCodec returns the codec encoding values as JSON, the default one
+func JSONCodec() Codec {
+	return jsonCodec{}
+}
+
+// GobCodec returns a codec encoding values with gob, more compact than JSON. Values holding
+// interfaces, such as the blocks of messages held during quiet hours, need their concrete
+// types registered with gob.Register.
+func GobCodec() Codec {
+	return gobCodec{}
+}
+
+type jsonCodec struct{}
+
+// Marshal encodes the value as JSON
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Unmarshal decodes the JSON into the value
+func (jsonCodec) Unmarshal(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+type gobCodec struct{}
+
+// Marshal encodes the value with gob
+func (gobCodec) Marshal(value interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := gob.NewEncoder(buffer).Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Unmarshal decodes the gob data into the value
+func (gobCodec) Unmarshal(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
@@ -1,25 +1,79 @@
 package slacker
 
 import (
+	"strings"
+
 	"github.com/shomali11/commander"
 	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	multiLineSuffix = "..."
+	codeFence       = "```"
+	lineBreakMarker = "\x00"
 )
 
 // CommandDefinition structure contains definition of the bot command
 type CommandDefinition struct {
 	Description       string
 	Example           string
+	Parameters        []ParameterDefinition
 	AuthorizationFunc func(botCtx BotContext, request Request) bool
 	Handler           func(botCtx BotContext, request Request, response ResponseWriter)
+
+	// CollectFeedback appends rating buttons to the replies of the command when a FeedbackSink is set
+	CollectFeedback bool
+
+	// UndoFunc reverts the effects of Handler, it receives the request of the original execution
+	UndoFunc func(botCtx BotContext, request Request, response ResponseWriter)
+
+	// Interactive handles the interactions with the messages the command posted, such as button clicks
+	Interactive func(botCtx BotContext, action *slack.BlockAction, response ResponseWriter)
+
+	// Scopes are the OAuth scopes the handler needs beyond posting replies, such as "files:write"
+	Scopes []string
+
+	// CircuitBreaker short-circuits the command for a while when its handler keeps reporting errors
+	CircuitBreaker *CircuitBreaker
+
+	// RequireSecondApprover holds the command until a different user, also passing AuthorizationFunc,
+	// approves it with a button. Both users are kept in the audit records returned by Approvals.
+	RequireSecondApprover bool
+
+	// Middleware wraps Handler, inside the middleware added to the bot with Use
+	Middleware []MiddlewareFunc
+
+	// Pipe returns the output of the command as lines when it runs within a pipeline such as
+	// `list pods | grep api`, receiving those of the previous command, if any
+	Pipe func(botCtx BotContext, request Request, input []string) ([]string, error)
+
+	// err is returned by Command instead of registering a definition that could not be built
+	err error
 }
 
-// NewBotCommand creates a new bot command object
+// NewBotCommand creates a new bot command object.
+// A final parameter written as `<name...>` captures the rest of the message,
+// including line breaks and the contents of a code block.
 func NewBotCommand(usage string, definition *CommandDefinition) BotCommand {
-	command := commander.NewCommand(usage)
+	tokens := commander.NewCommand(usage).Tokenize()
+
+	format := usage
+	multiLineParameter := empty
+	if len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		if last.IsParameter() && strings.HasSuffix(last.Word, multiLineSuffix) {
+			multiLineParameter = strings.TrimSuffix(last.Word, multiLineSuffix)
+			format = strings.Replace(usage, last.Word, multiLineParameter, 1)
+		}
+	}
+
 	return &botCommand{
-		usage:      usage,
-		definition: definition,
-		command:    command,
+		usage:              usage,
+		definition:         definition,
+		command:            commander.NewCommand(format),
+		tokens:             tokens,
+		multiLineParameter: multiLineParameter,
 	}
 }
 
@@ -38,6 +92,9 @@ type botCommand struct {
 	usage      string
 	definition *CommandDefinition
 	command    *commander.Command
+	tokens     []*commander.Token
+
+	multiLineParameter string
 }
 
 // Usage returns the command usage
@@ -52,12 +109,34 @@ func (c *botCommand) Definition() *CommandDefinition {
 
 // Match determines whether the bot should respond based on the text received
 func (c *botCommand) Match(text string) (*proper.Properties, bool) {
-	return c.command.Match(text)
+	if c.multiLineParameter == empty {
+		return c.command.Match(text)
+	}
+
+	// Line breaks are swapped for a marker so that the final parameter can span them
+	parameters, isMatch := c.command.Match(strings.Replace(text, newLine, space+lineBreakMarker, -1))
+	if !isMatch {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+	for _, token := range c.command.Tokenize() {
+		if !token.IsParameter() || !hasParameter(parameters, token.Word) {
+			continue
+		}
+
+		value := restoreLineBreaks(parameters.StringParam(token.Word, empty))
+		if token.Word == c.multiLineParameter {
+			value = stripCodeFence(value)
+		}
+		values[token.Word] = value
+	}
+	return proper.NewProperties(values), true
 }
 
 // Tokenize returns the command format's tokens
 func (c *botCommand) Tokenize() []*commander.Token {
-	return c.command.Tokenize()
+	return c.tokens
 }
 
 // Execute executes the handler logic
@@ -67,3 +146,21 @@ func (c *botCommand) Execute(botCtx BotContext, request Request, response Respon
 	}
 	c.definition.Handler(botCtx, request, response)
 }
+
+func hasParameter(parameters *proper.Properties, key string) bool {
+	return parameters.StringParam(key, space) != space || parameters.StringParam(key, empty) != empty
+}
+
+func restoreLineBreaks(text string) string {
+	text = strings.Replace(text, space+lineBreakMarker, newLine, -1)
+	return strings.Replace(text, lineBreakMarker, newLine, -1)
+}
+
+// stripCodeFence removes the triple backticks surrounding a code block
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) < 2*len(codeFence) || !strings.HasPrefix(text, codeFence) || !strings.HasSuffix(text, codeFence) {
+		return text
+	}
+	return strings.TrimSpace(text[len(codeFence) : len(text)-len(codeFence)])
+}
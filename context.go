@@ -7,12 +7,20 @@ import (
 	"github.com/slack-go/slack/socketmode"
 )
 
-// A BotContext interface is used to respond to an event
+const (
+	bookmarkTypeLink = "link"
+)
+
+// A BotContext interface is used to respond to an event.
+// Applications can provide their own implementation with Slacker.CustomBotContext.
 type BotContext interface {
 	Context() context.Context
 	Event() *MessageEvent
 	SocketMode() *socketmode.Client
 	Client() *slack.Client
+	AddBookmark(channelID string, title string, link string) error
+	Channels() ChannelManager
+	Memory() ConversationMemory
 }
 
 // NewBotContext creates a new bot context
@@ -47,6 +55,26 @@ func (r *botContext) Client() *slack.Client {
 	return r.client
 }
 
+// AddBookmark adds a link to the bookmarks bar of the channel
+func (r *botContext) AddBookmark(channelID string, title string, link string) error {
+	_, err := r.client.AddBookmarkContext(r.ctx, channelID, slack.AddBookmarkParameters{
+		Title: title,
+		Type:  bookmarkTypeLink,
+		Link:  link,
+	})
+	return scopeError(r.ctx, err, featureBookmarks, scopeBookmarks)
+}
+
+// Channels returns a channel manager using the slack client
+func (r *botContext) Channels() ChannelManager {
+	return NewChannelManager(r.ctx, r.client)
+}
+
+// Memory returns the conversation memory of the event's user and thread
+func (r *botContext) Memory() ConversationMemory {
+	return NewConversationMemory(r.ctx, r.event)
+}
+
 // MessageEvent contains details common to message based events, including the
 // raw event as returned from Slack along with the corresponding event type.
 // The struct should be kept minimal and only include data that is commonly
@@ -0,0 +1,242 @@
+// Package secret adds a secret share command to a slacker bot. The secret typed in a modal is kept
+// encrypted in the bot's store, until the user it is shared with reveals it once, ephemerally.
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shomali11/slacker"
+	"github.com/slack-go/slack"
+)
+
+const (
+	shareCommand     = "secret share"
+	shareDescription = "Shares a secret that the chosen user can reveal once"
+	shareWizard      = "secret_share"
+	shareTitle       = "Share a secret"
+	sharePrompt      = "Share a secret that only the chosen user can reveal, once."
+	shareButton      = "Share a secret"
+	shareID          = "slacker_secret_share"
+	revealID         = "slacker_secret_reveal"
+	revealButton     = "Reveal"
+	secretBlockID    = "secret"
+	secretLabel      = "Secret"
+	recipientBlockID = "recipient"
+	recipientLabel   = "Shared with"
+	sharedFormat     = "<@%s> shared a secret with <@%s>, it can be revealed once."
+	revealedFormat   = "<@%s> revealed the secret of <@%s>, it was destroyed."
+	revealFormat     = "Secret shared by <@%s>:\n```%s```"
+	expiredText      = "This secret was revealed already or expired."
+	notYoursText     = "This secret was not shared with you."
+	keyPrefix        = "secret:"
+	idBytes          = 16
+	defaultTTL       = 24 * time.Hour
+)
+
+var (
+	// ErrMissingKeyProvider is returned when registering the module without a KeyProvider
+	ErrMissingKeyProvider = errors.New("secret sharing needs a key provider")
+)
+
+// sharedSecret is a secret waiting to be revealed
+type sharedSecret struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// Module shares secrets through the bot. Secrets and the messages offering to reveal them are
+// destroyed after TTL, a day by default, when they were not revealed.
+type Module struct {
+	TTL time.Duration
+
+	bot   *slacker.Slacker
+	store slacker.Store
+}
+
+// New creates the module, encrypting secrets with the keys of the provider
+func New(bot *slacker.Slacker, provider slacker.KeyProvider) (*Module, error) {
+	if provider == nil {
+		return nil, ErrMissingKeyProvider
+	}
+	return &Module{TTL: defaultTTL, bot: bot, store: slacker.NewEncryptedStore(bot.Store(), provider)}, nil
+}
+
+// Register adds the secret share command and its modal to the bot. The reveal button is handled
+// by Interact, to set as the bot's interaction handler or to call from it.
+func (m *Module) Register() error {
+	if err := m.bot.Wizard(shareWizard, &slacker.WizardDefinition{
+		Title:   shareTitle,
+		Steps:   []*slacker.WizardStep{{Title: shareTitle, Blocks: shareBlocks}},
+		Handler: m.share,
+	}); err != nil {
+		return err
+	}
+
+	return m.bot.Command(shareCommand, &slacker.CommandDefinition{
+		Description: shareDescription,
+		Handler:     m.shareHandler,
+	})
+}
+
+// Interact handles the buttons posted by the module, ignoring the other interactions
+func (m *Module) Interact(botCtx slacker.BotContext, response slacker.ResponseWriter, callbackID string, blockID string, actionID string, value string) {
+	callback, ok := botCtx.Event().Data.(*slack.InteractionCallback)
+	if !ok {
+		return
+	}
+
+	switch actionID {
+	case shareID:
+		if err := m.bot.OpenWizard(botCtx, shareWizard, callback.TriggerID); err != nil {
+			response.ReportError(err)
+		}
+	case revealID:
+		m.reveal(botCtx, response, callback, value)
+	}
+}
+
+// shareHandler opens the modal. Messages are answered with a button that opens it, since modals
+// can only be opened in response to an interaction.
+func (m *Module) shareHandler(botCtx slacker.BotContext, request slacker.Request, response slacker.ResponseWriter) {
+	ev := botCtx.Event()
+	if command, ok := ev.Data.(*slack.SlashCommand); ok {
+		if err := m.bot.OpenWizard(botCtx, shareWizard, command.TriggerID); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+
+	button := slack.NewButtonBlockElement(shareID, "", slack.NewTextBlockObject(slack.PlainTextType, shareButton, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, sharePrompt, false, false), nil, nil),
+		slack.NewActionBlock(shareID, button),
+	}
+
+	if err := response.Reply(sharePrompt, slacker.WithBlocks(blocks), slacker.WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func shareBlocks(values map[string]string) []slack.Block {
+	secret := slack.NewPlainTextInputBlockElement(nil, secretBlockID)
+	secret.Multiline = true
+	recipient := slack.NewOptionsSelectBlockElement(slack.OptTypeUser, nil, recipientBlockID)
+
+	return []slack.Block{
+		slack.NewInputBlock(secretBlockID, slack.NewTextBlockObject(slack.PlainTextType, secretLabel, false, false), nil, secret),
+		slack.NewInputBlock(recipientBlockID, slack.NewTextBlockObject(slack.PlainTextType, recipientLabel, false, false), nil, recipient),
+	}
+}
+
+// share keeps the submitted secret and posts the button revealing it
+func (m *Module) share(botCtx slacker.BotContext, response slacker.ResponseWriter, values map[string]string) {
+	ev := botCtx.Event()
+	secret := &sharedSecret{From: ev.User, To: values[recipientBlockID], Text: values[secretBlockID]}
+
+	id, err := newID()
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	value, err := json.Marshal(secret)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+	if err := m.store.Set(botCtx.Context(), keyPrefix+id, value, m.TTL); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	text := fmt.Sprintf(sharedFormat, secret.From, secret.To)
+	button := slack.NewButtonBlockElement(revealID, id, slack.NewTextBlockObject(slack.PlainTextType, revealButton, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewActionBlock(revealID, button),
+	}
+
+	if err := response.Reply(text, slacker.WithBlocks(blocks), slacker.WithRetention(m.TTL)); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// reveal shows the secret to the user it was shared with, and destroys it
+func (m *Module) reveal(botCtx slacker.BotContext, response slacker.ResponseWriter, callback *slack.InteractionCallback, id string) {
+	ctx := botCtx.Context()
+	ev := botCtx.Event()
+
+	secret, err := m.claim(ctx, keyPrefix+id, ev.User)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	text := expiredText
+	switch {
+	case secret == nil:
+	case secret.To != ev.User:
+		text = notYoursText
+	default:
+		text = fmt.Sprintf(revealFormat, secret.From, secret.Text)
+	}
+
+	if _, err := botCtx.Client().PostEphemeralContext(ctx, ev.Channel, ev.User, slack.MsgOptionText(text, false)); err != nil {
+		fmt.Printf("failed posting secret: %v\n", err)
+		return
+	}
+	if secret == nil || secret.To != ev.User {
+		return
+	}
+
+	_, _, _, err = botCtx.Client().UpdateMessageContext(ctx, callback.Container.ChannelID, callback.Container.MessageTs,
+		slack.MsgOptionText(fmt.Sprintf(revealedFormat, ev.User, secret.From), false), slack.MsgOptionBlocks([]slack.Block{}...))
+	if err != nil {
+		fmt.Printf("failed updating message: %v\n", err)
+	}
+}
+
+// claim returns the secret and destroys it when the user is its recipient, the swap making sure
+// that only one click reveals it. It returns nil when the secret is gone.
+func (m *Module) claim(ctx context.Context, key string, userID string) (*sharedSecret, error) {
+	value, err := m.store.Get(ctx, key)
+	if errors.Is(err, slacker.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil || len(value) == 0 {
+		return nil, err
+	}
+
+	secret := &sharedSecret{}
+	if err := json.Unmarshal(value, secret); err != nil {
+		return nil, err
+	}
+	if secret.To != userID {
+		return secret, nil
+	}
+
+	// The secret is swapped out for an empty value, which a second click finds instead
+	claimed, err := m.store.(slacker.CompareAndSwapper).CompareAndSwap(ctx, key, value, []byte{}, time.Minute)
+	if err != nil || !claimed {
+		return nil, err
+	}
+	if err := m.store.Delete(ctx, key); err != nil {
+		fmt.Printf("failed deleting secret: %v\n", err)
+	}
+	return secret, nil
+}
+
+func newID() (string, error) {
+	id := make([]byte, idBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,164 @@
+// Package sentry sends the errors reported by slacker handlers to Sentry
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shomali11/slacker"
+)
+
+const (
+	storeURLFormat  = "%s://%s/api/%s/store/"
+	authHeader      = "X-Sentry-Auth"
+	authFormat      = "Sentry sentry_version=7, sentry_client=slacker/1.0, sentry_key=%s"
+	statusFormat    = "sentry responded with status %d"
+	contentType     = "application/json"
+	platform        = "go"
+	levelError      = "error"
+	levelFatal      = "fatal"
+	panicType       = "panic"
+	eventIDBytes    = 16
+	sendTimeout     = 10 * time.Second
+	tagCommand      = "command"
+	tagChannel      = "channel"
+	tagTeam         = "team"
+	tagEventType    = "event_type"
+	extraParameters = "parameters"
+	extraStack      = "stack"
+)
+
+var (
+	// ErrInvalidDSN is returned when the DSN is not of the form https://key@host/project
+	ErrInvalidDSN = errors.New("invalid Sentry DSN")
+)
+
+// Reporter is a slacker.ErrorReporter sending errors to a Sentry project
+type Reporter struct {
+	storeURL    string
+	key         string
+	environment string
+	client      *http.Client
+}
+
+// NewReporter creates a reporter for the project of the DSN, tagging events with the environment
+func NewReporter(dsn string, environment string) (*Reporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	project := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || len(parsed.User.Username()) == 0 || len(project) == 0 {
+		return nil, ErrInvalidDSN
+	}
+
+	return &Reporter{
+		storeURL:    fmt.Sprintf(storeURLFormat, parsed.Scheme, parsed.Host, project),
+		key:         parsed.User.Username(),
+		environment: environment,
+		client:      &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+type event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Environment string                 `json:"environment,omitempty"`
+	Message     string                 `json:"message"`
+	Exception   []exception            `json:"exception"`
+	User        *user                  `json:"user,omitempty"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra"`
+}
+
+type exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type user struct {
+	ID string `json:"id"`
+}
+
+// Report sends the error to Sentry, printing why if it could not be sent
+func (r *Reporter) Report(ctx context.Context, report *slacker.ErrorReport) {
+	if err := r.send(ctx, r.event(report)); err != nil {
+		fmt.Printf("failed reporting error to Sentry: %v\n", err)
+	}
+}
+
+func (r *Reporter) event(report *slacker.ErrorReport) *event {
+	ev := &event{
+		EventID:     eventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       levelError,
+		Platform:    platform,
+		Environment: r.environment,
+		Message:     report.Err.Error(),
+		Exception:   []exception{{Type: fmt.Sprintf("%T", report.Err), Value: report.Err.Error()}},
+		Tags:        map[string]string{tagCommand: report.Command},
+		Extra: map[string]interface{}{
+			extraParameters: report.Parameters,
+			extraStack:      string(report.Stack),
+		},
+	}
+
+	if report.Panic != nil {
+		ev.Level = levelFatal
+		ev.Exception[0].Type = panicType
+	}
+
+	// The text of the message is left out, as it holds the values of Secret parameters in clear
+	if report.Event != nil {
+		ev.User = &user{ID: report.Event.User}
+		ev.Tags[tagChannel] = report.Event.Channel
+		ev.Tags[tagTeam] = report.Event.TeamID
+		ev.Tags[tagEventType] = report.Event.Type
+	}
+	return ev
+}
+
+func (r *Reporter) send(ctx context.Context, ev *event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set(authHeader, fmt.Sprintf(authFormat, r.key))
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf(statusFormat, response.StatusCode)
+	}
+	return nil
+}
+
+func eventID() string {
+	id := make([]byte, eventIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return strings.Repeat("0", 2*eventIDBytes)
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,189 @@
+// Package sqlstore keeps the state of slacker's features in SQLite, for bots running as a single
+// binary, or in Postgres, for bots running several replicas. It uses database/sql, so the program
+// imports the driver of its choice and opens the database.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shomali11/slacker"
+)
+
+const (
+	placeholder       = "?"
+	postgresParameter = "$%d"
+
+	sqliteSchema = `CREATE TABLE IF NOT EXISTS slacker_store (
+	name TEXT PRIMARY KEY,
+	value BLOB NOT NULL,
+	expires_at INTEGER NOT NULL
+)`
+	postgresSchema = `CREATE TABLE IF NOT EXISTS slacker_store (
+	name TEXT PRIMARY KEY,
+	value BYTEA NOT NULL,
+	expires_at BIGINT NOT NULL
+)`
+	expiryIndex = `CREATE INDEX IF NOT EXISTS slacker_store_expires_at ON slacker_store (expires_at)`
+
+	getQuery    = `SELECT value FROM slacker_store WHERE name = ? AND (expires_at = 0 OR expires_at > ?)`
+	setQuery    = `INSERT INTO slacker_store (name, value, expires_at) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`
+	insertQuery = `INSERT INTO slacker_store (name, value, expires_at) VALUES (?, ?, ?) ON CONFLICT (name) DO NOTHING`
+	swapQuery   = `UPDATE slacker_store SET value = ?, expires_at = ? WHERE name = ? AND value = ? AND (expires_at = 0 OR expires_at > ?)`
+	deleteQuery = `DELETE FROM slacker_store WHERE name = ?`
+	keysQuery   = `SELECT name FROM slacker_store WHERE substr(name, 1, length(?)) = ? AND (expires_at = 0 OR expires_at > ?)`
+	expireQuery = `DELETE FROM slacker_store WHERE name = ? AND expires_at > 0 AND expires_at <= ?`
+	sweepQuery  = `DELETE FROM slacker_store WHERE expires_at > 0 AND expires_at <= ?`
+)
+
+// Store is a slacker.Store keeping its values in the slacker_store table, which it creates when
+// missing. Expired values are hidden until the sweep job of the bot removes them.
+type Store struct {
+	db     *sql.DB
+	rebind func(query string) string
+}
+
+// NewSQLiteStore creates a store in the SQLite database
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*Store, error) {
+	return newStore(ctx, db, sqliteSchema, func(query string) string { return query })
+}
+
+// NewPostgresStore creates a store in the Postgres database
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*Store, error) {
+	return newStore(ctx, db, postgresSchema, numberParameters)
+}
+
+func newStore(ctx context.Context, db *sql.DB, schema string, rebind func(query string) string) (*Store, error) {
+	for _, statement := range []string{schema, expiryIndex} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed creating the store schema: %w", err)
+		}
+	}
+	return &Store{db: db, rebind: rebind}, nil
+}
+
+// numberParameters replaces the ? placeholders of the query with the numbered ones of Postgres
+func numberParameters(query string) string {
+	parts := strings.Split(query, placeholder)
+	builder := strings.Builder{}
+	for i, part := range parts {
+		if i > 0 {
+			builder.WriteString(fmt.Sprintf(postgresParameter, i))
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
+
+// Get returns the value of the key
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	value := []byte{}
+	err := s.db.QueryRowContext(ctx, s.rebind(getQuery), key, now()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, slacker.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Set sets the value of the key, a ttl of zero never expires
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(setQuery), key, nonNil(value), expiresAt(ttl))
+	return err
+}
+
+// CompareAndSwap sets the value of the key if it still holds the old value, a nil old value
+// meaning the key must not exist
+func (s *Store) CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error) {
+	if old != nil {
+		result, err := s.db.ExecContext(ctx, s.rebind(swapQuery), nonNil(new), expiresAt(ttl), key, old, now())
+		return swapped(result, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, s.rebind(expireQuery), key, now()); err != nil {
+		return false, err
+	}
+
+	ok, err := swapped(tx.ExecContext(ctx, s.rebind(insertQuery), key, nonNil(new), expiresAt(ttl)))
+	if err != nil {
+		return false, err
+	}
+	return ok, tx.Commit()
+}
+
+// Delete removes the key
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(deleteQuery), key)
+	return err
+}
+
+// Keys returns the keys starting with the prefix
+func (s *Store) Keys(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(keysQuery), prefix, prefix, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Sweep removes the expired values, returning how many it removed
+func (s *Store) Sweep(ctx context.Context) (int, error) {
+	result, err := s.db.ExecContext(ctx, s.rebind(sweepQuery), now())
+	if err != nil {
+		return 0, err
+	}
+
+	swept, err := result.RowsAffected()
+	return int(swept), err
+}
+
+func swapped(result sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// nonNil stores nil values as empty ones, the value column not being nullable
+func nonNil(value []byte) []byte {
+	if value == nil {
+		return []byte{}
+	}
+	return value
+}
+
+func now() int64 {
+	return time.Now().UnixNano()
+}
+
+// expiresAt returns when a value set now with the ttl expires, zero never expiring
+func expiresAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
@@ -0,0 +1,253 @@
+package sqlstore_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/shomali11/slacker"
+	"github.com/shomali11/slacker/contrib/sqlstore"
+	"github.com/shomali11/slacker/slackmock"
+)
+
+func newSQLiteStore(t *testing.T) *sqlstore.Store {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "slacker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite allows a single writer, the connection serializing the concurrent tests
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	store, err := sqlstore.NewSQLiteStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestGetMissingKey(t *testing.T) {
+	store := newSQLiteStore(t)
+
+	if _, err := store.Get(context.Background(), "missing"); !errors.Is(err, slacker.ErrKeyNotFound) {
+		t.Fatalf("got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestSetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	if err := store.Set(ctx, "history:T1:U1", []byte("first"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "history:T1:U1", []byte("second"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := store.Get(ctx, "history:T1:U1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != "second" {
+		t.Fatalf("got %q, want the value set last", value)
+	}
+
+	if err := store.Delete(ctx, "history:T1:U1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "history:T1:U1"); !errors.Is(err, slacker.ErrKeyNotFound) {
+		t.Fatalf("got %v after deleting, want ErrKeyNotFound", err)
+	}
+}
+
+func TestNilValue(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	if err := store.Set(ctx, "claimed", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	value, err := store.Get(ctx, "claimed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) != 0 {
+		t.Fatalf("got %q, want an empty value", value)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	if err := store.Set(ctx, "expiring", []byte("soon"), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "lasting", []byte("forever"), 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := store.Get(ctx, "expiring"); !errors.Is(err, slacker.ErrKeyNotFound) {
+		t.Fatalf("got %v for an expired key, want ErrKeyNotFound", err)
+	}
+	keys, err := store.Keys(ctx, empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "lasting" {
+		t.Fatalf("got keys %v, want the expired one hidden", keys)
+	}
+
+	swept, err := store.Sweep(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swept != 1 {
+		t.Fatalf("swept %d values, want 1", swept)
+	}
+}
+
+func TestKeysPrefix(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	// Prefixes are compared literally, LIKE wildcards included
+	for _, key := range []string{"quiet:T1:a", "quiet:T1:b", "quiet:T2:a", "quietly", "quiet%:T1", "undo:T1"} {
+		if err := store.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "quiet:", want: []string{"quiet:T1:a", "quiet:T1:b", "quiet:T2:a"}},
+		{prefix: "quiet:T1:", want: []string{"quiet:T1:a", "quiet:T1:b"}},
+		{prefix: "quiet%", want: []string{"quiet%:T1"}},
+		{prefix: "missing:", want: []string{}},
+	}
+	for _, test := range tests {
+		keys, err := store.Keys(ctx, test.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(keys)
+		if strings.Join(keys, ",") != strings.Join(test.want, ",") {
+			t.Errorf("prefix %q: got keys %v, want %v", test.prefix, keys, test.want)
+		}
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	ok, err := store.CompareAndSwap(ctx, "claim", nil, []byte("one"), 0)
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v creating a missing key, want it created", ok, err)
+	}
+	ok, err = store.CompareAndSwap(ctx, "claim", nil, []byte("two"), 0)
+	if err != nil || ok {
+		t.Fatalf("got %v, %v creating an existing key, want it refused", ok, err)
+	}
+	ok, err = store.CompareAndSwap(ctx, "claim", []byte("stale"), []byte("two"), 0)
+	if err != nil || ok {
+		t.Fatalf("got %v, %v swapping a stale value, want it refused", ok, err)
+	}
+	ok, err = store.CompareAndSwap(ctx, "claim", []byte("one"), []byte("two"), 0)
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v swapping the current value, want it swapped", ok, err)
+	}
+
+	value, err := store.Get(ctx, "claim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, []byte("two")) {
+		t.Fatalf("got %q, want the swapped value", value)
+	}
+}
+
+func TestCompareAndSwapExpiredKey(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	if err := store.Set(ctx, "claim", []byte("old"), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// An expired key no longer exists, even before it is swept
+	ok, err := store.CompareAndSwap(ctx, "claim", nil, []byte("new"), 0)
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v creating an expired key, want it created", ok, err)
+	}
+}
+
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	const claimers = 10
+	winners := make(chan int, claimers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < claimers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := store.CompareAndSwap(ctx, "ran:T1:C1:1.000001", nil, []byte("claimed"), time.Hour)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				winners <- i
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(winners)
+
+	if count := len(winners); count != 1 {
+		t.Fatalf("%d claimers won, want exactly one", count)
+	}
+}
+
+func TestBotState(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	bot, err := slacker.NewClient("xoxb-test", "xapp-test", append(server.ClientOptions(), slacker.WithStore(store))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The state of the bot's features goes through the store
+	if err := bot.SetUserQuietHours(ctx, "T1", "U1", &slacker.QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := store.Keys(ctx, empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || !strings.Contains(keys[0], "U1") {
+		t.Fatalf("got keys %v, want the user's quiet hours", keys)
+	}
+}
+
+const empty = ""
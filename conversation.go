@@ -0,0 +1,175 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	conversationKeyPrefix  = "conversation"
+	conversationMaxRetries = 5
+	charactersPerToken     = 4
+)
+
+var (
+	// ErrConversationMemoryUnavailable is returned when the bot context was not created by a listening bot
+	ErrConversationMemoryUnavailable = errors.New("conversation memory is unavailable outside of Listen")
+	// ErrConversationConflict is returned when the conversation kept changing while remembering an exchange
+	ErrConversationConflict = errors.New("conversation memory was changed concurrently, please try again")
+)
+
+// Exchange is a message addressed to the bot and the bot's reply to it
+type Exchange struct {
+	Timestamp time.Time `json:"timestamp"`
+	Prompt    string    `json:"prompt"`
+	Reply     string    `json:"reply"`
+}
+
+// A ConversationMemory interface keeps the last exchanges between the bot and a user
+// in a thread, or at the top level of a channel, so handlers can answer in context
+type ConversationMemory interface {
+	Exchanges() ([]*Exchange, error)
+	Remember(prompt string, reply string) error
+	Forget() error
+}
+
+type conversationsKey struct{}
+
+// conversations holds the configuration shared by every conversation memory
+type conversations struct {
+	store       Store
+	codec       Codec
+	clock       Clock
+	swap        func(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)
+	size        int
+	ttl         time.Duration
+	tokenBudget int
+}
+
+func newConversations(defaults *ClientDefaults, swap func(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)) *conversations {
+	return &conversations{
+		store:       defaults.Store,
+		codec:       defaults.Codec,
+		clock:       defaults.Clock,
+		swap:        swap,
+		size:        defaults.ConversationSize,
+		ttl:         defaults.ConversationTTL,
+		tokenBudget: defaults.ConversationTokenBudget,
+	}
+}
+
+func withConversations(ctx context.Context, conversations *conversations) context.Context {
+	return context.WithValue(ctx, conversationsKey{}, conversations)
+}
+
+func conversationsFromContext(ctx context.Context) *conversations {
+	conversations, _ := ctx.Value(conversationsKey{}).(*conversations)
+	return conversations
+}
+
+// NewConversationMemory creates the conversation memory of the event's user and thread
+func NewConversationMemory(ctx context.Context, ev *MessageEvent) ConversationMemory {
+	return &conversationMemory{
+		ctx:           ctx,
+		conversations: conversationsFromContext(ctx),
+		key:           storeKey(conversationKeyPrefix, ev.TeamID, ev.User, ev.Channel, ev.ThreadTimeStamp),
+	}
+}
+
+type conversationMemory struct {
+	ctx           context.Context
+	conversations *conversations
+	key           string
+}
+
+// Exchanges returns the remembered exchanges, the oldest first
+func (m *conversationMemory) Exchanges() ([]*Exchange, error) {
+	_, exchanges, err := m.load()
+	return exchanges, err
+}
+
+// load returns the stored exchanges along with their encoding, nil when there are none
+func (m *conversationMemory) load() ([]byte, []*Exchange, error) {
+	if m.conversations == nil {
+		return nil, nil, ErrConversationMemoryUnavailable
+	}
+
+	exchanges := []*Exchange{}
+	data, err := m.conversations.store.Get(m.ctx, m.key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, exchanges, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.conversations.codec.Unmarshal(data, &exchanges); err != nil {
+		return nil, nil, err
+	}
+	return data, exchanges, nil
+}
+
+// Remember adds an exchange, forgetting the oldest ones beyond the size and token budget.
+// Exchanges remembered concurrently in the same conversation are all kept.
+func (m *conversationMemory) Remember(prompt string, reply string) error {
+	if m.conversations == nil {
+		return ErrConversationMemoryUnavailable
+	}
+
+	exchange := &Exchange{Timestamp: m.conversations.clock.Now(), Prompt: prompt, Reply: reply}
+	for attempt := 0; attempt < conversationMaxRetries; attempt++ {
+		data, exchanges, err := m.load()
+		if err != nil {
+			return err
+		}
+
+		updated, err := m.conversations.codec.Marshal(m.conversations.trim(append(exchanges, exchange)))
+		if err != nil {
+			return err
+		}
+
+		// Another exchange was remembered first, try again with it
+		swapped, err := m.conversations.swap(m.ctx, m.key, data, updated, m.conversations.ttl)
+		if err != nil || swapped {
+			return err
+		}
+	}
+	return ErrConversationConflict
+}
+
+// Forget removes every exchange
+func (m *conversationMemory) Forget() error {
+	if m.conversations == nil {
+		return ErrConversationMemoryUnavailable
+	}
+	return m.conversations.store.Delete(m.ctx, m.key)
+}
+
+// trim drops the oldest exchanges until they fit in the size and token budget,
+// always keeping the latest one
+func (c *conversations) trim(exchanges []*Exchange) []*Exchange {
+	if c.size > 0 && len(exchanges) > c.size {
+		exchanges = exchanges[len(exchanges)-c.size:]
+	}
+
+	if c.tokenBudget <= 0 {
+		return exchanges
+	}
+
+	tokens := 0
+	for _, exchange := range exchanges {
+		tokens += exchange.tokens()
+	}
+	for len(exchanges) > 1 && tokens > c.tokenBudget {
+		tokens -= exchanges[0].tokens()
+		exchanges = exchanges[1:]
+	}
+	return exchanges
+}
+
+// tokens estimates the number of tokens of the exchange
+func (e *Exchange) tokens() int {
+	characters := utf8.RuneCountInString(e.Prompt) + utf8.RuneCountInString(e.Reply)
+	return (characters + charactersPerToken - 1) / charactersPerToken
+}
@@ -0,0 +1,165 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	timerCountdownFormat = ":stopwatch: *%s*\n%s left"
+	timerElapsedFormat   = ":stopwatch: *%s*\n%s elapsed"
+	timerFinishedFormat  = ":alarm_clock: *%s*\nTime is up"
+	timerStoppedFormat   = ":stopwatch: *%s*\n%s"
+	defaultTimerInterval = 10 * time.Second
+	minTimerInterval     = time.Second
+	maxTimerInterval     = time.Minute
+)
+
+// A Timer interface is used to stop a message showing a countdown or the time elapsed.
+// Done is closed once the countdown is over or the timer was stopped.
+type Timer interface {
+	Stop(text string) error
+	Done() <-chan struct{}
+}
+
+// newTimer posts the timer's message and updates it every interval from its own goroutine.
+// When Slack rate limits the updates, the interval doubles up to a minute.
+func newTimer(botCtx BotContext, title string, until time.Time, options ...ReplyOption) *timer {
+	defaults := NewReplyDefaults(options...)
+	ev := botCtx.Event()
+
+	interval := defaults.TimerInterval
+	if interval < minTimerInterval {
+		interval = minTimerInterval
+	}
+
+	clock := clockFromContext(botCtx.Context())
+	t := &timer{
+		title:     title,
+		client:    botCtx.Client(),
+		channel:   ev.Channel,
+		until:     until,
+		clock:     clock,
+		startedAt: clock.Now(),
+		interval:  interval,
+		stopped:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	opts := []slack.MsgOption{slack.MsgOptionText(t.text(), false)}
+	if defaults.ThreadResponse {
+		opts = append(opts, slack.MsgOptionTS(ev.MakeThreadTimestamp()))
+	}
+
+	_, timestamp, err := t.client.PostMessageContext(context.Background(), t.channel, opts...)
+	if err != nil {
+		fmt.Printf("failed posting timer: %v\n", err)
+		close(t.done)
+		return t
+	}
+	t.timestamp = timestamp
+
+	go t.run()
+	return t
+}
+
+type timer struct {
+	mutex     sync.Mutex
+	title     string
+	client    *slack.Client
+	channel   string
+	timestamp string
+	until     time.Time
+	clock     Clock
+	startedAt time.Time
+	interval  time.Duration
+	finished  bool
+	stopped   chan struct{}
+	done      chan struct{}
+}
+
+// Stop replaces the timer with the text, or its elapsed time when empty, and stops updating it
+func (t *timer) Stop(text string) error {
+	t.mutex.Lock()
+	if t.finished {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.finished = true
+	close(t.stopped)
+	t.mutex.Unlock()
+
+	<-t.done
+	if len(text) == 0 {
+		text = fmt.Sprintf(timerElapsedFormat, t.title, since(t.clock, t.startedAt).Round(time.Second))
+	} else {
+		text = fmt.Sprintf(timerStoppedFormat, t.title, text)
+	}
+	return t.update(text)
+}
+
+// Done returns a channel closed when the timer is over
+func (t *timer) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *timer) run() {
+	defer close(t.done)
+
+	for {
+		select {
+		case <-t.stopped:
+			return
+		case <-t.clock.After(t.interval):
+		}
+
+		if !t.until.IsZero() && !t.clock.Now().Before(t.until) {
+			t.mutex.Lock()
+			t.finished = true
+			t.mutex.Unlock()
+
+			if err := t.update(fmt.Sprintf(timerFinishedFormat, t.title)); err != nil {
+				fmt.Printf("failed updating timer: %v\n", err)
+			}
+			return
+		}
+
+		err := t.update(t.text())
+		var rateLimited *slack.RateLimitedError
+		if errors.As(err, &rateLimited) {
+			t.interval *= 2
+			if t.interval > maxTimerInterval {
+				t.interval = maxTimerInterval
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Printf("failed updating timer: %v\n", err)
+		}
+	}
+}
+
+func (t *timer) text() string {
+	if t.until.IsZero() {
+		return fmt.Sprintf(timerElapsedFormat, t.title, since(t.clock, t.startedAt).Round(time.Second))
+	}
+
+	// The countdown is rounded up so that it reads zero only once the time is up
+	left := t.until.Sub(t.clock.Now())
+	if rounded := left.Round(time.Second); rounded < left {
+		left = rounded + time.Second
+	} else {
+		left = rounded
+	}
+	return fmt.Sprintf(timerCountdownFormat, t.title, left)
+}
+
+func (t *timer) update(text string) error {
+	_, _, _, err := t.client.UpdateMessageContext(context.Background(), t.channel, t.timestamp, slack.MsgOptionText(text, false))
+	return err
+}
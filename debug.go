@@ -0,0 +1,36 @@
+package slacker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	eventDumpFormat    = "event %s: %s\n"
+	matcherTracePrefix = "matcher: "
+)
+
+// dumpEvent prints a sample of the events received from Slack
+func (s *Slacker) dumpEvent(evt socketmode.Event) {
+	if s.eventDumpRate <= 0 || rand.Float64() >= s.eventDumpRate {
+		return
+	}
+
+	data, err := json.Marshal(evt.Data)
+	if err != nil {
+		fmt.Printf(eventDumpFormat, evt.Type, fmt.Sprintf("%+v", evt.Data))
+		return
+	}
+	fmt.Printf(eventDumpFormat, evt.Type, data)
+}
+
+// tracef prints a matcher trace when enabled
+func (s *Slacker) tracef(format string, args ...interface{}) {
+	if !s.matcherTrace {
+		return
+	}
+	fmt.Printf(matcherTracePrefix+format+newLine, args...)
+}
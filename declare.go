@@ -0,0 +1,143 @@
+package slacker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	commandTag          = "cmd"
+	exampleTag          = "example"
+	authorizationTag    = "auth"
+	scopesTag           = "scopes"
+	adminsAuthorization = "admins"
+	handlerPrefix       = "Handle"
+	undoPrefix          = "Undo"
+	declarationFormat   = "%w: %s"
+	missingHandlerError = "no method %s for field %s"
+	handlerSignature    = "method %s must be func(BotContext, Request or a struct, ResponseWriter)"
+	authorizerSignature = "method %s must be func(BotContext, Request) bool"
+	notStructPointer    = "expected a pointer to a struct, got %T"
+)
+
+var (
+	// ErrInvalidDeclaration is returned when the commands of a struct given to Register are not well formed
+	ErrInvalidDeclaration = errors.New("invalid command declaration")
+
+	botContextType     = reflect.TypeOf((*BotContext)(nil)).Elem()
+	requestType        = reflect.TypeOf((*Request)(nil)).Elem()
+	responseWriterType = reflect.TypeOf((*ResponseWriter)(nil)).Elem()
+)
+
+// Register defines the commands declared by the fields of a struct, so that commands can be kept
+// in their own types and files. Each field with a `cmd` tag giving its usage, such as
+//
+//	Deploy struct{} `cmd:"deploy <env>" desc:"Deploys the service" auth:"admins"`
+//
+// is a command handled by the method named Handle followed by the field's name. The method receives
+// either the Request or a struct decoded as with Typed. A method named Undo followed by the field's
+// name becomes its UndoFunc. The `desc`, `example` and `scopes` tags fill the definition, and the
+// `auth` tag names the method authorizing the command, or "admins" for the admins chosen during
+// setup, the workspace's admins and owners before that. No command is defined unless all of them
+// are well formed.
+func (s *Slacker) Register(commands interface{}) error {
+	value := reflect.ValueOf(commands)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf(declarationFormat, ErrInvalidDeclaration, fmt.Sprintf(notStructPointer, commands))
+	}
+
+	declared := []BotCommand{}
+	structType := value.Elem().Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		usage, ok := field.Tag.Lookup(commandTag)
+		if !ok {
+			continue
+		}
+
+		definition, err := s.declaredDefinition(value, field)
+		if err != nil {
+			return fmt.Errorf(declarationFormat, ErrInvalidDeclaration, err)
+		}
+		declared = append(declared, NewBotCommand(usage, definition))
+	}
+
+	return s.register(func() {
+		for _, cmd := range declared {
+			s.addCommand(cmd)
+		}
+		if s.initialized {
+			s.appendUndoHandle()
+		}
+	})
+}
+
+func (s *Slacker) declaredDefinition(value reflect.Value, field reflect.StructField) (*CommandDefinition, error) {
+	handlerName := handlerPrefix + field.Name
+	method := value.MethodByName(handlerName)
+	if !method.IsValid() {
+		return nil, fmt.Errorf(missingHandlerError, handlerName, field.Name)
+	}
+
+	definition, err := declaredHandler(handlerName, method)
+	if err != nil {
+		return nil, err
+	}
+
+	definition.Description = field.Tag.Get(descriptionTag)
+	definition.Example = field.Tag.Get(exampleTag)
+	if scopes := field.Tag.Get(scopesTag); len(scopes) > 0 {
+		for _, scope := range strings.Split(scopes, scopesSeparator) {
+			definition.Scopes = append(definition.Scopes, strings.TrimSpace(scope))
+		}
+	}
+
+	if undo := value.MethodByName(undoPrefix + field.Name); undo.IsValid() {
+		if !isRequestHandler(undo.Type()) {
+			return nil, fmt.Errorf(handlerSignature, undoPrefix+field.Name)
+		}
+		definition.UndoFunc = undo.Interface().(func(BotContext, Request, ResponseWriter))
+	}
+
+	switch authorization := field.Tag.Get(authorizationTag); authorization {
+	case empty:
+	case adminsAuthorization:
+		definition.AuthorizationFunc = s.setupAuthorization
+		definition.Scopes = append(definition.Scopes, scopeUsersRead)
+	default:
+		authorizer := value.MethodByName(authorization)
+		if !authorizer.IsValid() {
+			return nil, fmt.Errorf(missingHandlerError, authorization, field.Name)
+		}
+		authorizationFunc, ok := authorizer.Interface().(func(BotContext, Request) bool)
+		if !ok {
+			return nil, fmt.Errorf(authorizerSignature, authorization)
+		}
+		definition.AuthorizationFunc = authorizationFunc
+	}
+	return definition, nil
+}
+
+// declaredHandler creates the definition running the method, decoding its parameters if it takes a struct
+func declaredHandler(name string, method reflect.Value) (*CommandDefinition, error) {
+	if isRequestHandler(method.Type()) {
+		return &CommandDefinition{Handler: method.Interface().(func(BotContext, Request, ResponseWriter))}, nil
+	}
+
+	methodType := method.Type()
+	if methodType.NumIn() != 3 || methodType.NumOut() != 0 ||
+		methodType.In(0) != botContextType || methodType.In(1).Kind() != reflect.Struct || methodType.In(2) != responseWriterType {
+		return nil, fmt.Errorf(handlerSignature, name)
+	}
+
+	return typedDefinition(methodType.In(1), func(botCtx BotContext, params reflect.Value, response ResponseWriter) {
+		method.Call([]reflect.Value{reflect.ValueOf(&botCtx).Elem(), params, reflect.ValueOf(&response).Elem()})
+	})
+}
+
+func isRequestHandler(methodType reflect.Type) bool {
+	return methodType.NumIn() == 3 && methodType.NumOut() == 0 &&
+		methodType.In(0) == botContextType && methodType.In(1) == requestType && methodType.In(2) == responseWriterType
+}
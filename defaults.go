@@ -1,30 +1,681 @@
 package slacker
 
-import "github.com/slack-go/slack"
+import (
+	"net/http"
+	"time"
+
+	"github.com/slack-go/slack"
+)
 
 // ClientOption an option for client values
 type ClientOption func(*ClientDefaults)
 
-// WithDebug sets debug toggle
+// WithDebug sets debug toggle for both the API and Socket Mode clients
 func WithDebug(debug bool) ClientOption {
 	return func(defaults *ClientDefaults) {
 		defaults.Debug = debug
+		defaults.APIDebug = debug
+		defaults.SocketModeDebug = debug
+	}
+}
+
+// WithAPIDebug sets debug toggle for the API client
+func WithAPIDebug(debug bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.APIDebug = debug
+	}
+}
+
+// WithSocketModeDebug sets debug toggle for the Socket Mode client
+func WithSocketModeDebug(debug bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.SocketModeDebug = debug
+	}
+}
+
+// WithEventDump prints received events, sampled at a rate between 0 and 1
+func WithEventDump(sampleRate float64) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EventDumpRate = sampleRate
+	}
+}
+
+// WithMatcherTrace prints why each command did or did not match a message
+func WithMatcherTrace(trace bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.MatcherTrace = trace
+	}
+}
+
+// WithMentionAnywhere allows commands to be triggered when the bot is mentioned
+// anywhere in a message, rather than only at the start of it
+func WithMentionAnywhere(mentionAnywhere bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.MentionAnywhere = mentionAnywhere
+	}
+}
+
+// WithStopWords sets words that are stripped from a message before it is matched against commands
+func WithStopWords(stopWords ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.StopWords = stopWords
+	}
+}
+
+// WithModalFallback opens a modal to collect required parameters that were left out of a command
+func WithModalFallback(modalFallback bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ModalFallback = modalFallback
+	}
+}
+
+// WithStore sets the store used to persist state, it defaults to an in-memory store
+func WithStore(store Store) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Store = store
+	}
+}
+
+// WithHistory keeps the last commands of each user and enables the `history` and `redo` commands
+func WithHistory(size int) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.HistorySize = size
+	}
+}
+
+// WithUndoWindow sets how long after its execution a command can be undone
+func WithUndoWindow(undoWindow time.Duration) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.UndoWindow = undoWindow
+	}
+}
+
+// WithHotReload allows commands and handlers to be registered while the bot is listening
+func WithHotReload(hotReload bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.HotReload = hotReload
+	}
+}
+
+// WithUnroutedSampling persists a sample of the messages addressed to the bot that matched
+// no command or handler, at a rate between 0 and 1, for the given duration
+func WithUnroutedSampling(sampleRate float64, ttl time.Duration) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.UnroutedSampleRate = sampleRate
+		defaults.UnroutedTTL = ttl
+	}
+}
+
+// WithFeedback enables the `feedback` command and rating buttons on the replies of
+// commands that collect feedback, sending the results to the sink
+func WithFeedback(sink FeedbackSink) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.FeedbackSink = sink
+	}
+}
+
+// WithConversationMemory sets how many exchanges the conversation memory keeps per user and thread,
+// for how long, and the estimated number of tokens they may add up to, 0 meaning no limit
+func WithConversationMemory(size int, ttl time.Duration, tokenBudget int) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ConversationSize = size
+		defaults.ConversationTTL = ttl
+		defaults.ConversationTokenBudget = tokenBudget
+	}
+}
+
+// WithSemanticMatching maps messages that match no command to the command closest in meaning,
+// as long as their similarity, between -1 and 1, reaches the threshold
+func WithSemanticMatching(provider EmbeddingProvider, threshold float64) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EmbeddingProvider = provider
+		defaults.SemanticThreshold = threshold
+	}
+}
+
+// WithTranslator sets the language commands are written in. Messages matching no command are
+// matched against the commands translated to the language of the user's Slack locale, and replies
+// are translated to it.
+func WithTranslator(translator Translator, language string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Translator = translator
+		defaults.Language = language
+	}
+}
+
+// WithScopeAlerts sends a direct message to the user the first time an OAuth scope
+// needed by one of the bot's features is found missing
+func WithScopeAlerts(userID string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ScopeAlertUser = userID
+	}
+}
+
+// WithSetup adds a setup command letting workspace admins pick default channels, admin users
+// and which of the features are enabled, saving them in the store. See Slacker.Settings.
+func WithSetup(features ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Setup = true
+		defaults.SetupFeatures = features
+	}
+}
+
+// WithWorkerPool handles events in the pool, bounding how many run at the same time.
+// The same pool can be given to several bots.
+func WithWorkerPool(pool *WorkerPool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.WorkerPool = pool
+	}
+}
+
+// WithConcurrency handles at most n events at the same time in a worker pool of the bot's own,
+// unless one is set WithWorkerPool. Messages and slash commands wait for a free worker in the
+// queue set WithQueueSize.
+func WithConcurrency(n int) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Concurrency = n
+	}
+}
+
+// WithQueueSize lets up to n events wait for a free worker of the pool set WithConcurrency before
+// the bot stops reading the next ones
+func WithQueueSize(n int) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.QueueSize = n
+	}
+}
+
+// WithErrorPresenter chooses how errors reported by handlers are shown to users,
+// such as with an ErrorTemplates mapping errors to templates and error codes
+func WithErrorPresenter(presenter ErrorPresenter) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ErrorPresenter = presenter
+	}
+}
+
+// WithErrorReporter sends the errors reported by handlers, and their panics, to an error tracker
+func WithErrorReporter(reporter ErrorReporter) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ErrorReporter = reporter
+	}
+}
+
+// WithEventPooling reuses message events and requests across messages to reduce allocations
+// on busy workspaces. Handlers must then not keep the event or request after returning,
+// including from goroutines they start; copy what is needed instead.
+func WithEventPooling(pooling bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EventPooling = pooling
+	}
+}
+
+// WithIgnoredBots ignores messages posted by the bots
+func WithIgnoredBots(botIDs ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.IgnoredBots = botIDs
+	}
+}
+
+// WithIgnoredApps ignores messages posted by the bots of the apps
+func WithIgnoredApps(appIDs ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.IgnoredApps = appIDs
+	}
+}
+
+// WithIgnoreAllBots ignores messages posted by any bot, including workflows and Slackbot,
+// except those allowed with WithAllowedBots or WithAllowedApps
+func WithIgnoreAllBots(ignore bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.IgnoreAllBots = ignore
+	}
+}
+
+// WithIgnoreSlackbot ignores messages posted by Slackbot
+func WithIgnoreSlackbot(ignore bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.IgnoreSlackbot = ignore
+	}
+}
+
+// WithAllowedBots responds to the bots even when bots are ignored
+func WithAllowedBots(botIDs ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.AllowedBots = botIDs
+	}
+}
+
+// WithAllowedApps responds to the bots of the apps even when bots are ignored
+func WithAllowedApps(appIDs ...string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.AllowedApps = appIDs
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to call the Slack API, for proxies and custom timeouts
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.HTTPClient = client
+	}
+}
+
+// WithAPIURL sets the base URL of the Slack API, ending with a slash, such as a recording proxy
+func WithAPIURL(url string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.APIURL = url
+	}
+}
+
+// WithAttachmentMatching matches commands against the attachments of messages, and the messages
+// of other integrations without a mention, when the text of the message matched none
+func WithAttachmentMatching(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.AttachmentMatching = enabled
+	}
+}
+
+// WithMute adds the admin-only mute and unmute commands, silencing the bot in a channel for a while
+func WithMute(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.MuteCommands = enabled
+	}
+}
+
+// WithQuietHours sets the quiet hours of every channel, which SetQuietHours and SetUserQuietHours refine
+func WithQuietHours(hours *QuietHours) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.QuietHours = hours
+	}
+}
+
+// WithReceipts adds the admin-only receipts command, showing who has not acknowledged a broadcast
+func WithReceipts(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ReceiptsCommand = enabled
+	}
+}
+
+// WithMetrics sends the measurements of the bot, such as how long handlers run, to the metrics
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Metrics = metrics
+	}
+}
+
+// WithMetricLabels sets the labels of the command metrics, such as the channel, and how their
+// number of values is bounded
+func WithMetricLabels(labels *MetricLabels) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.MetricLabels = labels
+	}
+}
+
+// WithSlowHandlerThreshold warns about commands whose handler runs longer than the threshold
+func WithSlowHandlerThreshold(threshold time.Duration) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.SlowHandlerThreshold = threshold
+	}
+}
+
+// WithEventBacklogThreshold warns when at least this many socket mode events are waiting to be handled
+func WithEventBacklogThreshold(threshold int) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EventBacklogThreshold = threshold
+	}
+}
+
+// WithStatus records the recent runs of each command and adds the admin-only status command summarizing them
+func WithStatus(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.StatusCommand = enabled
+	}
+}
+
+// WithFaultInjection delays or fails Slack API calls and drops event acks on purpose, for testing in staging
+func WithFaultInjection(faults *FaultInjection) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.FaultInjection = faults
+	}
+}
+
+// WithClock sets the clock telling the time to the scheduler, cooldowns, throttles, tasks, timers and the default store
+func WithClock(clock Clock) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Clock = clock
+	}
+}
+
+// WithCodec sets how the values kept in the store are encoded, JSON by default
+func WithCodec(codec Codec) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Codec = codec
+	}
+}
+
+// WithStoreSweep sets how often the expired values of the store are removed, every 10 minutes by
+// default. Zero disables sweeping, for stores expiring values by themselves.
+func WithStoreSweep(period time.Duration) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.StoreSweepPeriod = period
+	}
+}
+
+// WithStoreNamespace keeps the values of the bot under the namespace, so that several bots can
+// share a store
+func WithStoreNamespace(namespace string) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.StoreNamespace = namespace
+	}
+}
+
+// WithStoreEncryption encrypts the values kept in the store with keys of the provider, so that
+// linked tokens, preferences and conversation memory are encrypted at rest whatever the store
+func WithStoreEncryption(provider KeyProvider) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.StoreKeyProvider = provider
+	}
+}
+
+// WithForgetMe adds the forget me command, deleting everything the bot stored about the user
+// invoking it
+func WithForgetMe(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ForgetMeCommand = enabled
+	}
+}
+
+// WithSudo adds the sudo command, granting admin privileges for a while to the users it authorizes
+func WithSudo(definition *SudoDefinition) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Sudo = definition
+	}
+}
+
+// WithEnvelopeLogging prints samples of the envelopes received from Slack and of the Slack API
+// calls, with their message contents and tokens redacted
+func WithEnvelopeLogging(logging *EnvelopeLogging) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EnvelopeLogging = logging
+	}
+}
+
+// WithDiagnostics adds the admin-only diagnostics command, reporting the runtime state of the bot
+// and uploading pprof profiles to the admin channel
+func WithDiagnostics(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.DiagnosticsCommand = enabled
+	}
+}
+
+// WithDelayedCommands adds the at and in commands, running any other command later as the user
+// who scheduled it, along with the scheduled and unschedule commands
+func WithDelayedCommands(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.DelayedCommands = enabled
+	}
+}
+
+// WithRecurringCommands adds the every command, letting admins run any other command on a
+// schedule such as `every weekday 9am run standup-report`, along with the recurring commands
+// listing, pausing and deleting them. They are kept in the Store and run by the job scheduler.
+func WithRecurringCommands(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.RecurringCommands = enabled
+	}
+}
+
+// WithBatchCommands runs messages holding several commands, one per line or separated by `&&`
+// such as `deploy api && deploy web`, one after the other. Their replies and a summary go to the
+// thread of the message, and a command reporting an error skips those after it.
+func WithBatchCommands(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.BatchCommands = enabled
+	}
+}
+
+// WithPipelines runs messages such as `list pods | grep api | restart`, piping the output of the
+// Pipe of each command into the next one, along with the grep, head and count filters
+func WithPipelines(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.Pipelines = enabled
+	}
+}
+
+// WithArchivePolicy mirrors the replies of the commands the policy covers into its archive channel
+func WithArchivePolicy(policy *ArchivePolicy) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.ArchivePolicy = policy
+	}
+}
+
+// WithEditedCommands runs the commands of messages edited by their sender, such as to fix a typo.
+// Each message runs commands once, so an edit does not run anything when its original or an
+// earlier edit already did.
+func WithEditedCommands(enabled bool) ClientOption {
+	return func(defaults *ClientDefaults) {
+		defaults.EditedCommands = enabled
 	}
 }
 
 // ClientDefaults configuration
 type ClientDefaults struct {
-	Debug bool
+	Debug           bool
+	APIDebug        bool
+	SocketModeDebug bool
+	EventDumpRate   float64
+	MatcherTrace    bool
+	MentionAnywhere bool
+	StopWords       []string
+	ModalFallback   bool
+	Store           Store
+	HistorySize     int
+	UndoWindow      time.Duration
+	HotReload       bool
+
+	UnroutedSampleRate float64
+	UnroutedTTL        time.Duration
+	FeedbackSink       FeedbackSink
+
+	ConversationSize        int
+	ConversationTTL         time.Duration
+	ConversationTokenBudget int
+
+	EmbeddingProvider EmbeddingProvider
+	SemanticThreshold float64
+
+	Translator Translator
+	Language   string
+
+	ScopeAlertUser string
+
+	Setup         bool
+	SetupFeatures []string
+
+	WorkerPool  *WorkerPool
+	Concurrency int
+	QueueSize   int
+
+	ErrorPresenter ErrorPresenter
+	ErrorReporter  ErrorReporter
+
+	EventPooling bool
+
+	IgnoredBots    []string
+	IgnoredApps    []string
+	IgnoreAllBots  bool
+	IgnoreSlackbot bool
+	AllowedBots    []string
+	AllowedApps    []string
+
+	HTTPClient *http.Client
+	APIURL     string
+
+	AttachmentMatching bool
+
+	MuteCommands bool
+
+	QuietHours *QuietHours
+
+	ReceiptsCommand bool
+
+	Metrics               Metrics
+	MetricLabels          *MetricLabels
+	SlowHandlerThreshold  time.Duration
+	EventBacklogThreshold int
+
+	StatusCommand bool
+
+	FaultInjection *FaultInjection
+
+	Clock Clock
+
+	Codec Codec
+
+	StoreSweepPeriod time.Duration
+	StoreNamespace   string
+
+	StoreKeyProvider KeyProvider
+
+	ForgetMeCommand bool
+
+	Sudo *SudoDefinition
+
+	EnvelopeLogging *EnvelopeLogging
+
+	DiagnosticsCommand bool
+
+	DelayedCommands bool
+
+	RecurringCommands bool
+
+	BatchCommands bool
+
+	Pipelines bool
+
+	ArchivePolicy *ArchivePolicy
+
+	EditedCommands bool
 }
 
 func newClientDefaults(options ...ClientOption) *ClientDefaults {
 	config := &ClientDefaults{
-		Debug: false,
+		Debug:           false,
+		APIDebug:        false,
+		SocketModeDebug: false,
+		EventDumpRate:   0,
+		MatcherTrace:    false,
+		MentionAnywhere: false,
+		StopWords:       []string{},
+		ModalFallback:   false,
+		Store:           nil,
+		HistorySize:     0,
+		UndoWindow:      10 * time.Minute,
+		HotReload:       false,
+
+		UnroutedSampleRate: 0,
+		UnroutedTTL:        7 * 24 * time.Hour,
+		FeedbackSink:       nil,
+
+		ConversationSize:        10,
+		ConversationTTL:         24 * time.Hour,
+		ConversationTokenBudget: 0,
+
+		EmbeddingProvider: nil,
+		SemanticThreshold: 0.8,
+
+		Translator: nil,
+		Language:   "en",
+
+		ScopeAlertUser: "",
+
+		Setup:         false,
+		SetupFeatures: []string{},
+
+		WorkerPool:  nil,
+		Concurrency: 0,
+		QueueSize:   0,
+
+		ErrorPresenter: nil,
+		ErrorReporter:  nil,
+
+		EventPooling: false,
+
+		IgnoredBots:    []string{},
+		IgnoredApps:    []string{},
+		IgnoreAllBots:  false,
+		IgnoreSlackbot: false,
+		AllowedBots:    []string{},
+		AllowedApps:    []string{},
+
+		HTTPClient: nil,
+		APIURL:     "",
+
+		AttachmentMatching: false,
+
+		MuteCommands: false,
+
+		QuietHours: nil,
+
+		ReceiptsCommand: false,
+
+		Metrics:               nil,
+		MetricLabels:          &MetricLabels{Command: true},
+		SlowHandlerThreshold:  0,
+		EventBacklogThreshold: 0,
+
+		StatusCommand: false,
+
+		FaultInjection: nil,
+
+		Clock: nil,
+
+		Codec: nil,
+
+		StoreSweepPeriod: defaultStoreSweepPeriod,
+		StoreNamespace:   "",
+
+		StoreKeyProvider: nil,
+
+		ForgetMeCommand: false,
+
+		Sudo: nil,
+
+		EnvelopeLogging: nil,
+
+		DiagnosticsCommand: false,
+
+		DelayedCommands: false,
+
+		RecurringCommands: false,
+
+		BatchCommands: false,
+
+		Pipelines: false,
+
+		ArchivePolicy: nil,
+
+		EditedCommands: false,
 	}
 
 	for _, option := range options {
 		option(config)
 	}
+
+	if config.Codec == nil {
+		config.Codec = JSONCodec()
+	}
+	if config.Clock == nil {
+		config.Clock = systemClock{}
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStoreWithClock(config.Clock)
+	}
+	if config.StoreKeyProvider != nil {
+		config.Store = NewEncryptedStore(config.Store, config.StoreKeyProvider)
+	}
+	if len(config.StoreNamespace) > 0 {
+		config.Store = NewNamespacedStore(config.Store, config.StoreNamespace)
+	}
 	return config
 }
 
@@ -52,11 +703,70 @@ func WithThreadReply(useThread bool) ReplyOption {
 	}
 }
 
+// WithTableEncoder sets the file format of tables sent with ReplyTable, it defaults to CSV
+func WithTableEncoder(encoder TableEncoder) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.TableEncoder = encoder
+	}
+}
+
+// WithChartRenderer sets the renderer of charts sent with ReplyChart, it defaults to the built-in renderer
+func WithChartRenderer(renderer ChartRenderer) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.ChartRenderer = renderer
+	}
+}
+
+// WithCritical sends the reply even in a channel where the bot was muted, or right away during quiet hours
+func WithCritical(critical bool) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.Critical = critical
+	}
+}
+
+// WithTimerInterval sets how often the message of StartTimer is updated, it defaults to 10 seconds
+func WithTimerInterval(interval time.Duration) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.TimerInterval = interval
+	}
+}
+
+// WithRetention deletes the message once the duration passed, for output such as temporary
+// credentials or join links
+func WithRetention(ttl time.Duration) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.Retention = ttl
+	}
+}
+
+// WithRedaction replaces the text of a message with a retention by the redaction once it expires,
+// instead of deleting it
+func WithRedaction(redaction string) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.Redaction = redaction
+	}
+}
+
+// WithArchive mirrors the reply into the archive channel, saying who ran which command where
+// with a link back to its message, whether or not the ArchivePolicy covers the command
+func WithArchive(channelID string) ReplyOption {
+	return func(defaults *ReplyDefaults) {
+		defaults.Archive = channelID
+	}
+}
+
 // ReplyDefaults configuration
 type ReplyDefaults struct {
 	Attachments    []slack.Attachment
 	Blocks         []slack.Block
 	ThreadResponse bool
+	TableEncoder   TableEncoder
+	ChartRenderer  ChartRenderer
+	Critical       bool
+	TimerInterval  time.Duration
+	Retention      time.Duration
+	Redaction      string
+	Archive        string
 }
 
 // NewReplyDefaults builds our ReplyDefaults from zero or more ReplyOption.
@@ -65,6 +775,13 @@ func NewReplyDefaults(options ...ReplyOption) *ReplyDefaults {
 		Attachments:    []slack.Attachment{},
 		Blocks:         []slack.Block{},
 		ThreadResponse: false,
+		TableEncoder:   NewCSVEncoder(),
+		ChartRenderer:  NewChartRenderer(),
+		Critical:       false,
+		TimerInterval:  defaultTimerInterval,
+		Retention:      0,
+		Redaction:      "",
+		Archive:        "",
 	}
 
 	for _, option := range options {
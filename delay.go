@@ -0,0 +1,373 @@
+package slacker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	delayedKeyPrefix      = "delayed"
+	delayedJob            = "delayed-commands"
+	delayedJobDesc        = "Runs the commands scheduled with at and in once they are due"
+	delayedPeriod         = 30 * time.Second
+	delayedRecordGrace    = 7 * 24 * time.Hour
+	delayedIDBytes        = 4
+	atCommand             = "at <time?> <command...>"
+	atDescription         = "Runs a command at a time of day, such as `at 17:00 deploy prod`"
+	inCommand             = "in <duration?> <command...>"
+	inDescription         = "Runs a command after a while, such as `in 2h restart worker`"
+	scheduledCommand      = "scheduled"
+	scheduledDescription  = "Lists the commands you scheduled with at and in"
+	unscheduleCommand     = "unschedule <id>"
+	unscheduleDescription = "Cancels a command you scheduled, by the ID that scheduled lists"
+	delayedTimeParam      = "time"
+	delayedDurationParam  = "duration"
+	delayedCommandParam   = "command"
+	delayedIDParam        = "id"
+	delayedClockFormat    = "15:04"
+	delayedScheduledText  = "`%s` will run %s, cancel it with `unschedule %s`"
+	delayedEntryFormat    = "`%s` `%s` %s"
+	delayedCancelledText  = "`%s` will not run"
+	noDelayedMessage      = "You have not scheduled any commands"
+	timeType              = "time such as 17:00"
+)
+
+var (
+	errDelayUnknownCommand = errors.New("There is no such command to schedule")
+	errDelayInPast         = errors.New("That time has passed already")
+	errDelayNotFound       = errors.New("There is no scheduled command with that ID")
+)
+
+// delayedCommand is a command waiting for the time it was scheduled at
+type delayedCommand struct {
+	ID              string            `json:"id"`
+	Usage           string            `json:"usage"`
+	Parameters      map[string]string `json:"parameters"`
+	TeamID          string            `json:"team"`
+	User            string            `json:"user"`
+	Channel         string            `json:"channel"`
+	TimeStamp       string            `json:"ts"`
+	ThreadTimeStamp string            `json:"thread_ts"`
+	ScheduledAt     time.Time         `json:"scheduled_at"`
+	RunAt           time.Time         `json:"run_at"`
+}
+
+// key returns the key of the command, under those of its user
+func (d *delayedCommand) key() string {
+	return storeKey(delayedKeyPrefix, d.TeamID, d.User, d.ID)
+}
+
+// scheduleDelayedCommands adds the job running the delayed commands when they are enabled, so
+// that commands scheduled before a restart run too
+func (s *Slacker) scheduleDelayedCommands() error {
+	if !s.delayedCommands {
+		return nil
+	}
+
+	return s.scheduler.add(delayedJob, &JobDefinition{
+		Description: delayedJobDesc,
+		Schedule:    Every(delayedPeriod),
+		Handler:     s.runDelayedCommands,
+	})
+}
+
+// appendDelayHandles adds the commands scheduling other commands when they are enabled, it is
+// called with the lock held. At and in come first, since the commands they schedule would match
+// the messages otherwise.
+func (s *Slacker) appendDelayHandles() {
+	if !s.delayedCommands {
+		return
+	}
+
+	at := NewBotCommand(atCommand, &CommandDefinition{
+		Description: atDescription,
+		Example:     "at 17:00 deploy prod",
+		Handler:     s.atHandler,
+	})
+	in := NewBotCommand(inCommand, &CommandDefinition{
+		Description: inDescription,
+		Example:     "in 2h restart worker",
+		Handler:     s.inHandler,
+	})
+	s.botCommands = append([]BotCommand{&builtinCommand{BotCommand: &leadingCommand{BotCommand: at}}, &builtinCommand{BotCommand: &leadingCommand{BotCommand: in}}}, s.botCommands...)
+
+	s.addBuiltinCommand(NewBotCommand(scheduledCommand, &CommandDefinition{
+		Description: scheduledDescription,
+		Handler:     s.scheduledHandler,
+	}))
+	s.addBuiltinCommand(NewBotCommand(unscheduleCommand, &CommandDefinition{
+		Description: unscheduleDescription,
+		Example:     "unschedule 1a2b3c4d",
+		Handler:     s.unscheduleHandler,
+	}))
+}
+
+// leadingCommand only matches the messages starting with its first word, so that a command tried
+// before the others does not catch messages merely containing that word
+type leadingCommand struct {
+	BotCommand
+}
+
+func (c *leadingCommand) Match(text string) (*proper.Properties, bool) {
+	fields := strings.Fields(text)
+	tokens := c.Tokenize()
+	if len(fields) == 0 || len(tokens) == 0 || !strings.EqualFold(fields[0], tokens[0].Word) {
+		return nil, false
+	}
+	return c.BotCommand.Match(text)
+}
+
+func (s *Slacker) atHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	value := request.Param(delayedTimeParam)
+	now := s.clock.Now()
+
+	// A time of day is the next one to come, a full timestamp is taken as is
+	runAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		clock, err := time.ParseInLocation(delayedClockFormat, value, now.Location())
+		if err != nil {
+			response.ReportError(fmt.Errorf(invalidTypeError, delayedTimeParam, timeType))
+			return
+		}
+
+		runAt = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !runAt.After(now) {
+			runAt = runAt.AddDate(0, 0, 1)
+		}
+	}
+	s.delayCommand(botCtx, response, request.Param(delayedCommandParam), runAt)
+}
+
+func (s *Slacker) inHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	duration, err := time.ParseDuration(request.Param(delayedDurationParam))
+	if err != nil || duration <= 0 {
+		response.ReportError(fmt.Errorf(invalidTypeError, delayedDurationParam, durationType))
+		return
+	}
+	s.delayCommand(botCtx, response, request.Param(delayedCommandParam), s.clock.Now().Add(duration))
+}
+
+// delayCommand keeps the command matching the text until it is due. Authorization is checked now
+// for early feedback, and once more when the command runs.
+func (s *Slacker) delayCommand(botCtx BotContext, response ResponseWriter, text string, runAt time.Time) {
+	now := s.clock.Now()
+	if !runAt.After(now) {
+		response.ReportError(errDelayInPast)
+		return
+	}
+
+	cmd, parameters := s.matcher.indexFor(s.commands()).match(text)
+	if cmd == nil || isDelayCommand(cmd) {
+		response.ReportError(errDelayUnknownCommand)
+		return
+	}
+
+	definition := cmd.Definition()
+	if definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx, s.newRequest(botCtx, parameters)) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	id := make([]byte, delayedIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	ev := botCtx.Event()
+	delayed := &delayedCommand{
+		ID:              hex.EncodeToString(id),
+		Usage:           cmd.Usage(),
+		Parameters:      parameterValues(cmd, parameters),
+		TeamID:          ev.TeamID,
+		User:            ev.User,
+		Channel:         ev.Channel,
+		TimeStamp:       ev.TimeStamp,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		ScheduledAt:     now,
+		RunAt:           runAt,
+	}
+	if err := s.saveValue(botCtx.Context(), delayed.key(), delayed, runAt.Sub(now)+delayedRecordGrace); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	text = fmt.Sprintf(delayedScheduledText, s.redactedInvocation(delayed.Usage, delayed.Parameters), formatDelayedTime(runAt), delayed.ID)
+	if err := response.Reply(text, WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func (s *Slacker) scheduledHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	delayed, err := s.loadDelayedCommands(botCtx.Context(), storeKey(delayedKeyPrefix, ev.TeamID, ev.User))
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if len(delayed) == 0 {
+		if err := response.Reply(noDelayedMessage, WithThreadReply(ev.IsThread())); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+
+	lines := []string{}
+	for _, command := range delayed {
+		lines = append(lines, fmt.Sprintf(delayedEntryFormat, command.ID, s.redactedInvocation(command.Usage, command.Parameters), formatDelayedTime(command.RunAt)))
+	}
+	if err := response.Reply(strings.Join(lines, newLine), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// unscheduleHandler cancels a command of the user, or of anyone for the admins
+func (s *Slacker) unscheduleHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ctx := botCtx.Context()
+	ev := botCtx.Event()
+	id := request.Param(delayedIDParam)
+
+	prefix := storeKey(delayedKeyPrefix, ev.TeamID, ev.User)
+	if s.setupAuthorization(botCtx, request) {
+		prefix = storeKey(delayedKeyPrefix, ev.TeamID)
+	}
+
+	delayed, err := s.loadDelayedCommands(ctx, prefix)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	for _, command := range delayed {
+		if command.ID != id {
+			continue
+		}
+
+		if err := s.store.Delete(ctx, command.key()); err != nil {
+			response.ReportError(err)
+			return
+		}
+		if err := response.Reply(fmt.Sprintf(delayedCancelledText, s.redactedInvocation(command.Usage, command.Parameters)), WithThreadReply(ev.IsThread())); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+	response.ReportError(errDelayNotFound)
+}
+
+// runDelayedCommands runs the commands that are due as the users who scheduled them, so that
+// their authorization is checked again, in case it was revoked since
+func (s *Slacker) runDelayedCommands(jobCtx JobContext) error {
+	ctx := jobCtx.Context()
+	keys, err := s.store.Keys(ctx, delayedKeyPrefix+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		delayed := &delayedCommand{}
+		found, err := s.loadValue(ctx, key, delayed)
+		if err != nil {
+			return err
+		}
+		if !found || len(delayed.ID) == 0 || s.clock.Now().Before(delayed.RunAt) {
+			continue
+		}
+
+		// Only one run counts, even when several instances of the bot share the store
+		claimed, err := s.claimDelayedCommand(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		cmd := s.findCommand(delayed.Usage)
+		if cmd == nil {
+			fmt.Printf("failed running delayed command: %s is not registered\n", delayed.Usage)
+			continue
+		}
+
+		ev := &MessageEvent{
+			Channel:         delayed.Channel,
+			User:            delayed.User,
+			Type:            delayedKeyPrefix,
+			TimeStamp:       delayed.TimeStamp,
+			ThreadTimeStamp: delayed.ThreadTimeStamp,
+			TeamID:          delayed.TeamID,
+		}
+		botCtx := s.newBotContext(ctx, ev)
+		parameters := proper.NewProperties(delayed.Parameters)
+		s.dispatch(func() {
+			s.runCommand(botCtx, s.newResponse(botCtx), cmd, parameters)
+		})
+	}
+	return nil
+}
+
+// claimDelayedCommand removes the command, reporting whether this call was the one removing it
+func (s *Slacker) claimDelayedCommand(ctx context.Context, key string) (bool, error) {
+	current, err := s.store.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil || len(current) == 0 {
+		return false, err
+	}
+
+	claimed, err := s.compareAndSwap(ctx, key, current, []byte{}, delayedPeriod)
+	if err != nil || !claimed {
+		return false, err
+	}
+	return true, s.store.Delete(ctx, key)
+}
+
+// loadDelayedCommands returns the commands scheduled under the prefix, the soonest first
+func (s *Slacker) loadDelayedCommands(ctx context.Context, prefix string) ([]*delayedCommand, error) {
+	keys, err := s.store.Keys(ctx, prefix+storeKeySeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	delayed := []*delayedCommand{}
+	for _, key := range keys {
+		command := &delayedCommand{}
+		found, err := s.loadValue(ctx, key, command)
+		if err != nil {
+			return nil, err
+		}
+		if found && len(command.ID) > 0 {
+			delayed = append(delayed, command)
+		}
+	}
+
+	sort.Slice(delayed, func(i, j int) bool {
+		return delayed[i].RunAt.Before(delayed[j].RunAt)
+	})
+	return delayed, nil
+}
+
+// isDelayCommand reports whether the command is one of those scheduling commands, which cannot
+// be scheduled in turn
+func isDelayCommand(cmd BotCommand) bool {
+	switch cmd.Usage() {
+	case atCommand, inCommand, scheduledCommand, unscheduleCommand:
+		return true
+	}
+	return false
+}
+
+func formatDelayedTime(t time.Time) string {
+	return fmt.Sprintf(muteTimeFormat, t.Unix(), t.Format(time.RFC1123))
+}
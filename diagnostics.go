@@ -0,0 +1,189 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	diagnosticsCommand     = "diagnostics <profile?>"
+	diagnosticsDescription = "Shows the runtime state of the bot, or uploads a profile to the admin channel, such as `diagnostics heap`"
+	diagnosticsParam       = "profile"
+	cpuProfile             = "cpu"
+	cpuProfileDuration     = 30 * time.Second
+	profileFilenameFormat  = "%s-%s.pprof"
+	profileTitleFormat     = "%s profile of %s"
+	profileUploadedFormat  = "The %s profile was uploaded to <#%s>"
+	diagnosticsFormat      = "*Diagnostics*" +
+		"\nGoroutines: %d" +
+		"\nHeap: %s in use, %s allocated, %d objects" +
+		"\nGarbage collections: %d, paused %s in total" +
+		"\nCommand queue: %d of %d" +
+		"\nEvent backlog: %d of %d" +
+		"\nBusy workers: %d of %d" +
+		"\nQueued events: %d of %d"
+	profileTimeFormat = "20060102-150405"
+)
+
+var (
+	// ErrUnknownProfile is returned when capturing a profile that pprof does not know
+	ErrUnknownProfile = errors.New("unknown profile")
+
+	errNoAdminChannel = errors.New("Profiles are uploaded to the admin channel, which is not set")
+)
+
+// Diagnostics is a snapshot of the runtime state of the bot. The worker and queue counts are zero
+// without a WorkerPool.
+type Diagnostics struct {
+	Goroutines           int
+	HeapInuse            uint64
+	HeapAlloc            uint64
+	HeapObjects          uint64
+	NumGC                uint32
+	PauseTotal           time.Duration
+	CommandQueue         int
+	CommandQueueCapacity int
+	EventBacklog         int
+	EventBacklogCapacity int
+	BusyWorkers          int
+	Workers              int
+	QueuedEvents         int
+	QueueSize            int
+}
+
+// Diagnostics returns the runtime state of the bot, such as its goroutines and the depth of its queues
+func (s *Slacker) Diagnostics() *Diagnostics {
+	stats := &runtime.MemStats{}
+	runtime.ReadMemStats(stats)
+
+	diagnostics := &Diagnostics{
+		Goroutines:           runtime.NumGoroutine(),
+		HeapInuse:            stats.HeapInuse,
+		HeapAlloc:            stats.HeapAlloc,
+		HeapObjects:          stats.HeapObjects,
+		NumGC:                stats.NumGC,
+		PauseTotal:           time.Duration(stats.PauseTotalNs),
+		CommandQueue:         len(s.commandChannel),
+		CommandQueueCapacity: cap(s.commandChannel),
+		EventBacklog:         len(s.socketModeClient.Events),
+		EventBacklogCapacity: cap(s.socketModeClient.Events),
+	}
+	if s.workerPool != nil {
+		diagnostics.BusyWorkers, diagnostics.Workers = len(s.workerPool.slots), cap(s.workerPool.slots)
+		diagnostics.QueuedEvents, diagnostics.QueueSize = s.workerPool.queued()
+	}
+	return diagnostics
+}
+
+// CaptureProfile writes the pprof profile, such as goroutine or heap. The cpu profile is recorded
+// for the duration, which the other profiles ignore.
+func (s *Slacker) CaptureProfile(ctx context.Context, profile string, duration time.Duration, w io.Writer) error {
+	if profile != cpuProfile {
+		lookup := pprof.Lookup(profile)
+		if lookup == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownProfile, profile)
+		}
+		return lookup.WriteTo(w, 0)
+	}
+
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.clock.After(duration):
+		return nil
+	}
+}
+
+// appendDiagnosticsHandle adds the diagnostics command when it is enabled, it is called with the lock held
+func (s *Slacker) appendDiagnosticsHandle() {
+	if !s.diagnosticsCommand {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(diagnosticsCommand, &CommandDefinition{
+		Description:       diagnosticsDescription,
+		Example:           "diagnostics heap",
+		Handler:           s.diagnosticsHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead, scopeFilesWrite},
+	}))
+}
+
+func (s *Slacker) diagnosticsHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	profile := request.Param(diagnosticsParam)
+	if len(profile) == 0 {
+		if err := response.Reply(formatDiagnostics(s.Diagnostics()), WithThreadReply(ev.IsThread())); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+
+	// Profiles can reveal internals, so they go to the admin channel rather than where they were asked for
+	channelID := s.adminNotifier.channel()
+	if len(channelID) == 0 {
+		response.ReportError(errNoAdminChannel)
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := s.CaptureProfile(botCtx.Context(), profile, cpuProfileDuration, buffer); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	now := s.clock.Now()
+	_, err := botCtx.Client().UploadFileContext(botCtx.Context(), slack.FileUploadParameters{
+		Title:    fmt.Sprintf(profileTitleFormat, profile, now.Format(time.RFC1123)),
+		Reader:   buffer,
+		Filename: fmt.Sprintf(profileFilenameFormat, profile, now.Format(profileTimeFormat)),
+		Channels: []string{channelID},
+	})
+	if err != nil {
+		response.ReportError(scopeError(botCtx.Context(), err, featureFiles, scopeFilesWrite))
+		return
+	}
+
+	if err := response.Reply(fmt.Sprintf(profileUploadedFormat, profile, channelID), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func formatDiagnostics(diagnostics *Diagnostics) string {
+	return fmt.Sprintf(diagnosticsFormat,
+		diagnostics.Goroutines,
+		formatBytes(diagnostics.HeapInuse), formatBytes(diagnostics.HeapAlloc), diagnostics.HeapObjects,
+		diagnostics.NumGC, diagnostics.PauseTotal.Round(time.Microsecond),
+		diagnostics.CommandQueue, diagnostics.CommandQueueCapacity,
+		diagnostics.EventBacklog, diagnostics.EventBacklogCapacity,
+		diagnostics.BusyWorkers, diagnostics.Workers,
+		diagnostics.QueuedEvents, diagnostics.QueueSize)
+}
+
+// formatBytes prints the size in the largest binary unit it reaches
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,32 @@
+package slacker
+
+import (
+	"github.com/slack-go/slack/slackevents"
+)
+
+// isEcho reports whether the event is one of the bot's own messages coming back, whichever way
+// it was posted: as the bot, as its bot user, through a response URL, or as an edit or deletion
+// of one of its messages
+func (s *Slacker) isEcho(ev *MessageEvent) bool {
+	if s.isSelf(ev.BotID, ev.User) {
+		return true
+	}
+
+	raw, ok := ev.Data.(*slackevents.MessageEvent)
+	if !ok {
+		return false
+	}
+
+	// Edits and deletions carry the message they apply to rather than a sender of their own
+	for _, message := range []*slackevents.MessageEvent{raw.Message, raw.PreviousMessage} {
+		if message != nil && s.isSelf(message.BotID, message.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelf reports whether the bot ID or the user ID is the bot's
+func (s *Slacker) isSelf(botID string, userID string) bool {
+	return (len(botID) > 0 && botID == s.botID) || (len(userID) > 0 && userID == s.botUserID)
+}
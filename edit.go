@@ -0,0 +1,101 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	messageChangedSubType = "message_changed"
+	commandRanKeyPrefix   = "ran"
+	commandRanTTL         = 24 * time.Hour
+)
+
+// commandClaim lets a single version of a message run commands, whether the original or one of
+// its edits, the first command run by it claiming the message for the others
+type commandClaim struct {
+	once    sync.Once
+	key     string
+	claimed bool
+}
+
+type commandClaimKey struct{}
+
+func withCommandClaim(ctx context.Context, ev *MessageEvent) context.Context {
+	if len(ev.TimeStamp) == 0 {
+		return ctx
+	}
+	claim := &commandClaim{key: storeKey(commandRanKeyPrefix, ev.TeamID, ev.Channel, ev.TimeStamp)}
+	return context.WithValue(ctx, commandClaimKey{}, claim)
+}
+
+func commandClaimFromContext(ctx context.Context) *commandClaim {
+	claim, _ := ctx.Value(commandClaimKey{}).(*commandClaim)
+	return claim
+}
+
+// claimCommand reports whether the message being handled may run commands, which it may unless
+// another version of it already ran one. The commands of a batch share the claim of their message.
+// Messages are not claimed when the store fails, running them being safer than dropping them.
+func (s *Slacker) claimCommand(ctx context.Context) bool {
+	claim := commandClaimFromContext(ctx)
+	if claim == nil {
+		return true
+	}
+
+	claim.once.Do(func() {
+		fresh, err := s.compareAndSwap(ctx, claim.key, nil, []byte(s.clock.Now().UTC().Format(time.RFC3339)), commandRanTTL)
+		if err != nil {
+			fmt.Printf("failed claiming message: %v\n", err)
+			fresh = true
+		}
+		claim.claimed = fresh
+	})
+	return claim.claimed
+}
+
+// editedMessage returns the new version of a message edited by its sender, or nil for any other
+// event. Edits leaving the text unchanged, as when Slack unfurls its links, are left out.
+func editedMessage(ev *MessageEvent) *slackevents.MessageEvent {
+	raw, ok := ev.Data.(*slackevents.MessageEvent)
+	if !ok || raw.SubType != messageChangedSubType || raw.Message == nil {
+		return nil
+	}
+	if raw.PreviousMessage != nil && raw.PreviousMessage.Text == raw.Message.Text {
+		return nil
+	}
+	return raw.Message
+}
+
+// applyEdit turns the edit into the message it changed, keeping the timestamp of the original so
+// that both share a claim, and reports whether the event should be handled. Edits only run commands
+// WithEditedCommands, and are otherwise handled as before.
+func (s *Slacker) applyEdit(ev *MessageEvent) bool {
+	raw, ok := ev.Data.(*slackevents.MessageEvent)
+	if !s.editedCommands || !ok || raw.SubType != messageChangedSubType {
+		return true
+	}
+
+	message := editedMessage(ev)
+	if message == nil {
+		s.tracef("edit of message %s in %s did not change its text", ev.TimeStamp, ev.Channel)
+		return false
+	}
+
+	ev.User = message.User
+	ev.Text = message.Text
+	ev.TimeStamp = message.TimeStamp
+	ev.ThreadTimeStamp = message.ThreadTimeStamp
+	ev.BotID = message.BotID
+
+	// Mentions in channels arrive as app_mention events, which Slack does not send again for edits
+	if !strings.HasPrefix(ev.Channel, directChannelMarker) && strings.Contains(ev.Text, fmt.Sprintf(userMentionFormat, s.botUserID)) {
+		ev.Type = string(slackevents.AppMention)
+	}
+	return true
+}
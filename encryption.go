@@ -0,0 +1,242 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	dataKeySize = 32
+)
+
+var (
+	// ErrUnknownEncryptionKey is returned when a value was encrypted with a key the KeyProvider does not have
+	ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+	// ErrInvalidCiphertext is returned when an encrypted value is corrupted or was moved to another key
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// A KeyProvider interface provides the keys encrypting the data keys of an encrypted store, such as
+// keys held by a KMS. CurrentKey returns the key encrypting new values, and Key the key a value was
+// encrypted with, so that keys can be rotated while older values remain readable.
+// Keys are 16, 24 or 32 bytes long, for AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	CurrentKey(ctx context.Context) (string, []byte, error)
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// NewStaticKeyProvider creates a KeyProvider holding the keys by ID, encrypting new values with the
+// current one
+func NewStaticKeyProvider(current string, keys map[string][]byte) KeyProvider {
+	return &staticKeyProvider{current: current, keys: keys}
+}
+
+type staticKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// CurrentKey returns the key encrypting new values
+func (p *staticKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := p.Key(ctx, p.current)
+	return p.current, key, err
+}
+
+// Key returns the key with the ID
+func (p *staticKeyProvider) Key(ctx context.Context, id string) ([]byte, error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, ErrUnknownEncryptionKey
+	}
+	return key, nil
+}
+
+// NewEncryptedStore creates a Store encrypting the values it keeps in the store with envelope
+// encryption: each value is sealed with AES-GCM under a data key of its own, which is sealed under
+// the current key of the provider. Keys are left in clear, so they should not hold secrets.
+func NewEncryptedStore(store Store, provider KeyProvider) Store {
+	return &encryptedStore{store: store, provider: provider}
+}
+
+// sealedValue is what an encrypted store keeps for a value
+type sealedValue struct {
+	KeyID   string `json:"key_id"`
+	DataKey []byte `json:"data_key"`
+	Value   []byte `json:"value"`
+}
+
+type encryptedStore struct {
+	mutex    sync.Mutex
+	store    Store
+	provider KeyProvider
+}
+
+// Get returns the decrypted value of the key
+func (e *encryptedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	sealed, err := e.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return e.open(ctx, key, sealed)
+}
+
+// Set encrypts the value of the key
+func (e *encryptedStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	sealed, err := e.seal(ctx, key, value)
+	if err != nil {
+		return err
+	}
+	return e.store.Set(ctx, key, sealed, ttl)
+}
+
+// Delete removes the key
+func (e *encryptedStore) Delete(ctx context.Context, key string) error {
+	return e.store.Delete(ctx, key)
+}
+
+// Keys returns the keys starting with the prefix
+func (e *encryptedStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	return e.store.Keys(ctx, prefix)
+}
+
+// CompareAndSwap sets the value of the key if it still holds the old value. Encrypting the same
+// value twice giving different ciphertexts, the current ciphertext is decrypted to be compared and
+// then swapped in the underlying store, when it is a CompareAndSwapper.
+func (e *encryptedStore) CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error) {
+	swapper, ok := e.store.(CompareAndSwapper)
+	if !ok {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+	}
+
+	current, err := e.store.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		current, err = nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if (current == nil) != (old == nil) {
+		return false, nil
+	}
+	if current != nil {
+		value, err := e.open(ctx, key, current)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(value, old) {
+			return false, nil
+		}
+	}
+
+	sealed, err := e.seal(ctx, key, new)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return swapper.CompareAndSwap(ctx, key, current, sealed, ttl)
+	}
+	return true, e.store.Set(ctx, key, sealed, ttl)
+}
+
+// Sweep removes the expired values of the underlying store, when it is a Sweeper
+func (e *encryptedStore) Sweep(ctx context.Context) (int, error) {
+	if sweeper, ok := e.store.(Sweeper); ok {
+		return sweeper.Sweep(ctx)
+	}
+	return 0, nil
+}
+
+// seal encrypts the value under a new data key, the store key being authenticated so that values
+// cannot be moved between keys
+func (e *encryptedStore) seal(ctx context.Context, key string, value []byte) ([]byte, error) {
+	keyID, masterKey, err := e.provider.CurrentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	sealedKey, err := sealAESGCM(masterKey, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	sealedData, err := sealAESGCM(dataKey, value, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&sealedValue{KeyID: keyID, DataKey: sealedKey, Value: sealedData})
+}
+
+// open decrypts the value sealed for the key
+func (e *encryptedStore) open(ctx context.Context, key string, sealed []byte) ([]byte, error) {
+	envelope := &sealedValue{}
+	if err := json.Unmarshal(sealed, envelope); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	masterKey, err := e.provider.Key(ctx, envelope.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := openAESGCM(masterKey, envelope.DataKey, []byte(envelope.KeyID))
+	if err != nil {
+		return nil, err
+	}
+	return openAESGCM(dataKey, envelope.Value, []byte(key))
+}
+
+// sealAESGCM encrypts the plaintext, prefixing it with a random nonce
+func sealAESGCM(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM decrypts the ciphertext sealed by sealAESGCM
+func openAESGCM(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
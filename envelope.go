@@ -0,0 +1,189 @@
+package slacker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	envelopeInboundFormat  = "envelope %s: %s\n"
+	envelopeOutboundFormat = "api %s %s: %d request %s response %s\n"
+	envelopeFailedFormat   = "api %s %s: %v request %s\n"
+	formContentType        = "application/x-www-form-urlencoded"
+	contentTypeHeader      = "Content-Type"
+	maxLoggedBodyBytes     = 64 << 10
+)
+
+// DefaultRedactedFields are the fields whose values envelope logging hides when no others are
+// set, the message contents and the tokens
+var DefaultRedactedFields = []string{"text", "blocks", "attachments", "files", "value", "values", "token", "trigger_id", "response_url"}
+
+// EnvelopeLogging prints the full envelopes received from Slack and the Slack API calls with their
+// responses, each sampled at its rate between 0 and 1. The values of RedactedFields, at any depth
+// of the JSON documents and forms, are replaced before printing. They default to
+// DefaultRedactedFields, and the tokens of the Authorization header are never printed.
+type EnvelopeLogging struct {
+	InboundRate    float64
+	OutboundRate   float64
+	RedactedFields []string
+}
+
+func (e *EnvelopeLogging) redactedFields() map[string]bool {
+	fields := e.RedactedFields
+	if len(fields) == 0 {
+		fields = DefaultRedactedFields
+	}
+
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		redacted[field] = true
+	}
+	return redacted
+}
+
+// logEnvelope prints a sample of the envelopes received from Slack
+func (s *Slacker) logEnvelope(evt socketmode.Event) {
+	logging := s.envelopeLogging
+	if logging == nil || evt.Request == nil || logging.InboundRate <= 0 || rand.Float64() >= logging.InboundRate {
+		return
+	}
+
+	data, err := json.Marshal(evt.Request)
+	if err != nil {
+		fmt.Printf("failed logging envelope: %v\n", err)
+		return
+	}
+	fmt.Printf(envelopeInboundFormat, evt.Type, redactJSON(data, logging.redactedFields()))
+}
+
+// envelopeTransport prints a sample of the requests sent to the Slack API and their responses
+type envelopeTransport struct {
+	logging  *EnvelopeLogging
+	redacted map[string]bool
+	base     http.RoundTripper
+}
+
+// withEnvelopeLogging returns a copy of the HTTP client whose requests are logged
+func withEnvelopeLogging(client *http.Client, logging *EnvelopeLogging) *http.Client {
+	logged := &http.Client{}
+	if client != nil {
+		*logged = *client
+	}
+
+	base := logged.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	logged.Transport = &envelopeTransport{logging: logging, redacted: logging.redactedFields(), base: base}
+	return logged
+}
+
+// RoundTrip sends the request, printing it along with the response when it is sampled
+func (t *envelopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.logging.OutboundRate <= 0 || rand.Float64() >= t.logging.OutboundRate {
+		return t.base.RoundTrip(req)
+	}
+
+	var requestBody []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	request := t.redact(req.Header.Get(contentTypeHeader), requestBody)
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		fmt.Printf(envelopeFailedFormat, req.Method, req.URL.Path, err, request)
+		return nil, err
+	}
+
+	// The response is logged up to a limit, and handed on in full
+	head, err := io.ReadAll(io.LimitReader(res.Body, maxLoggedBodyBytes))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), res.Body), res.Body}
+
+	fmt.Printf(envelopeOutboundFormat, req.Method, req.URL.Path, res.StatusCode, request, t.redact(res.Header.Get(contentTypeHeader), head))
+	return res, nil
+}
+
+func (t *envelopeTransport) redact(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return empty
+	}
+	if strings.HasPrefix(contentType, formContentType) {
+		return redactForm(body, t.redacted)
+	}
+	return redactJSON(body, t.redacted)
+}
+
+// redactForm hides the redacted fields of a form, and the fields holding JSON documents are
+// redacted in turn
+func redactForm(body []byte, redacted map[string]bool) string {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return redactedValue
+	}
+
+	for field, fieldValues := range values {
+		for i, value := range fieldValues {
+			switch {
+			case redacted[field]:
+				fieldValues[i] = redactedValue
+			case strings.HasPrefix(value, "{") || strings.HasPrefix(value, "["):
+				fieldValues[i] = redactJSON([]byte(value), redacted)
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// redactJSON hides the redacted fields of a JSON document, which is hidden whole when it does not parse
+func redactJSON(data []byte, redacted map[string]bool) string {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return redactedValue
+	}
+
+	data, err := json.Marshal(redactValue(document, redacted))
+	if err != nil {
+		return redactedValue
+	}
+	return string(data)
+}
+
+func redactValue(value interface{}, redacted map[string]bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range value {
+			if redacted[field] {
+				value[field] = redactedValue
+				continue
+			}
+			value[field] = redactValue(fieldValue, redacted)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item, redacted)
+		}
+	}
+	return value
+}
@@ -0,0 +1,264 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	escalationAckID        = "slacker_escalation_ack"
+	escalationJobFormat    = "escalation-%s"
+	escalationJobDesc      = "Escalates %q until it is acknowledged"
+	escalationAckText      = "Acknowledge"
+	escalationRepeatFormat = ":rotating_light: Not acknowledged after %s: %s"
+	escalationLinkFormat   = "%s\n<%s|View the original message>"
+	escalationAckedFormat  = ":white_check_mark: Acknowledged by <@%s>"
+)
+
+var (
+	// ErrInvalidEscalation is returned when an escalation has no channel, text or window
+	ErrInvalidEscalation = errors.New("an escalation needs a channel, a text and a window")
+	// ErrEscalationNotFound is returned when acknowledging an escalation that is unknown or finished
+	ErrEscalationNotFound = errors.New("escalation not found")
+)
+
+// EscalationDefinition structure contains the definition of a message needing acknowledgment.
+// The message is posted to Channel with an acknowledge button. Each time Window passes without an
+// acknowledgment, the next channel or user of Chain is notified, and once the chain is exhausted the
+// escalation stops. An empty chain notifies the thread of the message once. Adding the Reaction, such
+// as "eyes", to the message also acknowledges it when the bot receives reaction events.
+type EscalationDefinition struct {
+	Channel        string
+	Text           string
+	Window         time.Duration
+	Chain          []string
+	Reaction       string
+	OnAcknowledged func(ctx context.Context, userID string)
+	OnExhausted    func(ctx context.Context)
+}
+
+// Escalation is a posted message waiting for acknowledgment
+type Escalation struct {
+	ID        string
+	Channel   string
+	Timestamp string
+
+	definition *EscalationDefinition
+	permalink  string
+	mutex      sync.Mutex
+	step       int
+	acked      bool
+	ackedBy    string
+	exhausted  bool
+	finish     func()
+}
+
+// Acknowledged returns whether the escalation was acknowledged, and by whom
+func (e *Escalation) Acknowledged() (bool, string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.acked, e.ackedBy
+}
+
+// escalationSchedule runs the escalation each time its window passes, until it is over
+type escalationSchedule struct {
+	e *Escalation
+}
+
+// Next returns the time of the next notification, or the zero time once the escalation is over
+func (schedule escalationSchedule) Next(after time.Time) time.Time {
+	e := schedule.e
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.acked || e.exhausted {
+		return time.Time{}
+	}
+	return after.Add(e.definition.Window)
+}
+
+// escalations holds the escalations waiting for acknowledgment
+type escalations struct {
+	mutex     sync.Mutex
+	byID      map[string]*Escalation
+	byMessage map[string]*Escalation
+}
+
+func newEscalations() *escalations {
+	return &escalations{byID: make(map[string]*Escalation), byMessage: make(map[string]*Escalation)}
+}
+
+func (x *escalations) add(e *Escalation) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.byID[e.ID] = e
+	x.byMessage[storeKey(e.Channel, e.Timestamp)] = e
+}
+
+func (x *escalations) remove(e *Escalation) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	delete(x.byID, e.ID)
+	delete(x.byMessage, storeKey(e.Channel, e.Timestamp))
+}
+
+func (x *escalations) find(id string) *Escalation {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	return x.byID[id]
+}
+
+func (x *escalations) findMessage(channelID string, timestamp string) *Escalation {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	return x.byMessage[storeKey(channelID, timestamp)]
+}
+
+// Escalate posts the message and notifies the chain in turn until someone acknowledges it
+func (s *Slacker) Escalate(ctx context.Context, definition *EscalationDefinition) (*Escalation, error) {
+	if definition == nil || len(definition.Channel) == 0 || len(definition.Text) == 0 || definition.Window <= 0 {
+		return nil, ErrInvalidEscalation
+	}
+
+	e := &Escalation{ID: newTaskID(), definition: definition}
+	channelID, timestamp, err := s.client.PostMessageContext(ctx, definition.Channel, e.messageOptions(definition.Text)...)
+	if err != nil {
+		return nil, scopeError(ctx, err, featureMessages, scopeChatWrite)
+	}
+	e.Channel, e.Timestamp = channelID, timestamp
+
+	if permalink, err := s.client.GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: channelID, Ts: timestamp}); err == nil {
+		e.permalink = permalink
+	}
+
+	name := fmt.Sprintf(escalationJobFormat, e.ID)
+	e.finish = func() {
+		s.escalations.remove(e)
+		s.scheduler.remove(name)
+	}
+
+	s.escalations.add(e)
+	err = s.scheduler.add(name, &JobDefinition{
+		Description: fmt.Sprintf(escalationJobDesc, definition.Text),
+		Schedule:    escalationSchedule{e: e},
+		Handler:     e.escalate,
+	})
+	if err != nil {
+		s.escalations.remove(e)
+		return nil, err
+	}
+	return e, nil
+}
+
+// Acknowledge acknowledges the escalation on behalf of the user, stopping further notifications
+func (s *Slacker) Acknowledge(ctx context.Context, id string, userID string) error {
+	e := s.escalations.find(id)
+	if e == nil {
+		return ErrEscalationNotFound
+	}
+	return e.acknowledge(ctx, s.client, userID)
+}
+
+func (e *Escalation) acknowledge(ctx context.Context, client *slack.Client, userID string) error {
+	e.mutex.Lock()
+	if e.acked {
+		e.mutex.Unlock()
+		return nil
+	}
+	e.acked, e.ackedBy = true, userID
+	e.mutex.Unlock()
+
+	e.finish()
+
+	text := fmt.Sprintf(escalationAckedFormat, userID)
+	_, _, _, err := client.UpdateMessageContext(ctx, e.Channel, e.Timestamp,
+		slack.MsgOptionText(e.definition.Text, false),
+		slack.MsgOptionBlocks(slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, e.definition.Text, false, false), nil, nil),
+			slack.NewContextBlock(empty, slack.NewTextBlockObject(slack.MarkdownType, text, false, false))),
+	)
+
+	if e.definition.OnAcknowledged != nil {
+		e.definition.OnAcknowledged(ctx, userID)
+	}
+	return err
+}
+
+// escalate notifies the next step of the chain, it runs each time the window passes
+func (e *Escalation) escalate(jobCtx JobContext) error {
+	e.mutex.Lock()
+	if e.acked || e.exhausted {
+		e.mutex.Unlock()
+		return nil
+	}
+	step := e.step
+	e.step++
+	e.exhausted = e.step >= len(e.definition.Chain)
+	exhausted := e.exhausted
+	e.mutex.Unlock()
+
+	text := fmt.Sprintf(escalationRepeatFormat, time.Duration(step+1)*e.definition.Window, e.definition.Text)
+	options := []slack.MsgOption{}
+
+	channelID := e.Channel
+	if len(e.definition.Chain) > 0 {
+		channelID = e.definition.Chain[step]
+		if len(e.permalink) > 0 {
+			text = fmt.Sprintf(escalationLinkFormat, text, e.permalink)
+		}
+	} else {
+		options = append(options, slack.MsgOptionTS(e.Timestamp))
+	}
+
+	_, _, err := jobCtx.Client().PostMessageContext(jobCtx.Context(), channelID, append(e.messageOptions(text), options...)...)
+
+	if exhausted {
+		e.finish()
+		if e.definition.OnExhausted != nil {
+			e.definition.OnExhausted(jobCtx.Context())
+		}
+	}
+	return err
+}
+
+func (e *Escalation) messageOptions(text string) []slack.MsgOption {
+	ack := slack.NewButtonBlockElement(escalationAckID, e.ID, slack.NewTextBlockObject(slack.PlainTextType, escalationAckText, false, false))
+	ack.Style = slack.StylePrimary
+	return []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewActionBlock(escalationAckID, ack),
+		),
+	}
+}
+
+// handleEscalationAck acknowledges the escalation whose button was clicked
+func (s *Slacker) handleEscalationAck(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	err := s.Acknowledge(botCtx.Context(), action.Value, callback.User.ID)
+	if err != nil && !errors.Is(err, ErrEscalationNotFound) {
+		response.ReportError(err)
+	}
+}
+
+// acknowledgeReaction acknowledges the escalation of the reacted message when the reaction is its own
+func (s *Slacker) acknowledgeReaction(ctx context.Context, event *slackevents.ReactionAddedEvent) {
+	e := s.escalations.findMessage(event.Item.Channel, event.Item.Timestamp)
+	if e == nil || len(e.definition.Reaction) == 0 || reactionName(event.Reaction) != reactionName(e.definition.Reaction) {
+		return
+	}
+
+	if err := e.acknowledge(ctx, s.client, event.User); err != nil {
+		fmt.Printf("failed acknowledging escalation: %v\n", err)
+	}
+}
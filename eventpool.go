@@ -0,0 +1,84 @@
+package slacker
+
+import (
+	"sync"
+
+	"github.com/shomali11/proper"
+)
+
+var (
+	messageEventPool = sync.Pool{
+		New: func() interface{} {
+			return &MessageEvent{}
+		},
+	}
+	requestPool = sync.Pool{
+		New: func() interface{} {
+			return &request{}
+		},
+	}
+)
+
+// acquireMessageEvent returns the message event of the raw event, taken from the pool when pooling is enabled
+func (s *Slacker) acquireMessageEvent(evt interface{}, teamID string) *MessageEvent {
+	if !s.eventPooling {
+		return newMessageEvent(evt, teamID)
+	}
+
+	me := messageEventPool.Get().(*MessageEvent)
+	if !fillMessageEvent(me, evt, teamID) {
+		messageEventPool.Put(me)
+		return nil
+	}
+	return me
+}
+
+// releaseMessageEvent returns the message event to the pool once the event was handled
+func (s *Slacker) releaseMessageEvent(me *MessageEvent) {
+	if !s.eventPooling {
+		return
+	}
+
+	*me = MessageEvent{}
+	messageEventPool.Put(me)
+}
+
+// retainedEvent returns the event, or a copy of it when pooling is enabled, for uses that outlive the handler
+func (s *Slacker) retainedEvent(me *MessageEvent) *MessageEvent {
+	if !s.eventPooling || me == nil {
+		return me
+	}
+
+	retained := *me
+	return &retained
+}
+
+// acquireRequest returns a request taken from the pool, unless a custom request constructor is set
+func (s *Slacker) acquireRequest(botCtx BotContext, properties *proper.Properties) (Request, bool) {
+	s.mutex.RLock()
+	pooled := s.eventPooling && !s.customRequest
+	s.mutex.RUnlock()
+
+	if !pooled {
+		return nil, false
+	}
+
+	r := requestPool.Get().(*request)
+	r.botCtx = botCtx
+	r.properties = properties
+	return r, true
+}
+
+// releaseRequest returns a request taken from the pool once the command ran
+func (s *Slacker) releaseRequest(r Request) {
+	if !s.eventPooling {
+		return
+	}
+
+	pooled, ok := r.(*request)
+	if !ok {
+		return
+	}
+	*pooled = request{}
+	requestPool.Put(pooled)
+}
@@ -0,0 +1,70 @@
+package slacker
+
+import (
+	"testing"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack/slackevents"
+)
+
+var (
+	benchmarkEvent = &slackevents.MessageEvent{
+		Type:      "message",
+		Channel:   "C1",
+		User:      "U1",
+		Text:      "deploy api production",
+		TimeStamp: "1700000000.000100",
+	}
+	benchmarkProperties = proper.NewProperties(map[string]string{"service": "api"})
+
+	eventSink   *MessageEvent
+	requestSink Request
+)
+
+// benchmarkEventHandling acquires and releases the message event and request of each event, as handling it does
+func benchmarkEventHandling(b *testing.B, pooling bool) {
+	s := &Slacker{eventPooling: pooling, requestConstructor: NewRequest}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ev := s.acquireMessageEvent(benchmarkEvent, "T1")
+		request := s.newRequest(nil, benchmarkProperties)
+		eventSink, requestSink = ev, request
+
+		s.releaseRequest(request)
+		s.releaseMessageEvent(ev)
+	}
+}
+
+func BenchmarkEventHandling(b *testing.B) {
+	b.Run("plain", func(b *testing.B) {
+		benchmarkEventHandling(b, false)
+	})
+	b.Run("pooled", func(b *testing.B) {
+		benchmarkEventHandling(b, true)
+	})
+}
+
+func BenchmarkEventHandlingParallel(b *testing.B) {
+	for _, pooling := range []bool{false, true} {
+		name := "plain"
+		if pooling {
+			name = "pooled"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			s := &Slacker{eventPooling: pooling, requestConstructor: NewRequest}
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ev := s.acquireMessageEvent(benchmarkEvent, "T1")
+					request := s.newRequest(nil, benchmarkProperties)
+
+					s.releaseRequest(request)
+					s.releaseMessageEvent(ev)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package slacker
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	modalFallbackID        = "slacker_modal_fallback"
+	modalFallbackKeyPrefix = "modal-fallback"
+	modalFallbackTTL       = time.Hour
+	modalFallbackIDBytes   = 8
+	modalFallbackTitle     = "Missing parameters"
+	modalFallbackSubmit    = "Run"
+	modalFallbackClose     = "Cancel"
+	modalFallbackPrompt    = "Some parameters of `%s` are missing."
+	modalFallbackButton    = "Fill in parameters"
+	modalFallbackMissing   = "Some parameters are missing"
+	modalFallbackExpired   = "These parameters expired, run the command again"
+	modalFallbackKeptHint  = "Leave blank to keep the value already given"
+)
+
+var (
+	errForeignModalFallback = errors.New("Only the user who ran the command can fill in its parameters")
+)
+
+// modalFallbackMetadata carries a partially invoked command from the message to the modal submission.
+// It is kept in the store, the button and the modal only carrying its ID and usage, and the modal
+// leaves the inputs of Secret parameters empty, so their values never leave the server.
+type modalFallbackMetadata struct {
+	ID              string            `json:"id"`
+	Usage           string            `json:"usage"`
+	User            string            `json:"user,omitempty"`
+	Channel         string            `json:"channel,omitempty"`
+	TimeStamp       string            `json:"ts,omitempty"`
+	ThreadTimeStamp string            `json:"thread_ts,omitempty"`
+	Parameters      map[string]string `json:"parameters,omitempty"`
+}
+
+// requestMissingParameters collects the missing parameters of a command through a modal.
+// Slash commands open the modal right away, whereas messages are answered with a button
+// that opens it, since modals can only be opened in response to an interaction.
+func (s *Slacker) requestMissingParameters(botCtx BotContext, response ResponseWriter, cmd BotCommand, parameters *proper.Properties) {
+	id := make([]byte, modalFallbackIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	ev := botCtx.Event()
+	metadata := &modalFallbackMetadata{
+		ID:              hex.EncodeToString(id),
+		Usage:           cmd.Usage(),
+		User:            ev.User,
+		Channel:         ev.Channel,
+		TimeStamp:       ev.TimeStamp,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		Parameters:      parameterValues(cmd, parameters),
+	}
+	if err := s.saveValue(botCtx.Context(), storeKey(modalFallbackKeyPrefix, ev.TeamID, metadata.ID), metadata, modalFallbackTTL); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if command, ok := ev.Data.(*slack.SlashCommand); ok {
+		if err := s.openModalFallback(botCtx, command.TriggerID, metadata); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+
+	prompt := slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(modalFallbackPrompt, cmd.Usage()), false, false)
+	button := slack.NewButtonBlockElement(modalFallbackID, metadata.ID, slack.NewTextBlockObject(slack.PlainTextType, modalFallbackButton, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(prompt, nil, nil),
+		slack.NewActionBlock(modalFallbackID, button),
+	}
+
+	if err := response.Reply(modalFallbackMissing, WithBlocks(blocks), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// handleModalFallbackAction opens the modal when the user who ran the command clicks the button
+// posted by requestMissingParameters
+func (s *Slacker) handleModalFallbackAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	metadata := &modalFallbackMetadata{}
+	found, err := s.loadValue(botCtx.Context(), storeKey(modalFallbackKeyPrefix, botCtx.Event().TeamID, action.Value), metadata)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+	if !found {
+		s.replaceInteractionMessage(botCtx, callback, modalFallbackExpired)
+		return
+	}
+	if callback.User.ID != metadata.User {
+		response.ReportError(errForeignModalFallback)
+		return
+	}
+
+	if err := s.openModalFallback(botCtx, callback.TriggerID, metadata); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// handleModalFallbackSubmission executes the command with the parameters collected by the modal
+func (s *Slacker) handleModalFallbackSubmission(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	view := &modalFallbackMetadata{}
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), view); err != nil {
+		return nil
+	}
+
+	ctx := botCtx.Context()
+	key := storeKey(modalFallbackKeyPrefix, botCtx.Event().TeamID, view.ID)
+	metadata := &modalFallbackMetadata{}
+	found, err := s.loadValue(ctx, key, metadata)
+	if err != nil {
+		fmt.Printf("failed loading modal fallback: %v\n", err)
+		return nil
+	}
+	if !found || callback.User.ID != metadata.User {
+		return nil
+	}
+
+	cmd := s.findCommand(metadata.Usage)
+	if cmd == nil {
+		return nil
+	}
+
+	if metadata.Parameters == nil {
+		metadata.Parameters = make(map[string]string)
+	}
+
+	values := viewValues(callback.View)
+	if payload := validationResponse(validateInputs(cmd.Definition().Parameters, values)); payload != nil {
+		return payload
+	}
+	if payload := validationResponse(s.validateProvided(botCtx, cmd.Definition().Parameters, values)); payload != nil {
+		return payload
+	}
+
+	if err := s.store.Delete(ctx, key); err != nil {
+		fmt.Printf("failed deleting modal fallback: %v\n", err)
+	}
+	// Inputs left blank keep the values already given, those of Secret parameters included
+	for name, value := range values {
+		metadata.Parameters[name] = value
+	}
+
+	ev := &MessageEvent{
+		Channel:         metadata.Channel,
+		User:            callback.User.ID,
+		Data:            callback,
+		Type:            string(callback.Type),
+		TimeStamp:       metadata.TimeStamp,
+		ThreadTimeStamp: metadata.ThreadTimeStamp,
+		TeamID:          callback.Team.ID,
+	}
+
+	commandCtx := s.newBotContext(botCtx.Context(), ev)
+	go s.runCommand(commandCtx, s.newResponse(commandCtx), cmd, proper.NewProperties(metadata.Parameters))
+	return nil
+}
+
+// openModalFallback opens a modal with an input for each of the command's parameter definitions
+func (s *Slacker) openModalFallback(botCtx BotContext, triggerID string, metadata *modalFallbackMetadata) error {
+	cmd := s.findCommand(metadata.Usage)
+	if cmd == nil {
+		return nil
+	}
+
+	privateMetadata, err := json.Marshal(&modalFallbackMetadata{ID: metadata.ID, Usage: metadata.Usage})
+	if err != nil {
+		return err
+	}
+
+	blocks := []slack.Block{}
+	for i := range cmd.Definition().Parameters {
+		definition := &cmd.Definition().Parameters[i]
+		value, ok := metadata.Parameters[definition.Name]
+		if !ok || !definition.Secret {
+			blocks = append(blocks, parameterInput(definition, value))
+			continue
+		}
+
+		// Secret values are not sent back to Slack, the input being optional since blank keeps them
+		input := parameterInput(definition, empty)
+		input.Optional = true
+		input.Hint = slack.NewTextBlockObject(slack.PlainTextType, modalFallbackKeptHint, false, false)
+		blocks = append(blocks, input)
+	}
+
+	view := slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      modalFallbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, modalFallbackTitle, false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, modalFallbackSubmit, false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, modalFallbackClose, false, false),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+		PrivateMetadata: string(privateMetadata),
+	}
+
+	_, err = s.client.OpenViewContext(botCtx.Context(), triggerID, view)
+	return err
+}
+
+// parameterInput builds the modal input for a parameter, using a select menu when it has choices
+// or takes a user or a channel
+func parameterInput(definition *ParameterDefinition, value string) *slack.InputBlock {
+	var element slack.BlockElement
+	if suggestsValues(definition) {
+		selectElement := slack.NewOptionsSelectBlockElement(slack.OptTypeExternal, nil, definition.Name)
+		if len(value) > 0 {
+			selectElement.InitialOption = NewOption(value, value)
+		}
+		element = selectElement
+	} else if len(definition.Choices) > 0 {
+		options := []*slack.OptionBlockObject{}
+		var initialOption *slack.OptionBlockObject
+		for _, choice := range definition.Choices {
+			option := slack.NewOptionBlockObject(choice, slack.NewTextBlockObject(slack.PlainTextType, choice, false, false), nil)
+			if choice == value {
+				initialOption = option
+			}
+			options = append(options, option)
+		}
+
+		selectElement := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, definition.Name, options...)
+		selectElement.InitialOption = initialOption
+		element = selectElement
+	} else if definition.Type == UserParameter {
+		selectElement := slack.NewOptionsSelectBlockElement(slack.OptTypeUser, nil, definition.Name)
+		selectElement.InitialUser, _ = mentionedID(userParameterRegex, value)
+		element = selectElement
+	} else if definition.Type == ChannelParameter {
+		selectElement := slack.NewOptionsSelectBlockElement(slack.OptTypeConversations, nil, definition.Name)
+		selectElement.InitialConversation, _ = mentionedID(channelParameterRegex, value)
+		element = selectElement
+	} else {
+		inputElement := slack.NewPlainTextInputBlockElement(nil, definition.Name)
+		inputElement.InitialValue = value
+		element = inputElement
+	}
+
+	input := slack.NewInputBlock(definition.Name, slack.NewTextBlockObject(slack.PlainTextType, definition.Name, false, false), nil, element)
+	input.Optional = !definition.Required
+	if len(definition.Description) > 0 {
+		input.Hint = slack.NewTextBlockObject(slack.PlainTextType, definition.Description, false, false)
+	}
+	return input
+}
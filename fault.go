@@ -0,0 +1,103 @@
+package slacker
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFaultRetryAfter = time.Second
+	faultLatencyFormat     = "fault injection: delaying %s %s by %s\n"
+	faultStatusFormat      = "fault injection: answering %s %s with %d\n"
+	faultDroppedAckFormat  = "fault injection: dropping the ack of a %s event\n"
+	retryAfterHeader       = "Retry-After"
+)
+
+// FaultInjection makes the bot misbehave on purpose, to test in staging how handlers and the retries
+// of the Slack API calls cope with failures. Each rate is the probability, between 0 and 1, that a
+// call or event is affected. Outgoing Slack API calls are delayed by Latency, or answered with a rate
+// limit asking to retry after RetryAfter, or with a server error. Events are left unacknowledged at
+// DroppedAckRate, which makes Slack deliver them again. RetryAfter defaults to a second.
+type FaultInjection struct {
+	Latency         time.Duration
+	LatencyRate     float64
+	RateLimitRate   float64
+	RetryAfter      time.Duration
+	ServerErrorRate float64
+	DroppedAckRate  float64
+}
+
+func (f *FaultInjection) retryAfter() time.Duration {
+	if f.RetryAfter < time.Second {
+		return defaultFaultRetryAfter
+	}
+	return f.RetryAfter
+}
+
+// faultTransport injects the faults into the requests sent to the Slack API
+type faultTransport struct {
+	faults *FaultInjection
+	base   http.RoundTripper
+	clock  Clock
+}
+
+// withFaultInjection returns a copy of the HTTP client whose requests are subject to the faults
+func withFaultInjection(client *http.Client, faults *FaultInjection, clock Clock) *http.Client {
+	injected := &http.Client{}
+	if client != nil {
+		*injected = *client
+	}
+
+	base := injected.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	injected.Transport = &faultTransport{faults: faults, base: base, clock: clock}
+	return injected
+}
+
+// RoundTrip delays the request or answers it with a failure instead of sending it to Slack
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.faults.Latency > 0 && rand.Float64() < t.faults.LatencyRate {
+		fmt.Printf(faultLatencyFormat, req.Method, req.URL.Path, t.faults.Latency)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-t.clock.After(t.faults.Latency):
+		}
+	}
+
+	if rand.Float64() < t.faults.RateLimitRate {
+		header := http.Header{}
+		header.Set(retryAfterHeader, strconv.Itoa(int(t.faults.retryAfter().Seconds())))
+		return faultResponse(req, http.StatusTooManyRequests, header), nil
+	}
+
+	if rand.Float64() < t.faults.ServerErrorRate {
+		return faultResponse(req, http.StatusServiceUnavailable, http.Header{}), nil
+	}
+	return t.base.RoundTrip(req)
+}
+
+func faultResponse(req *http.Request, code int, header http.Header) *http.Response {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	fmt.Printf(faultStatusFormat, req.Method, req.URL.Path, code)
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode: code,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(empty)),
+		Request:    req,
+	}
+}
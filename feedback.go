@@ -0,0 +1,154 @@
+package slacker
+
+import (
+	"context"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	feedbackCommand     = "feedback <text...>"
+	feedbackDescription = "Sends feedback about the bot"
+	feedbackExample     = "feedback the deploy command is great"
+	feedbackBlockID     = "slacker_feedback"
+	feedbackPositiveID  = "slacker_feedback_positive"
+	feedbackNegativeID  = "slacker_feedback_negative"
+	feedbackPositive    = ":thumbsup:"
+	feedbackNegative    = ":thumbsdown:"
+	feedbackThanks      = "Thanks for your feedback!"
+)
+
+// Feedback is a user's opinion about the bot or one of its replies
+type Feedback struct {
+	Timestamp time.Time
+	TeamID    string
+	Channel   string
+	User      string
+
+	// Command is the usage of the command whose reply was rated, it is empty for free-form feedback
+	Command string
+
+	// MessageTimeStamp is the timestamp of the rated reply
+	MessageTimeStamp string
+
+	// Rating is 1 for a positive rating, -1 for a negative one and 0 for free-form feedback
+	Rating int
+
+	// Text is the free-form feedback
+	Text string
+}
+
+// A FeedbackSink interface receives the feedback collected by the bot
+type FeedbackSink interface {
+	Collect(ctx context.Context, feedback *Feedback) error
+}
+
+// feedbackResponse appends rating buttons to the replies of a command
+type feedbackResponse struct {
+	ResponseWriter
+	usage string
+}
+
+// Reply sends the message with rating buttons below it
+func (r *feedbackResponse) Reply(message string, options ...ReplyOption) error {
+	defaults := NewReplyDefaults(options...)
+
+	blocks := defaults.Blocks
+	if len(blocks) == 0 {
+		blocks = []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, message, false, false), nil, nil)}
+	}
+
+	positive := slack.NewButtonBlockElement(feedbackPositiveID, r.usage, slack.NewTextBlockObject(slack.PlainTextType, feedbackPositive, true, false))
+	negative := slack.NewButtonBlockElement(feedbackNegativeID, r.usage, slack.NewTextBlockObject(slack.PlainTextType, feedbackNegative, true, false))
+	blocks = append(blocks, slack.NewActionBlock(feedbackBlockID, positive, negative))
+
+	return r.ResponseWriter.Reply(message, append(options, WithBlocks(blocks))...)
+}
+
+// ReplyBlocks sends the blocks with rating buttons below them
+func (r *feedbackResponse) ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error {
+	return r.Reply(blocksText(blocks), append(options, WithBlocks(blocks))...)
+}
+
+// withFeedback wraps the response of commands that collect feedback
+func (s *Slacker) withFeedback(cmd BotCommand, response ResponseWriter) ResponseWriter {
+	if s.feedbackSink == nil || !cmd.Definition().CollectFeedback {
+		return response
+	}
+	return &feedbackResponse{ResponseWriter: response, usage: cmd.Usage()}
+}
+
+func (s *Slacker) feedbackHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	feedback := &Feedback{
+		Timestamp:        s.clock.Now(),
+		TeamID:           ev.TeamID,
+		Channel:          ev.Channel,
+		User:             ev.User,
+		MessageTimeStamp: ev.TimeStamp,
+		Text:             request.Param("text"),
+	}
+
+	if err := s.feedbackSink.Collect(botCtx.Context(), feedback); err != nil {
+		response.ReportError(err)
+		return
+	}
+	response.Reply(feedbackThanks, WithThreadReply(ev.IsThread()))
+}
+
+// handleFeedbackAction collects a rating and replaces the buttons with a thank you note
+func (s *Slacker) handleFeedbackAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	rating := 1
+	if action.ActionID == feedbackNegativeID {
+		rating = -1
+	}
+
+	feedback := &Feedback{
+		Timestamp:        s.clock.Now(),
+		TeamID:           callback.Team.ID,
+		Channel:          callback.Container.ChannelID,
+		User:             callback.User.ID,
+		Command:          action.Value,
+		MessageTimeStamp: callback.Container.MessageTs,
+		Rating:           rating,
+	}
+
+	if err := s.feedbackSink.Collect(botCtx.Context(), feedback); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	blocks := []slack.Block{}
+	for _, block := range callback.Message.Blocks.BlockSet {
+		if actions, ok := block.(*slack.ActionBlock); ok && actions.BlockID == feedbackBlockID {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	blocks = append(blocks, slack.NewContextBlock(feedbackBlockID, slack.NewTextBlockObject(slack.MarkdownType, feedbackThanks, false, false)))
+
+	_, _, _, err := s.client.UpdateMessageContext(
+		botCtx.Context(),
+		callback.Container.ChannelID,
+		callback.Container.MessageTs,
+		slack.MsgOptionText(callback.Message.Text, false),
+		slack.MsgOptionBlocks(blocks...),
+	)
+	if err != nil {
+		response.ReportError(err)
+	}
+}
+
+// appendFeedbackHandle adds the feedback command when a sink is set, it is called with the lock held
+func (s *Slacker) appendFeedbackHandle() {
+	if s.feedbackSink == nil {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(feedbackCommand, &CommandDefinition{
+		Description: feedbackDescription,
+		Example:     feedbackExample,
+		Handler:     s.feedbackHandler,
+	}))
+}
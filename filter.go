@@ -0,0 +1,102 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	slackbotUserID = "USLACKBOT"
+	scopeBotsRead  = "bots:read"
+	featureFilter  = "ignoring bots by app"
+)
+
+// messageFilter decides which senders the bot does not respond to. Bots are told apart by
+// their bot ID, and by the app they belong to, which is looked up once per bot.
+type messageFilter struct {
+	client         *slack.Client
+	ignoreAllBots  bool
+	ignoreSlackbot bool
+	ignoredBots    map[string]bool
+	ignoredApps    map[string]bool
+	allowedBots    map[string]bool
+	allowedApps    map[string]bool
+
+	mutex sync.Mutex
+	apps  map[string]string
+}
+
+func newMessageFilter(client *slack.Client, defaults *ClientDefaults) *messageFilter {
+	return &messageFilter{
+		client:         client,
+		ignoreAllBots:  defaults.IgnoreAllBots,
+		ignoreSlackbot: defaults.IgnoreSlackbot,
+		ignoredBots:    stringSet(defaults.IgnoredBots),
+		ignoredApps:    stringSet(defaults.IgnoredApps),
+		allowedBots:    stringSet(defaults.AllowedBots),
+		allowedApps:    stringSet(defaults.AllowedApps),
+		apps:           make(map[string]string),
+	}
+}
+
+// ignores reports whether the message event comes from a sender the bot does not respond to
+func (f *messageFilter) ignores(ctx context.Context, ev *MessageEvent) bool {
+	if ev.User == slackbotUserID {
+		return f.ignoreSlackbot || f.ignoreAllBots
+	}
+
+	if len(ev.BotID) == 0 {
+		return false
+	}
+
+	if f.allowedBots[ev.BotID] {
+		return false
+	}
+	if f.ignoredBots[ev.BotID] {
+		return true
+	}
+
+	if len(f.ignoredApps) > 0 || len(f.allowedApps) > 0 {
+		appID := f.appID(ctx, ev.BotID)
+		if f.allowedApps[appID] {
+			return false
+		}
+		if f.ignoredApps[appID] {
+			return true
+		}
+	}
+	return f.ignoreAllBots
+}
+
+// appID returns the app of the bot, remembering it since bots do not move between apps
+func (f *messageFilter) appID(ctx context.Context, botID string) string {
+	f.mutex.Lock()
+	appID, ok := f.apps[botID]
+	f.mutex.Unlock()
+
+	if ok {
+		return appID
+	}
+
+	bot, err := f.client.GetBotInfoContext(ctx, botID)
+	if err != nil {
+		fmt.Printf("failed getting bot info: %v\n", scopeError(ctx, err, featureFilter, scopeBotsRead))
+		return empty
+	}
+
+	f.mutex.Lock()
+	f.apps[botID] = bot.AppID
+	f.mutex.Unlock()
+	return bot.AppID
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,154 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	fleetBotFormat = "%s: %w"
+)
+
+var (
+	// ErrBotExists is returned when adding a bot under a name already used in the fleet
+	ErrBotExists = errors.New("bot already exists")
+	// ErrBotNotFound is returned when referring to a bot that is not part of the fleet
+	ErrBotNotFound = errors.New("bot not found")
+)
+
+// Fleet runs several bots, each its own Slack app, in one process.
+// The bots share a store, each keeping its values under its own name, and a worker pool,
+// and can post as one another.
+type Fleet struct {
+	mutex sync.RWMutex
+	store Store
+	pool  *WorkerPool
+	bots  map[string]*Slacker
+}
+
+// NewFleet creates a fleet whose bots share the store and handle at most concurrency events at once.
+// A nil store defaults to an in-memory one.
+func NewFleet(store Store, concurrency int) *Fleet {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Fleet{store: store, pool: NewWorkerPool(concurrency), bots: make(map[string]*Slacker)}
+}
+
+// Add creates a bot with the tokens of its Slack app and adds it to the fleet under the name.
+// Options are applied after the shared store and pool, and may override them. The bot's values are
+// kept in the shared store under the name, so that bots of the fleet do not share their history,
+// undo or locales.
+func (f *Fleet) Add(name string, botToken string, appToken string, options ...ClientOption) (*Slacker, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.bots[name]; ok {
+		return nil, ErrBotExists
+	}
+
+	shared := []ClientOption{WithStore(NewNamespacedStore(f.store, name)), WithWorkerPool(f.pool)}
+	bot, err := NewClient(botToken, appToken, append(shared, options...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.fleet = f
+	f.bots[name] = bot
+	return bot, nil
+}
+
+// Bot returns the bot added under the name, or nil
+func (f *Fleet) Bot(name string) *Slacker {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.bots[name]
+}
+
+// Names returns the names of the bots in the fleet, sorted
+func (f *Fleet) Names() []string {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	names := make([]string, 0, len(f.bots))
+	for name := range f.bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Listen runs every bot of the fleet until the context is cancelled or one of them fails,
+// in which case the others are stopped and the error is returned
+func (f *Fleet) Listen(ctx context.Context) error {
+	f.mutex.RLock()
+	bots := make(map[string]*Slacker, len(f.bots))
+	for name, bot := range f.bots {
+		bots[name] = bot
+	}
+	f.mutex.RUnlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(bots))
+	for name, bot := range bots {
+		go func(name string, bot *Slacker) {
+			err := bot.Listen(ctx)
+			if err != nil && ctx.Err() == nil {
+				err = fmt.Errorf(fleetBotFormat, name, err)
+			} else {
+				err = nil
+			}
+			errs <- err
+		}(name, bot)
+	}
+
+	var first error
+	for range bots {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// Post sends a message to the channel as the named bot, letting a bot hand a conversation to another
+func (f *Fleet) Post(ctx context.Context, name string, channelID string, message string, options ...ReplyOption) error {
+	bot := f.Bot(name)
+	if bot == nil {
+		return ErrBotNotFound
+	}
+
+	defaults := NewReplyDefaults(options...)
+	err := withRateLimitRetry(ctx, func() error {
+		_, _, err := bot.client.PostMessageContext(ctx, channelID,
+			slack.MsgOptionText(message, false),
+			slack.MsgOptionAttachments(defaults.Attachments...),
+			slack.MsgOptionBlocks(defaults.Blocks...),
+		)
+		return err
+	})
+	return scopeError(ctx, err, featureMessages, scopeChatWrite)
+}
+
+// Mention returns the text mentioning the named bot, for one bot to address a command to another
+func (f *Fleet) Mention(name string) (string, error) {
+	bot := f.Bot(name)
+	if bot == nil {
+		return empty, ErrBotNotFound
+	}
+	return fmt.Sprintf(userMentionFormat, bot.botUserID), nil
+}
+
+// Fleet returns the fleet the bot was added to, or nil when it runs alone
+func (s *Slacker) Fleet() *Fleet {
+	return s.fleet
+}
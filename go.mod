@@ -1,11 +1,16 @@
 module github.com/shomali11/slacker
 
-go 1.14
+go 1.18
 
 require (
-	github.com/pkg/errors v0.8.1 // indirect
+	github.com/gorilla/websocket v1.4.2
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/shomali11/commander v0.0.0-20191122162317-51bc574c29ba
 	github.com/shomali11/proper v0.0.0-20180607004733-233a9a872c30
-	github.com/slack-go/slack v0.9.1
+	github.com/slack-go/slack v0.11.4
+)
+
+require (
+	github.com/pkg/errors v0.8.1 // indirect
 	github.com/stretchr/testify v1.3.0 // indirect
 )
@@ -0,0 +1,86 @@
+package slacker
+
+import (
+	"strings"
+)
+
+// CommandGroup registers commands sharing a prefix, such as `deploy start` and `deploy status`,
+// along with an access policy and middleware that apply to all of them
+type CommandGroup struct {
+	bot    *Slacker
+	parent *CommandGroup
+	prefix string
+
+	authorizationFunc func(botCtx BotContext, request Request) bool
+	middleware        []MiddlewareFunc
+}
+
+// Group returns a group of commands whose usages start with the prefix
+func (s *Slacker) Group(prefix string) *CommandGroup {
+	return &CommandGroup{bot: s, prefix: strings.TrimSpace(prefix)}
+}
+
+// Group returns a group nested in this one, whose commands also pass its authorization and middleware
+func (g *CommandGroup) Group(prefix string) *CommandGroup {
+	return &CommandGroup{bot: g.bot, parent: g, prefix: g.usage(prefix)}
+}
+
+// AuthorizationFunc sets the function authorizing the group's commands registered after it, on
+// top of the AuthorizationFunc of each one
+func (g *CommandGroup) AuthorizationFunc(authorizationFunc func(botCtx BotContext, request Request) bool) *CommandGroup {
+	g.authorizationFunc = authorizationFunc
+	return g
+}
+
+// Use adds middleware wrapping the handlers of the group's commands registered after it, inside
+// the bot's middleware and outside that of each command
+func (g *CommandGroup) Use(middleware ...MiddlewareFunc) *CommandGroup {
+	g.middleware = append(append([]MiddlewareFunc{}, g.middleware...), middleware...)
+	return g
+}
+
+// Command defines a new command whose usage follows the group's prefix
+func (g *CommandGroup) Command(usage string, definition *CommandDefinition) error {
+	grouped := &CommandDefinition{}
+	if definition != nil {
+		*grouped = *definition
+	}
+
+	// The outermost group comes first, for authorization and middleware alike
+	authorizationFuncs, middleware := []func(botCtx BotContext, request Request) bool{}, []MiddlewareFunc{}
+	for group := g; group != nil; group = group.parent {
+		if group.authorizationFunc != nil {
+			authorizationFuncs = append([]func(botCtx BotContext, request Request) bool{group.authorizationFunc}, authorizationFuncs...)
+		}
+		middleware = append(append([]MiddlewareFunc{}, group.middleware...), middleware...)
+	}
+
+	if grouped.AuthorizationFunc != nil {
+		authorizationFuncs = append(authorizationFuncs, grouped.AuthorizationFunc)
+	}
+	if len(authorizationFuncs) > 0 {
+		grouped.AuthorizationFunc = func(botCtx BotContext, request Request) bool {
+			for _, authorizationFunc := range authorizationFuncs {
+				if !authorizationFunc(botCtx, request) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	grouped.Middleware = append(middleware, grouped.Middleware...)
+
+	return g.bot.Command(g.usage(usage), grouped)
+}
+
+// usage returns the usage prefixed by the group's
+func (g *CommandGroup) usage(usage string) string {
+	usage = strings.TrimSpace(usage)
+	if len(g.prefix) == 0 {
+		return usage
+	}
+	if len(usage) == 0 {
+		return g.prefix
+	}
+	return g.prefix + space + usage
+}
@@ -0,0 +1,83 @@
+package slacker
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/slack-go/slack"
+)
+
+// The adapters below let handlers take the request's context as their first argument, as is usual
+// in Go, so it can be passed on to API calls, tracing and timeouts without unpacking it. The context
+// is the one of the BotContext or JobContext, and it is canceled when the bot stops or the command
+// is canceled.
+
+// CommandHandler adapts a command handler taking a context, for CommandDefinition.Handler and UndoFunc
+func CommandHandler(handler func(ctx context.Context, botCtx BotContext, request Request, response ResponseWriter)) func(botCtx BotContext, request Request, response ResponseWriter) {
+	return func(botCtx BotContext, request Request, response ResponseWriter) {
+		handler(botCtx.Context(), botCtx, request, response)
+	}
+}
+
+// MessageHandler adapts a message handler taking a context, for Message
+func MessageHandler(handler func(ctx context.Context, botCtx BotContext, response ResponseWriter)) func(botCtx BotContext, response ResponseWriter) {
+	return func(botCtx BotContext, response ResponseWriter) {
+		handler(botCtx.Context(), botCtx, response)
+	}
+}
+
+// LinkHandler adapts a link handler taking a context, for LinkShareDefinition.Handler
+func LinkHandler(handler func(ctx context.Context, botCtx BotContext, link *url.URL, response ResponseWriter)) func(botCtx BotContext, link *url.URL, response ResponseWriter) {
+	return func(botCtx BotContext, link *url.URL, response ResponseWriter) {
+		handler(botCtx.Context(), botCtx, link, response)
+	}
+}
+
+// InteractionHandler adapts an interaction handler taking a context, for Interact
+func InteractionHandler(handler func(ctx context.Context, botCtx BotContext, response ResponseWriter, callbackID string, blockID string, actionID string, value string)) func(botCtx BotContext, response ResponseWriter, callbackID string, blockID string, actionID string, value string) {
+	return func(botCtx BotContext, response ResponseWriter, callbackID string, blockID string, actionID string, value string) {
+		handler(botCtx.Context(), botCtx, response, callbackID, blockID, actionID, value)
+	}
+}
+
+// InteractiveHandler adapts an interactive handler taking a context, for CommandDefinition.Interactive
+func InteractiveHandler(handler func(ctx context.Context, botCtx BotContext, action *slack.BlockAction, response ResponseWriter)) func(botCtx BotContext, action *slack.BlockAction, response ResponseWriter) {
+	return func(botCtx BotContext, action *slack.BlockAction, response ResponseWriter) {
+		handler(botCtx.Context(), botCtx, action, response)
+	}
+}
+
+// SubmissionHandler adapts a view submission handler taking a context, for ViewSubmission
+func SubmissionHandler(handler func(ctx context.Context, botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error)) ViewSubmissionHandler {
+	return func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error) {
+		return handler(botCtx.Context(), botCtx, response, callback)
+	}
+}
+
+// ClosedHandler adapts a view closed handler taking a context, for ViewClosed
+func ClosedHandler(handler func(ctx context.Context, botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback)) func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) {
+	return func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) {
+		handler(botCtx.Context(), botCtx, response, callback)
+	}
+}
+
+// WizardHandler adapts a wizard handler taking a context, for WizardDefinition.Handler
+func WizardHandler(handler func(ctx context.Context, botCtx BotContext, response ResponseWriter, values map[string]string)) func(botCtx BotContext, response ResponseWriter, values map[string]string) {
+	return func(botCtx BotContext, response ResponseWriter, values map[string]string) {
+		handler(botCtx.Context(), botCtx, response, values)
+	}
+}
+
+// TypedHandler adapts a typed command handler taking a context, for Typed
+func TypedHandler[T any](handler func(ctx context.Context, botCtx BotContext, params T, response ResponseWriter)) func(botCtx BotContext, params T, response ResponseWriter) {
+	return func(botCtx BotContext, params T, response ResponseWriter) {
+		handler(botCtx.Context(), botCtx, params, response)
+	}
+}
+
+// JobHandler adapts a job handler taking a context, for JobDefinition.Handler
+func JobHandler(handler func(ctx context.Context, jobCtx JobContext) error) func(jobCtx JobContext) error {
+	return func(jobCtx JobContext) error {
+		return handler(jobCtx.Context(), jobCtx)
+	}
+}
@@ -0,0 +1,190 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shomali11/commander"
+	"github.com/shomali11/proper"
+)
+
+const (
+	historyCommand      = "history"
+	redoCommand         = "redo"
+	historyKeyPrefix    = "history"
+	historyDescription  = "Lists your recent commands"
+	redoDescription     = "Runs your last command again"
+	historyEntryFormat  = "%d. `%s` _%s ago_"
+	redactedValue       = "[redacted]"
+	emptyHistoryMessage = "You have not run any commands yet"
+)
+
+var (
+	errNothingToRedo  = errors.New("There is no command to redo")
+	errRedactedRedo   = errors.New("Your last command contained secret parameters and cannot be redone")
+	errUnknownCommand = errors.New("That command is no longer available")
+)
+
+// HistoryEntry is a command previously executed by a user
+type HistoryEntry struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Usage      string            `json:"usage"`
+	Parameters map[string]string `json:"parameters"`
+	Channel    string            `json:"channel"`
+	Redacted   bool              `json:"redacted"`
+}
+
+// History returns the user's recent commands, the most recent first
+func (s *Slacker) History(ctx context.Context, teamID string, userID string) ([]*HistoryEntry, error) {
+	entries := []*HistoryEntry{}
+	if _, err := s.loadValue(ctx, storeKey(historyKeyPrefix, teamID, userID), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// recordHistory adds the command to the history of the user who executed it.
+// Values of secret parameters are redacted before being stored.
+func (s *Slacker) recordHistory(botCtx BotContext, cmd BotCommand, values map[string]string) error {
+	if s.historySize <= 0 || isUntrackedCommand(cmd) {
+		return nil
+	}
+
+	ev := botCtx.Event()
+	entry := &HistoryEntry{
+		Timestamp:  s.clock.Now(),
+		Usage:      cmd.Usage(),
+		Parameters: values,
+		Channel:    ev.Channel,
+	}
+
+	for _, definition := range cmd.Definition().Parameters {
+		if _, ok := values[definition.Name]; ok && definition.Secret {
+			values[definition.Name] = redactedValue
+			entry.Redacted = true
+		}
+	}
+
+	entries, err := s.History(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		return err
+	}
+
+	entries = append([]*HistoryEntry{entry}, entries...)
+	if len(entries) > s.historySize {
+		entries = entries[:s.historySize]
+	}
+	return s.saveValue(botCtx.Context(), storeKey(historyKeyPrefix, ev.TeamID, ev.User), entries, 0)
+}
+
+func (s *Slacker) historyHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	entries, err := s.History(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		response.Reply(emptyHistoryMessage)
+		return
+	}
+
+	lines := []string{}
+	for i, entry := range entries {
+		elapsed := since(s.clock, entry.Timestamp).Round(time.Second)
+		lines = append(lines, fmt.Sprintf(historyEntryFormat, i+1, formatInvocation(entry.Usage, entry.Parameters), elapsed))
+	}
+	response.Reply(strings.Join(lines, newLine))
+}
+
+func (s *Slacker) redoHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	entries, err := s.History(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if len(entries) == 0 {
+		response.ReportError(errNothingToRedo)
+		return
+	}
+
+	entry := entries[0]
+	if entry.Redacted {
+		response.ReportError(errRedactedRedo)
+		return
+	}
+
+	cmd := s.findCommand(entry.Usage)
+	if cmd == nil {
+		response.ReportError(errUnknownCommand)
+		return
+	}
+
+	s.runCommand(botCtx, response, cmd, proper.NewProperties(entry.Parameters))
+}
+
+// appendHistoryHandles adds the history commands when enabled, it is called with the lock held
+func (s *Slacker) appendHistoryHandles() {
+	if s.historySize <= 0 {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(historyCommand, &CommandDefinition{Description: historyDescription, Handler: s.historyHandler}))
+	s.addBuiltinCommand(NewBotCommand(redoCommand, &CommandDefinition{Description: redoDescription, Handler: s.redoHandler}))
+}
+
+// isUntrackedCommand determines whether the command is a built-in that is kept out of the history
+func isUntrackedCommand(cmd BotCommand) bool {
+	return cmd.Usage() == historyCommand || cmd.Usage() == redoCommand || cmd.Usage() == undoCommand || cmd.Usage() == feedbackCommand
+}
+
+// redactedValues returns a copy of the parameter values of the command, with those of its Secret
+// parameters redacted
+func redactedValues(cmd BotCommand, values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for name, value := range values {
+		redacted[name] = value
+	}
+	for _, definition := range cmd.Definition().Parameters {
+		if _, ok := redacted[definition.Name]; ok && definition.Secret {
+			redacted[definition.Name] = redactedValue
+		}
+	}
+	return redacted
+}
+
+// redactedInvocation rebuilds the text of a command with its Secret parameters redacted, redacting
+// every value when the command is no longer registered, since its secrets are then unknown
+func (s *Slacker) redactedInvocation(usage string, values map[string]string) string {
+	cmd := s.findCommand(usage)
+	if cmd == nil {
+		redacted := make(map[string]string, len(values))
+		for name := range values {
+			redacted[name] = redactedValue
+		}
+		return formatInvocation(usage, redacted)
+	}
+	return formatInvocation(usage, redactedValues(cmd, values))
+}
+
+// formatInvocation rebuilds the text of a command from its usage and parameter values
+func formatInvocation(usage string, values map[string]string) string {
+	words := []string{}
+	for _, token := range commander.NewCommand(usage).Tokenize() {
+		if !token.IsParameter() {
+			words = append(words, token.Word)
+			continue
+		}
+
+		if value, ok := values[strings.TrimSuffix(token.Word, multiLineSuffix)]; ok {
+			words = append(words, value)
+		}
+	}
+	return strings.Join(words, space)
+}
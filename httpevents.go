@@ -0,0 +1,203 @@
+package slacker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const (
+	headerContentType   = "Content-Type"
+	contentTypeJSON     = "application/json"
+	contentTypeText     = "text/plain"
+	interactionPayload  = "payload"
+	maxEventBodyBytes   = 1 << 20
+	httpEventsAPIType   = "events_api"
+	httpInteractiveType = "interactive"
+	httpSlashType       = "slash_commands"
+)
+
+var (
+	errNoSigningSecret = errors.New("A signing secret is required to verify the requests sent by Slack")
+)
+
+// NewHTTPClient creates a new client receiving events through Slack's Events API over HTTP rather
+// than Socket Mode, for the environments where bots cannot keep a connection open, such as Cloud
+// Run or Lambda. The client is an http.Handler to serve at the Request URLs of the app's events,
+// interactivity and slash commands, rejecting the requests not signed with the signing secret.
+// Listen still has to run, the requests being refused until it does.
+func NewHTTPClient(botToken, signingSecret string, options ...ClientOption) (*Slacker, error) {
+	if len(signingSecret) == 0 {
+		return nil, errNoSigningSecret
+	}
+
+	s, err := NewClient(botToken, empty, options...)
+	if err != nil {
+		return nil, err
+	}
+	s.httpHandler = ChainHTTPMiddleware(http.HandlerFunc(s.serveEvents), s.VerifySignatures(signingSecret, 0))
+	return s, nil
+}
+
+// ServeHTTP handles the events, interactions and slash commands Slack sends to a client created
+// with NewHTTPClient. It can be wrapped with HTTPMiddleware like any other handler.
+func (s *Slacker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.httpHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.httpHandler.ServeHTTP(w, r)
+}
+
+// listenHTTP keeps the context of Listen for the requests to run in, until it is done
+func (s *Slacker) listenHTTP(ctx context.Context) error {
+	s.mutex.Lock()
+	s.httpContext = ctx
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		s.httpContext = nil
+		s.mutex.Unlock()
+	}()
+
+	fmt.Println("Listening to Slack with the Events API.")
+	s.mutex.RLock()
+	initHandler := s.initHandler
+	s.mutex.RUnlock()
+	if initHandler != nil {
+		go initHandler()
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// serveEvents handles a request whose signature was verified, telling Events API callbacks,
+// interactions and slash commands apart by their body
+func (s *Slacker) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.RLock()
+	ctx := s.httpContext
+	s.mutex.RUnlock()
+	if ctx == nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventBodyBytes))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get(headerContentType), contentTypeJSON) {
+		s.serveEventsAPI(ctx, w, body)
+		return
+	}
+
+	// Interactions and slash commands are both sent as forms, interactions in a single field
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if payload := r.PostForm.Get(interactionPayload); len(payload) > 0 {
+		s.serveInteraction(ctx, w, payload)
+		return
+	}
+
+	// SlashCommandParse reads the form again
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	r.PostForm, r.Form = nil, nil
+	command, err := slack.SlashCommandParse(r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if payload, err := json.Marshal(command); err == nil {
+		s.receivedEvent(socketmode.EventTypeSlashCommand, httpSlashType, command, payload)
+	}
+	// Slack expects an answer within 3 seconds, so the command runs after it
+	s.dispatch(func() { s.handleCommandEvent(ctx, &command) })
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveEventsAPI answers the URL verification challenge, or dispatches the callback's inner event
+func (s *Slacker) serveEventsAPI(ctx context.Context, w http.ResponseWriter, body []byte) {
+	ev, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	switch ev.Type {
+	case slackevents.URLVerification:
+		challenge := &slackevents.ChallengeResponse{}
+		if err := json.Unmarshal(body, challenge); err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(headerContentType, contentTypeText)
+		w.Write([]byte(challenge.Challenge))
+	case slackevents.CallbackEvent:
+		s.receivedEvent(socketmode.EventTypeEventsAPI, httpEventsAPIType, ev, body)
+		s.handleEventsAPIEvent(ctx, ev)
+		w.WriteHeader(http.StatusOK)
+	default:
+		s.tracef("ignored Events API request of type %q", ev.Type)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// serveInteraction runs the interaction's handler, answering with its payload, if any
+func (s *Slacker) serveInteraction(ctx context.Context, w http.ResponseWriter, payload string) {
+	callback := slack.InteractionCallback{}
+	if err := json.Unmarshal([]byte(payload), &callback); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	s.receivedEvent(socketmode.EventTypeInteractive, httpInteractiveType, callback, []byte(payload))
+	response := s.handleInteractionEvent(ctx, &callback)
+	if response == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		fmt.Printf("failed answering interaction: %v\n", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.Write(data)
+}
+
+// receivedEvent dumps and logs the request as the events received through Socket Mode are
+func (s *Slacker) receivedEvent(eventType socketmode.EventType, requestType string, data interface{}, body []byte) {
+	evt := socketmode.Event{
+		Type: eventType,
+		Data: data,
+		Request: &socketmode.Request{
+			Type:    requestType,
+			Payload: json.RawMessage(body),
+		},
+	}
+	s.dumpEvent(evt)
+	s.logEnvelope(evt)
+}
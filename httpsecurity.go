@@ -0,0 +1,185 @@
+package slacker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerForwardedFor = "X-Forwarded-For"
+	headerRealIP       = "X-Real-Ip"
+	forwardedSeparator = ","
+)
+
+// An HTTPMiddleware wraps a handler receiving Slack's HTTP requests, rejecting those that do not
+// satisfy the network policy before they reach it
+type HTTPMiddleware func(next http.Handler) http.Handler
+
+// ChainHTTPMiddleware wraps the handler with the middleware, the first one seeing requests first
+func ChainHTTPMiddleware(handler http.Handler, middleware ...HTTPMiddleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+type clientIPKey struct{}
+
+// ClientIP returns the address of the client sending the request, as resolved by
+// TrustProxyHeaders when the request went through a trusted proxy
+func ClientIP(r *http.Request) net.IP {
+	if ip, ok := r.Context().Value(clientIPKey{}).(net.IP); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// TrustProxyHeaders resolves the client address from the X-Forwarded-For and X-Real-IP headers of
+// the requests coming from the proxies, given as IP addresses or CIDR ranges. Their headers are
+// ignored for the other requests, since any client can set them.
+func TrustProxyHeaders(proxies ...string) (HTTPMiddleware, error) {
+	trusted, err := parseNetworks(proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIP(r)
+			if ip != nil && containsIP(trusted, ip) {
+				ip = forwardedIP(r, trusted, ip)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}, nil
+}
+
+// AllowIPs rejects the requests whose client address, resolved by TrustProxyHeaders when placed
+// before it, is not in the networks, given as IP addresses or CIDR ranges
+func AllowIPs(networks ...string) (HTTPMiddleware, error) {
+	allowed, err := parseNetworks(networks)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if ip == nil || !containsIP(allowed, ip) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// MutualTLSConfig returns the TLS configuration of a server requiring client certificates signed
+// by one of the authorities, to serve Slack's requests through a proxy presenting one
+func MutualTLSConfig(clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+}
+
+// RequireClientCertificate rejects the requests that did not present a verified client
+// certificate, or whose certificate allowed refuses, such as one with an unexpected subject.
+// The server verifies the certificates, with MutualTLSConfig for instance.
+func RequireClientCertificate(allowed func(certificate *x509.Certificate) bool) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			if allowed != nil && !allowed(r.TLS.VerifiedChains[0][0]) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthenticateRequests rejects the requests for which authenticate returns an error, to plug in
+// checks such as a shared header set by an API gateway
+func AuthenticateRequests(authenticate func(r *http.Request) error) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := authenticate(r); err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the last address of the forwarding chain that is not a trusted proxy,
+// since the addresses before it may have been set by the client
+func forwardedIP(r *http.Request, trusted []*net.IPNet, remote net.IP) net.IP {
+	if forwarded := r.Header.Get(headerForwardedFor); len(forwarded) > 0 {
+		hops := strings.Split(forwarded, forwardedSeparator)
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !containsIP(trusted, ip) || i == 0 {
+				return ip
+			}
+		}
+		return remote
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(headerRealIP))); ip != nil {
+		return ip
+	}
+	return remote
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// parseNetworks parses IP addresses and CIDR ranges, addresses being single host networks
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: value}
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// interactionRoute handles a block action originating from one of slacker's own features
+type interactionRoute func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction)
+
+// viewSubmissionRoute handles the submission of a view opened by one of slacker's own features.
+// The returned response, if any, is sent back to Slack to update the modal instead of closing it.
+type viewSubmissionRoute func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) *slack.ViewSubmissionResponse
+
+// routeAction registers an internal handler for block actions with the given action ID
+func (s *Slacker) routeAction(actionID string, route interactionRoute) {
+	s.actionRoutes[actionID] = route
+}
+
+// routeViewSubmission registers an internal handler for submissions of views with the given callback ID
+func (s *Slacker) routeViewSubmission(callbackID string, route viewSubmissionRoute) {
+	s.viewSubmissionRoutes[callbackID] = route
+}
+
+// handleInteractionEvent handles the interaction, returning the payload to acknowledge it with, if any
+func (s *Slacker) handleInteractionEvent(ctx context.Context, callback *slack.InteractionCallback) interface{} {
+	me := &MessageEvent{
+		Channel: callback.Channel.ID,
+		User:    callback.User.ID,
+		Text:    "",
+		Data:    callback,
+		Type:    string(callback.Type),
+		TeamID:  callback.Team.ID,
+	}
+	botCtx := s.newBotContext(ctx, me)
+	response := s.newResponse(botCtx)
+
+	if callback.Type == slack.InteractionTypeViewSubmission {
+		return s.handleViewSubmission(botCtx, response, callback)
+	}
+
+	if callback.Type == slack.InteractionTypeViewClosed {
+		s.handleViewClosed(botCtx, response, callback)
+		return nil
+	}
+
+	if callback.Type == slack.InteractionTypeBlockSuggestion {
+		return s.handleBlockSuggestion(botCtx, callback)
+	}
+
+	if len(callback.ActionCallback.BlockActions) == 0 {
+		return nil
+	}
+
+	action := callback.ActionCallback.BlockActions[0]
+	if route, ok := s.actionRoutes[action.ActionID]; ok {
+		route(botCtx, response, callback, action)
+		return nil
+	}
+
+	if s.handleControlPanelAction(botCtx, response, callback, action) {
+		return nil
+	}
+
+	if s.handleCommandInteraction(botCtx, callback, action) {
+		return nil
+	}
+
+	s.mutex.RLock()
+	interactionHandler := s.interactionHandler
+	interactions := s.interactions
+	s.mutex.RUnlock()
+
+	callbackID := callback.CallbackID
+	if len(callbackID) == 0 {
+		// Actions in modals carry the callback ID of their view
+		callbackID = callback.View.CallbackID
+	}
+	if interaction := matchInteraction(interactions, callbackID, action.BlockID, action.ActionID); interaction != nil {
+		interaction.handler(botCtx, response, callbackID, action.BlockID, action.ActionID, actionValue(action))
+		return nil
+	}
+
+	if interactionHandler == nil {
+		return nil
+	}
+	interactionHandler(botCtx, response, callback.CallbackID, action.BlockID, action.ActionID, actionValue(action))
+	return nil
+}
+
+// botInteraction is a handler added with Interaction
+type botInteraction struct {
+	pattern string
+	handler func(botCtx BotContext, response ResponseWriter, callbackID string, blockID string, actionID string, value string)
+}
+
+// matchInteraction returns the interaction whose pattern matches the action ID, block ID or
+// callback ID, in that order, preferring exact patterns to globs
+func matchInteraction(interactions []*botInteraction, callbackID string, blockID string, actionID string) *botInteraction {
+	ids := []string{}
+	for _, id := range []string{actionID, blockID, callbackID} {
+		if len(id) > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		for _, interaction := range interactions {
+			if interaction.pattern == id {
+				return interaction
+			}
+		}
+	}
+
+	for _, interaction := range interactions {
+		for _, id := range ids {
+			if matched, _ := path.Match(interaction.pattern, id); matched {
+				return interaction
+			}
+		}
+	}
+	return nil
+}
+
+// replaceInteractionMessage replaces the message containing the interaction with plain text,
+// removing its buttons so they cannot be clicked again
+func (s *Slacker) replaceInteractionMessage(botCtx BotContext, callback *slack.InteractionCallback, text string) {
+	_, _, _, err := s.client.UpdateMessageContext(
+		botCtx.Context(),
+		callback.Container.ChannelID,
+		callback.Container.MessageTs,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks([]slack.Block{}...),
+	)
+	if err != nil {
+		fmt.Printf("failed updating message: %v\n", err)
+	}
+}
+
+// viewValues returns the value of every input of the submitted view, by block ID
+func viewValues(view slack.View) map[string]string {
+	values := make(map[string]string)
+	if view.State == nil {
+		return values
+	}
+
+	for blockID, actions := range view.State.Values {
+		for _, input := range actions {
+			if value := actionValue(&input); len(value) > 0 {
+				values[blockID] = value
+			}
+		}
+	}
+	return values
+}
+
+// actionValue returns the value selected by a block action, whatever the kind of element.
+// Elements allowing several selections join their values with commas.
+func actionValue(action *slack.BlockAction) string {
+	switch slack.MessageElementType(action.Type) {
+	case slack.METDatepicker:
+		return action.SelectedDate
+	case slack.METTimepicker:
+		return action.SelectedTime
+	case slack.METOverflow, slack.METRadioButtons, slack.METCheckboxGroups:
+		return strings.Join(SelectedValues(action), selectionSeparator)
+	}
+
+	switch string(action.Type) {
+	case slack.OptTypeStatic, slack.OptTypeExternal, slack.MultiOptTypeStatic, slack.MultiOptTypeExternal:
+		return strings.Join(SelectedValues(action), selectionSeparator)
+	case slack.OptTypeUser, slack.OptTypeChannels, slack.OptTypeConversations,
+		slack.MultiOptTypeUser, slack.MultiOptTypeChannels, slack.MultiOptTypeConversations:
+		return selectionValue(action)
+	}
+	return action.Value
+}
@@ -0,0 +1,71 @@
+package slacker
+
+import (
+	"context"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	commandMetadataType  = "slacker_command"
+	commandMetadataUsage = "usage"
+)
+
+// commandMetadata returns the metadata tying a message to the running command when the command
+// handles the interactions of its messages, so that they can be routed back to it
+func commandMetadata(botCtx BotContext) (slack.SlackMetadata, bool) {
+	exec := executionFromContext(botCtx.Context())
+	if exec == nil || exec.command.Definition().Interactive == nil {
+		return slack.SlackMetadata{}, false
+	}
+
+	return slack.SlackMetadata{
+		EventType:    commandMetadataType,
+		EventPayload: map[string]interface{}{commandMetadataUsage: exec.command.Usage()},
+	}, true
+}
+
+// handleCommandInteraction passes the interaction to the Interactive handler of the command that
+// posted the message, and reports whether there was one. Users clicking must pass the command's
+// AuthorizationFunc, as if they ran it, and panics are recovered as those of commands are.
+func (s *Slacker) handleCommandInteraction(botCtx BotContext, callback *slack.InteractionCallback, action *slack.BlockAction) bool {
+	metadata := callback.Message.Metadata
+	if metadata.EventType != commandMetadataType {
+		return false
+	}
+
+	usage, _ := metadata.EventPayload[commandMetadataUsage].(string)
+	cmd := s.findCommand(usage)
+	if cmd == nil || cmd.Definition().Interactive == nil {
+		return false
+	}
+
+	// Running as the command means the messages the handler posts are routed back to it as well
+	ctx, cancel := context.WithCancel(botCtx.Context())
+	defer cancel()
+
+	parameters := proper.NewProperties(map[string]string{})
+	exec := &execution{command: cmd, parameters: parameters, cancel: cancel}
+	defer exec.finishTasks()
+	botCtx = s.newBotContext(withExecution(ctx, exec), botCtx.Event())
+	response := s.withTranslation(botCtx, s.newResponse(botCtx))
+	request := s.newRequest(botCtx, parameters)
+	defer s.releaseRequest(request)
+
+	definition := cmd.Definition()
+	if definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx, request) {
+		s.tracef("interaction with `%s` was not authorized for user %s", cmd.Usage(), botCtx.Event().User)
+		response.ReportError(s.authorizationError())
+		return true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(botCtx, response, cmd, r)
+		}
+	}()
+
+	definition.Interactive(botCtx, action, response)
+	return true
+}
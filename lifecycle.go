@@ -0,0 +1,143 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shomali11/proper"
+)
+
+var (
+	// ErrAlreadyListening is returned when the bot is configured after Listen was called
+	// without hot reload enabled, or when Listen is called twice
+	ErrAlreadyListening = errors.New("slacker is already listening")
+)
+
+// register applies a change to the bot's configuration. Changes are only allowed
+// during the registration phase, before Listen, unless hot reload is enabled.
+func (s *Slacker) register(change func()) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running && !s.hotReload {
+		return ErrAlreadyListening
+	}
+
+	change()
+	return nil
+}
+
+// start moves the bot from the registration phase to the running phase
+func (s *Slacker) start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return ErrAlreadyListening
+	}
+	s.running = true
+
+	if !s.initialized {
+		s.prependHelpHandle()
+		s.appendHistoryHandles()
+		s.appendUndoHandle()
+		s.appendFeedbackHandle()
+		s.appendReportHandle()
+		s.appendSetupHandle()
+		s.appendMuteHandles()
+		s.appendReceiptsHandle()
+		s.appendStatusHandle()
+		s.appendForgetMeHandle()
+		s.appendSudoHandle()
+		s.appendDiagnosticsHandle()
+		s.appendDelayHandles()
+		s.appendRecurringHandles()
+		s.initialized = true
+	}
+	return nil
+}
+
+// stop moves the bot back to the registration phase once Listen returns
+func (s *Slacker) stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.running = false
+}
+
+// commands returns the registered commands. The slice is never modified in place,
+// so it can be iterated while commands are being registered.
+func (s *Slacker) commands() []BotCommand {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.botCommands
+}
+
+// addCommand appends a command without modifying slices previously returned by commands
+func (s *Slacker) addCommand(command BotCommand) {
+	commands := make([]BotCommand, len(s.botCommands), len(s.botCommands)+1)
+	copy(commands, s.botCommands)
+	s.botCommands = append(commands, command)
+}
+
+// addBuiltinCommand appends a command of the bot itself, rather than one the user registered
+func (s *Slacker) addBuiltinCommand(command BotCommand) {
+	s.addCommand(&builtinCommand{BotCommand: command})
+}
+
+// builtinCommand marks the commands of the bot itself, which are kept out of tools and semantic matching
+type builtinCommand struct {
+	BotCommand
+}
+
+func isBuiltinCommand(cmd BotCommand) bool {
+	_, ok := cmd.(*builtinCommand)
+	return ok
+}
+
+// userCommands returns the commands the user registered, leaving out the built-in ones
+func userCommands(commands []BotCommand) []BotCommand {
+	registered := []BotCommand{}
+	for _, cmd := range commands {
+		if !isBuiltinCommand(cmd) {
+			registered = append(registered, cmd)
+		}
+	}
+	return registered
+}
+
+func (s *Slacker) newBotContext(ctx context.Context, ev *MessageEvent) BotContext {
+	s.mutex.RLock()
+	botContextConstructor := s.botContextConstructor
+	s.mutex.RUnlock()
+
+	return botContextConstructor(ctx, s.client, s.socketModeClient, ev)
+}
+
+func (s *Slacker) newRequest(botCtx BotContext, properties *proper.Properties) Request {
+	if r, ok := s.acquireRequest(botCtx, properties); ok {
+		return r
+	}
+
+	s.mutex.RLock()
+	requestConstructor := s.requestConstructor
+	s.mutex.RUnlock()
+
+	return requestConstructor(botCtx, properties)
+}
+
+func (s *Slacker) newResponse(botCtx BotContext) ResponseWriter {
+	s.mutex.RLock()
+	responseConstructor := s.responseConstructor
+	s.mutex.RUnlock()
+
+	return responseConstructor(botCtx)
+}
+
+func (s *Slacker) authorizationError() error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.unAuthorizedError
+}
@@ -1,12 +1,31 @@
 package slacker
 
-import "net/url"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
 
-// LinkShareDefinition structure contains definition of the bot LinkShare
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// LinkShareDefinition structure contains definition of the bot LinkShare.
+// The handler finds the channel, timestamp and user of the message in botCtx.Event(), and the raw
+// event with LinkShared. Its response replies in the thread of the message unless told otherwise
+// with WithThreadReply(false).
 type LinkShareDefinition struct {
 	Description string
 	Example     string
 	Handler     func(botCtx BotContext, request *url.URL, response ResponseWriter)
+
+	// Priority orders the handlers of a domain, higher first and then in the order they were defined
+	Priority int
+
+	// Scopes are the OAuth scopes the handler needs beyond receiving links, such as "links:write"
+	Scopes []string
 }
 
 // NewBotLinkShare creates a new bot LinkShare object
@@ -47,3 +66,110 @@ func (c *botLinkShare) Execute(botCtx BotContext, request *url.URL, response Res
 	}
 	c.definition.Handler(botCtx, request, response)
 }
+
+type linkStateKey struct{}
+
+// linkState tells whether a handler stopped the processing of the shared links
+type linkState struct {
+	stopped bool
+}
+
+// StopLinkHandling marks the shared links as handled, so that the handlers that would run after the
+// current one, for this link and the others of the message, are skipped
+func StopLinkHandling(botCtx BotContext) {
+	if state, ok := botCtx.Context().Value(linkStateKey{}).(*linkState); ok {
+		state.stopped = true
+	}
+}
+
+// handleLinks runs the handlers of the links of the message, once per distinct URL and by priority
+func (s *Slacker) handleLinks(ctx context.Context, ev *MessageEvent, linkEvt *slackevents.LinkSharedEvent, linkShares []BotLinkShare) {
+	state := &linkState{}
+	botCtx := s.newBotContext(context.WithValue(ctx, linkStateKey{}, state), ev)
+	response := &threadedResponse{ResponseWriter: s.withTranslation(botCtx, s.newResponse(botCtx))}
+
+	ordered := make([]BotLinkShare, len(linkShares))
+	copy(ordered, linkShares)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return linkPriority(ordered[i]) > linkPriority(ordered[j])
+	})
+
+	seen := make(map[string]bool)
+	for _, shared := range linkEvt.Links {
+		if seen[shared.URL] {
+			continue
+		}
+		seen[shared.URL] = true
+
+		value, err := url.Parse(shared.URL)
+		if err != nil {
+			fmt.Printf("failed parsing shared link: %v\n", err)
+			continue
+		}
+
+		for _, link := range ordered {
+			if link.Domain() != shared.Domain {
+				continue
+			}
+			link.Execute(botCtx, value, response)
+			if state.stopped {
+				return
+			}
+		}
+	}
+}
+
+func linkPriority(link BotLinkShare) int {
+	if link.Definition() == nil {
+		return 0
+	}
+	return link.Definition().Priority
+}
+
+// LinkShared returns the raw event when the message event is a shared link, and nil otherwise
+func (e *MessageEvent) LinkShared() *slackevents.LinkSharedEvent {
+	linkEvt, _ := e.Data.(*slackevents.LinkSharedEvent)
+	return linkEvt
+}
+
+// threadedResponse replies in the thread of the event by default
+type threadedResponse struct {
+	ResponseWriter
+}
+
+func (r *threadedResponse) Reply(text string, options ...ReplyOption) error {
+	return r.ResponseWriter.Reply(text, threaded(options)...)
+}
+
+func (r *threadedResponse) ReportError(err error, options ...ReportErrorOption) {
+	r.ResponseWriter.ReportError(err, append([]ReportErrorOption{WithThreadError(true)}, options...)...)
+}
+
+func (r *threadedResponse) FileUpload(title string, comment string, filename string, filetype string, reader io.Reader, options ...ReplyOption) error {
+	return r.ResponseWriter.FileUpload(title, comment, filename, filetype, reader, threaded(options)...)
+}
+
+func (r *threadedResponse) StartTask(title string, options ...ReplyOption) Task {
+	return r.ResponseWriter.StartTask(title, threaded(options)...)
+}
+
+func (r *threadedResponse) StartTimer(title string, until time.Time, options ...ReplyOption) Timer {
+	return r.ResponseWriter.StartTimer(title, until, threaded(options)...)
+}
+
+func (r *threadedResponse) ReplyTable(name string, rows [][]string, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyTable(name, rows, threaded(options)...)
+}
+
+func (r *threadedResponse) ReplyChart(spec *ChartSpec, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyChart(spec, threaded(options)...)
+}
+
+func (r *threadedResponse) ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyBlocks(blocks, threaded(options)...)
+}
+
+// threaded puts the thread reply first, so that the options given can still turn it off
+func threaded(options []ReplyOption) []ReplyOption {
+	return append([]ReplyOption{WithThreadReply(true)}, options...)
+}
@@ -0,0 +1,283 @@
+package slacker
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	eventAppMention     = "app_mention"
+	eventDirectMessages = "message.im"
+	eventLinkShared     = "link_shared"
+	eventReactionAdded  = "reaction_added"
+	scopeCommands       = "commands"
+	featureSlashCommand = "slash commands"
+	slashDescription    = "Runs a command"
+	slashUsageHint      = "help"
+	slashHintSeparator  = " | "
+	yamlIndent          = "  "
+	yamlListItem        = "- "
+	yamlKeySuffix       = ":"
+	yamlEmptyList       = " []"
+	jsonTag             = "json"
+	omitEmptyOption     = "omitempty"
+	tagSeparator        = ","
+)
+
+// ManifestInfo is the part of an app manifest that cannot be derived from the code. RequestURL is
+// where a client created with NewHTTPClient is served, which Slack sends events, interactions and
+// slash commands to instead of Socket Mode.
+type ManifestInfo struct {
+	Name         string
+	Description  string
+	SlashCommand string
+	RequestURL   string
+}
+
+// Manifest is a Slack app manifest, see https://api.slack.com/reference/manifests
+type Manifest struct {
+	DisplayInformation ManifestDisplayInformation `json:"display_information"`
+	Features           ManifestFeatures           `json:"features"`
+	OAuthConfig        ManifestOAuthConfig        `json:"oauth_config"`
+	Settings           ManifestSettings           `json:"settings"`
+}
+
+// ManifestDisplayInformation is how the app appears in Slack
+type ManifestDisplayInformation struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ManifestFeatures are the bot user and slash commands of the app
+type ManifestFeatures struct {
+	BotUser       ManifestBotUser         `json:"bot_user"`
+	SlashCommands []*ManifestSlashCommand `json:"slash_commands,omitempty"`
+}
+
+// ManifestBotUser is the bot user of the app
+type ManifestBotUser struct {
+	DisplayName  string `json:"display_name"`
+	AlwaysOnline bool   `json:"always_online"`
+}
+
+// ManifestSlashCommand is a slash command of the app
+type ManifestSlashCommand struct {
+	Command      string `json:"command"`
+	Description  string `json:"description"`
+	URL          string `json:"url,omitempty"`
+	UsageHint    string `json:"usage_hint,omitempty"`
+	ShouldEscape bool   `json:"should_escape"`
+}
+
+// ManifestOAuthConfig holds the OAuth scopes of the app
+type ManifestOAuthConfig struct {
+	Scopes ManifestScopes `json:"scopes"`
+}
+
+// ManifestScopes are the OAuth scopes requested for the bot token
+type ManifestScopes struct {
+	Bot []string `json:"bot"`
+}
+
+// ManifestSettings are the events, interactivity and connection settings of the app
+type ManifestSettings struct {
+	EventSubscriptions ManifestEventSubscriptions `json:"event_subscriptions"`
+	Interactivity      ManifestInteractivity      `json:"interactivity"`
+	SocketModeEnabled  bool                       `json:"socket_mode_enabled"`
+}
+
+// ManifestEventSubscriptions are the events the bot subscribes to
+type ManifestEventSubscriptions struct {
+	RequestURL string   `json:"request_url,omitempty"`
+	BotEvents  []string `json:"bot_events"`
+}
+
+// ManifestInteractivity enables buttons, menus and modals
+type ManifestInteractivity struct {
+	IsEnabled  bool   `json:"is_enabled"`
+	RequestURL string `json:"request_url,omitempty"`
+}
+
+// GenerateManifest derives an app manifest from the registered features, so that the scopes
+// and events configured in Slack stay in sync with the code. Clients created with NewHTTPClient
+// get the RequestURL of the info in place of Socket Mode.
+func (s *Slacker) GenerateManifest(info ManifestInfo) *Manifest {
+	requestURL := empty
+	if s.httpHandler != nil {
+		requestURL = info.RequestURL
+	}
+
+	manifest := &Manifest{
+		DisplayInformation: ManifestDisplayInformation{Name: info.Name, Description: info.Description},
+		Features: ManifestFeatures{
+			BotUser: ManifestBotUser{DisplayName: info.Name, AlwaysOnline: true},
+		},
+		Settings: ManifestSettings{
+			EventSubscriptions: ManifestEventSubscriptions{RequestURL: requestURL, BotEvents: s.botEvents()},
+			Interactivity:      ManifestInteractivity{IsEnabled: true, RequestURL: requestURL},
+			SocketModeEnabled:  s.httpHandler == nil,
+		},
+	}
+
+	required := s.requiredScopes()
+	if len(info.SlashCommand) > 0 {
+		manifest.Features.SlashCommands = append(manifest.Features.SlashCommands, &ManifestSlashCommand{
+			Command:     info.SlashCommand,
+			Description: slashDescription,
+			URL:         requestURL,
+			UsageHint:   slashUsageHint,
+		})
+		required = append(required, &ScopeError{Scope: scopeCommands, Feature: featureSlashCommand})
+	}
+
+	// Each name added with SlashCommand is described by its first definition, hinting all its usages
+	s.mutex.RLock()
+	slashCommands := s.slashCommands
+	s.mutex.RUnlock()
+
+	named := make(map[string]*ManifestSlashCommand)
+	for _, cmd := range slashCommands {
+		if strings.EqualFold(cmd.name, info.SlashCommand) {
+			continue
+		}
+
+		if slash, ok := named[cmd.name]; ok {
+			if len(cmd.usage) > 0 {
+				slash.UsageHint = strings.TrimPrefix(slash.UsageHint+slashHintSeparator+cmd.usage, slashHintSeparator)
+			}
+			continue
+		}
+
+		description := slashDescription
+		if definition := cmd.Definition(); definition != nil && len(definition.Description) > 0 {
+			description = definition.Description
+		}
+		named[cmd.name] = &ManifestSlashCommand{Command: cmd.name, Description: description, URL: requestURL, UsageHint: cmd.usage}
+		manifest.Features.SlashCommands = append(manifest.Features.SlashCommands, named[cmd.name])
+		required = append(required, &ScopeError{Scope: scopeCommands, Feature: featureSlashCommand})
+	}
+
+	scopes := []string{}
+	seen := make(map[string]bool)
+	for _, requirement := range required {
+		if !seen[requirement.Scope] {
+			seen[requirement.Scope] = true
+			scopes = append(scopes, requirement.Scope)
+		}
+	}
+	sort.Strings(scopes)
+	manifest.OAuthConfig.Scopes.Bot = scopes
+	return manifest
+}
+
+// botEvents returns the events the registered features listen to
+func (s *Slacker) botEvents() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := []string{eventAppMention, eventDirectMessages}
+	if len(s.botLinkShares) > 0 {
+		events = append(events, eventLinkShared)
+	}
+	if len(s.reactionCommands) > 0 {
+		events = append(events, eventReactionAdded)
+	}
+	if len(s.watchers) > 0 {
+		events = append(events, eventChannelMessages)
+	}
+	return events
+}
+
+// JSON encodes the manifest as JSON
+func (m *Manifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, empty, yamlIndent)
+}
+
+// YAML encodes the manifest as YAML
+func (m *Manifest) YAML() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writeYAML(buffer, reflect.ValueOf(m).Elem(), 0)
+	return buffer.Bytes(), nil
+}
+
+// writeYAML writes the value in block style, naming struct fields after their json tags
+func writeYAML(buffer *bytes.Buffer, value reflect.Value, depth int) {
+	indent := strings.Repeat(yamlIndent, depth)
+	switch value.Kind() {
+	case reflect.Ptr:
+		writeYAML(buffer, value.Elem(), depth)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			name, omitEmpty := yamlFieldName(value.Type().Field(i))
+			field := value.Field(i)
+			if omitEmpty && field.IsZero() {
+				continue
+			}
+			writeYAMLEntry(buffer, indent+name+yamlKeySuffix, field, depth)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			if isYAMLScalar(item) {
+				buffer.WriteString(indent + yamlListItem + yamlScalar(item) + newLine)
+				continue
+			}
+
+			// The first field goes on the line of the dash, the others are aligned with it
+			nested := &bytes.Buffer{}
+			writeYAML(nested, item, depth+1)
+			buffer.WriteString(indent + yamlListItem + strings.TrimPrefix(nested.String(), indent+yamlIndent))
+		}
+	default:
+		buffer.WriteString(indent + yamlScalar(value) + newLine)
+	}
+}
+
+func writeYAMLEntry(buffer *bytes.Buffer, key string, value reflect.Value, depth int) {
+	if isYAMLScalar(value) {
+		buffer.WriteString(key + space + yamlScalar(value) + newLine)
+		return
+	}
+	if value.Kind() == reflect.Slice && value.Len() == 0 {
+		buffer.WriteString(key + yamlEmptyList + newLine)
+		return
+	}
+	buffer.WriteString(key + newLine)
+	writeYAML(buffer, value, depth+1)
+}
+
+func isYAMLScalar(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr:
+		return isYAMLScalar(value.Elem())
+	case reflect.Struct, reflect.Slice:
+		return false
+	}
+	return true
+}
+
+// yamlScalar writes strings double quoted, which YAML reads the same way as JSON
+func yamlScalar(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return strconv.Quote(value.String())
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	}
+	return empty
+}
+
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := strings.Split(field.Tag.Get(jsonTag), tagSeparator)
+	name := tag[0]
+	if len(name) == 0 {
+		name = field.Name
+	}
+	return name, len(tag) > 1 && tag[1] == omitEmptyOption
+}
@@ -0,0 +1,157 @@
+package slacker
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/shomali11/proper"
+)
+
+// commandIndex narrows down the commands that can match a message before their expressions run.
+// A command only matches when each of its literal words is a word of the message, so commands are
+// indexed by their first literal word and only those whose words all appear are tried, in the order
+// they were registered so that the first match is the same as with a linear scan. A prefix trie
+// would only find the commands starting the message, while commands match anywhere in its text and
+// may implement Match themselves, so the index narrows the candidates and leaves the match to them.
+type commandIndex struct {
+	commands []BotCommand
+	anchored map[string][]int
+	literals [][]string
+	floating []int
+}
+
+func newCommandIndex(commands []BotCommand) *commandIndex {
+	index := &commandIndex{
+		commands: commands,
+		anchored: make(map[string][]int),
+		literals: make([][]string, len(commands)),
+	}
+
+	for i, cmd := range commands {
+		for _, token := range cmd.Tokenize() {
+			if !token.IsParameter() {
+				index.literals[i] = append(index.literals[i], strings.ToLower(token.Word))
+			}
+		}
+
+		if len(index.literals[i]) == 0 {
+			index.floating = append(index.floating, i)
+			continue
+		}
+		anchor := index.literals[i][0]
+		index.anchored[anchor] = append(index.anchored[anchor], i)
+	}
+	return index
+}
+
+// match returns the first command matching the text, with its parameters
+func (x *commandIndex) match(text string) (BotCommand, *proper.Properties) {
+	scratch := matchScratchPool.Get().(*matchScratch)
+	defer scratch.release()
+
+	scratch.words = appendFields(scratch.words[:0], text)
+	scratch.candidates = append(scratch.candidates[:0], x.floating...)
+	for _, word := range scratch.words {
+		scratch.candidates = append(scratch.candidates, x.anchored[strings.ToLower(word)]...)
+	}
+	sort.Ints(scratch.candidates)
+
+	previous := -1
+	for _, i := range scratch.candidates {
+		// A command is a candidate once per occurrence of its first word
+		if i == previous || !containsWords(scratch.words, x.literals[i]) {
+			previous = i
+			continue
+		}
+		previous = i
+
+		if parameters, isMatch := x.commands[i].Match(text); isMatch {
+			return x.commands[i], parameters
+		}
+	}
+	return nil, nil
+}
+
+// matchScratch holds the buffers used while matching, reused across messages
+type matchScratch struct {
+	words      []string
+	candidates []int
+}
+
+// release returns the buffers to the pool, without keeping the words of the text alive
+func (m *matchScratch) release() {
+	for i := range m.words {
+		m.words[i] = empty
+	}
+	matchScratchPool.Put(m)
+}
+
+var matchScratchPool = sync.Pool{
+	New: func() interface{} {
+		return &matchScratch{}
+	},
+}
+
+// appendFields appends the words of the text separated by white space, without copying them
+func appendFields(words []string, text string) []string {
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+func containsWords(words []string, literals []string) bool {
+	for _, literal := range literals {
+		found := false
+		for _, word := range words {
+			if strings.EqualFold(word, literal) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// commandMatcher keeps the index of the registered commands, rebuilding it when they change
+type commandMatcher struct {
+	mutex sync.Mutex
+	index *commandIndex
+}
+
+// indexFor returns the index of the commands. Registering a command replaces the slice,
+// so comparing it with the indexed one tells whether the index is still current.
+func (m *commandMatcher) indexFor(commands []BotCommand) *commandIndex {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.index == nil || !sameCommands(m.index.commands, commands) {
+		m.index = newCommandIndex(commands)
+	}
+	return m.index
+}
+
+func sameCommands(a []BotCommand, b []BotCommand) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
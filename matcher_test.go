@@ -0,0 +1,92 @@
+package slacker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func benchmarkCommands(count int) []BotCommand {
+	commands := make([]BotCommand, 0, count)
+	for i := 0; i < count; i++ {
+		commands = append(commands, NewBotCommand(fmt.Sprintf("service%d deploy <environment> <version>", i), &CommandDefinition{}))
+	}
+	return commands
+}
+
+func TestCommandIndexMatchesLikeLinearScan(t *testing.T) {
+	commands := append(benchmarkCommands(10),
+		NewBotCommand("deploy <service>", &CommandDefinition{}),
+		NewBotCommand("status", &CommandDefinition{}),
+	)
+	index := newCommandIndex(commands)
+
+	for _, text := range []string{"service3 deploy prod 1.2", "please SERVICE9 deploy staging 2", "deploy api", "status please", "hello", empty} {
+		var want BotCommand
+		for _, cmd := range commands {
+			if _, isMatch := cmd.Match(text); isMatch {
+				want = cmd
+				break
+			}
+		}
+
+		got, _ := index.match(text)
+		if got != want {
+			t.Errorf("%q: the index matched %v, the linear scan %v", text, usageOf(got), usageOf(want))
+		}
+	}
+}
+
+func usageOf(cmd BotCommand) string {
+	if cmd == nil {
+		return "nothing"
+	}
+	return cmd.Usage()
+}
+
+func BenchmarkMatch(b *testing.B) {
+	for _, count := range []int{10, 100, 1000} {
+		commands := benchmarkCommands(count)
+		index := newCommandIndex(commands)
+		text := fmt.Sprintf("service%d deploy production 1.2.3", count-1)
+
+		b.Run(fmt.Sprintf("linear/%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, cmd := range commands {
+					if _, isMatch := cmd.Match(text); isMatch {
+						break
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("index/%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				index.match(text)
+			}
+		})
+	}
+}
+
+func BenchmarkMatchMiss(b *testing.B) {
+	for _, count := range []int{10, 100, 1000} {
+		commands := benchmarkCommands(count)
+		index := newCommandIndex(commands)
+		const text = "what is the weather like today"
+
+		b.Run(fmt.Sprintf("linear/%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, cmd := range commands {
+					cmd.Match(text)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("index/%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				index.match(text)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package slacker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// isAddressedToBot determines whether a message event could contain a command.
+// Mentions in channels arrive as app_mention events, so plain message events
+// are only considered when they were sent in a direct message.
+func isAddressedToBot(ev *MessageEvent) bool {
+	switch slackevents.EventsAPIType(ev.Type) {
+	case slackevents.AppMention:
+		return true
+	case slackevents.Message:
+		return strings.HasPrefix(ev.Channel, directChannelMarker)
+	}
+	return false
+}
+
+// commandText extracts the text to be matched against commands from a message.
+// It returns false when the message did not address the bot in a way that
+// should trigger commands.
+func (s *Slacker) commandText(ev *MessageEvent) (string, bool) {
+	mention := fmt.Sprintf(userMentionFormat, s.botUserID)
+	text := strings.TrimSpace(ev.Text)
+
+	switch {
+	case strings.HasPrefix(text, mention):
+		text = strings.TrimPrefix(text, mention)
+	case s.mentionAnywhere && strings.Contains(text, mention):
+		text = strings.Replace(text, mention, space, -1)
+	case strings.HasPrefix(ev.Channel, directChannelMarker):
+	default:
+		return empty, false
+	}
+
+	return stripStopWords(text, s.stopWords), true
+}
+
+// stripStopWords removes the stop words from each line of the text, ignoring case.
+// Anything from the first code block onwards is left untouched.
+func stripStopWords(text string, stopWords []string) string {
+	if len(stopWords) == 0 {
+		return strings.TrimSpace(text)
+	}
+
+	code := empty
+	if index := strings.Index(text, codeFence); index >= 0 {
+		text, code = text[:index], text[index:]
+	}
+
+	lines := strings.Split(text, newLine)
+	for i, line := range lines {
+		words := []string{}
+		for _, word := range strings.Fields(line) {
+			if !isStopWord(word, stopWords) {
+				words = append(words, word)
+			}
+		}
+		lines[i] = strings.Join(words, space)
+	}
+	return strings.TrimSpace(strings.Join(lines, newLine) + space + code)
+}
+
+func isStopWord(word string, stopWords []string) bool {
+	for _, stopWord := range stopWords {
+		if strings.EqualFold(word, stopWord) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	metricHandlerDuration = "slacker_handler_duration_seconds"
+	metricSlowHandlers    = "slacker_slow_handlers_total"
+	metricEventBacklog    = "slacker_event_backlog"
+	metricLabelCommand    = "command"
+	metricLabelChannel    = "channel"
+	metricLabelTeam       = "team"
+	metricOtherValue      = "other"
+	metricBucketPrefix    = "bucket-"
+	slowHandlerFormat     = "slow handler: `%s` took %s, over %s\n"
+	eventBacklogFormat    = "event backlog: %d of %d events are waiting to be handled\n"
+	backlogWarningPeriod  = time.Minute
+)
+
+// A Metrics interface receives the measurements of the bot, such as to export them to Prometheus.
+// Count adds the value to a counter, Observe records it in a histogram and Gauge sets a gauge to it.
+type Metrics interface {
+	Count(name string, value float64, labels map[string]string)
+	Observe(name string, value float64, labels map[string]string)
+	Gauge(name string, value float64, labels map[string]string)
+}
+
+// MetricLabels choose the labels of the command metrics, only the command by default. To bound
+// the number of series in big workspaces, channel and team values are hashed into HashBuckets
+// buckets when it is set, and only the first MaxValues distinct values of each are kept when it
+// is set, the others being labeled "other".
+type MetricLabels struct {
+	Command     bool
+	Channel     bool
+	Team        bool
+	MaxValues   int
+	HashBuckets int
+}
+
+// metricLabeler labels the command metrics, keeping track of the values seen to cap them
+type metricLabeler struct {
+	labels *MetricLabels
+	mutex  sync.Mutex
+	seen   map[string]map[string]bool
+}
+
+func newMetricLabeler(labels *MetricLabels) *metricLabeler {
+	if labels == nil {
+		labels = &MetricLabels{Command: true}
+	}
+	return &metricLabeler{labels: labels, seen: make(map[string]map[string]bool)}
+}
+
+// commandLabels returns the labels of the command's metrics for the event
+func (l *metricLabeler) commandLabels(cmd BotCommand, ev *MessageEvent) map[string]string {
+	labels := map[string]string{}
+	if l.labels.Command {
+		labels[metricLabelCommand] = cmd.Usage()
+	}
+	if l.labels.Channel {
+		labels[metricLabelChannel] = l.bounded(metricLabelChannel, ev.Channel)
+	}
+	if l.labels.Team {
+		labels[metricLabelTeam] = l.bounded(metricLabelTeam, ev.TeamID)
+	}
+	return labels
+}
+
+// bounded returns the value to use for the label, hashed or capped when configured
+func (l *metricLabeler) bounded(label string, value string) string {
+	if l.labels.HashBuckets > 0 {
+		hash := fnv.New32a()
+		hash.Write([]byte(value))
+		value = metricBucketPrefix + strconv.Itoa(int(hash.Sum32()%uint32(l.labels.HashBuckets)))
+	}
+	if l.labels.MaxValues <= 0 {
+		return value
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	seen, ok := l.seen[label]
+	if !ok {
+		seen = make(map[string]bool)
+		l.seen[label] = seen
+	}
+	if seen[value] {
+		return value
+	}
+	if len(seen) >= l.labels.MaxValues {
+		return metricOtherValue
+	}
+	seen[value] = true
+	return value
+}
+
+// observeHandler records how long the command's handler ran, and warns when it ran slower than the threshold
+func (s *Slacker) observeHandler(botCtx BotContext, cmd BotCommand, started time.Time) {
+	ctx := botCtx.Context()
+	elapsed := since(s.clock, started)
+	labels := s.metricLabeler.commandLabels(cmd, botCtx.Event())
+	if s.metrics != nil {
+		s.metrics.Observe(metricHandlerDuration, elapsed.Seconds(), labels)
+	}
+
+	if s.slowHandlerThreshold <= 0 || elapsed < s.slowHandlerThreshold {
+		return
+	}
+
+	fmt.Printf(slowHandlerFormat, cmd.Usage(), elapsed.Round(time.Millisecond), s.slowHandlerThreshold)
+	if s.metrics != nil {
+		s.metrics.Count(metricSlowHandlers, 1, labels)
+	}
+	s.adminNotifier.notify(ctx, adminSlowKind, fmt.Sprintf(adminSlowFormat, cmd.Usage(), elapsed.Round(time.Millisecond)))
+}
+
+// checkEventBacklog records how many socket mode events are waiting, and warns at most once per
+// period while they are over the threshold. It is called from the Listen loop only.
+func (s *Slacker) checkEventBacklog(ctx context.Context) {
+	backlog, capacity := len(s.socketModeClient.Events), cap(s.socketModeClient.Events)
+	if s.metrics != nil {
+		s.metrics.Gauge(metricEventBacklog, float64(backlog), nil)
+	}
+
+	if s.eventBacklogThreshold <= 0 || backlog < s.eventBacklogThreshold {
+		return
+	}
+	if since(s.clock, s.backlogWarned) < backlogWarningPeriod {
+		return
+	}
+	s.backlogWarned = s.clock.Now()
+
+	fmt.Printf(eventBacklogFormat, backlog, capacity)
+	s.adminNotifier.notify(ctx, adminBacklogKind, fmt.Sprintf(adminBacklogFormat, backlog, capacity))
+}
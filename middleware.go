@@ -0,0 +1,41 @@
+package slacker
+
+// CommandHandlerFunc handles a command, it is the signature of CommandDefinition's Handler
+type CommandHandlerFunc func(botCtx BotContext, request Request, response ResponseWriter)
+
+// MiddlewareFunc wraps a command handler, running code around it or instead of it, such as
+// logging, metrics, authorization or panic recovery. It calls next to carry on.
+type MiddlewareFunc func(next CommandHandlerFunc) CommandHandlerFunc
+
+// Use adds middleware wrapping the handlers of every command, the first one added being the
+// outermost. The middleware of each command's definition runs inside it.
+func (s *Slacker) Use(middleware ...MiddlewareFunc) error {
+	return s.register(func() {
+		s.middleware = append(append([]MiddlewareFunc{}, s.middleware...), middleware...)
+	})
+}
+
+// execute runs the command's handler through the bot's middleware and the command's own
+func (s *Slacker) execute(botCtx BotContext, request Request, response ResponseWriter, cmd BotCommand) {
+	s.withMiddleware(cmd, cmd.Execute)(botCtx, request, response)
+}
+
+// withMiddleware wraps the handler of the command, or of its Pipe, with the bot's middleware and the command's own
+func (s *Slacker) withMiddleware(cmd BotCommand, handler CommandHandlerFunc) CommandHandlerFunc {
+	s.mutex.RLock()
+	middleware := s.middleware
+	s.mutex.RUnlock()
+
+	if definition := cmd.Definition(); definition != nil {
+		handler = chainMiddleware(handler, definition.Middleware)
+	}
+	return chainMiddleware(handler, middleware)
+}
+
+// chainMiddleware wraps the handler with the middleware, the first one being the outermost
+func chainMiddleware(handler CommandHandlerFunc, middleware []MiddlewareFunc) CommandHandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,120 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	schemaKeyPrefix         = "schema"
+	schemaVersionKey        = "version"
+	storeSweepJob           = "store-sweep"
+	storeSweepDesc          = "Removes the expired values of the store"
+	defaultStoreSweepPeriod = 10 * time.Minute
+)
+
+var (
+	// ErrInvalidMigration is returned when registering a migration without a function, or that does
+	// not move the schema to a later version
+	ErrInvalidMigration = errors.New("a migration needs a function and a later version")
+)
+
+// A MigrationFunc moves the state kept in the store from one schema version to the next
+type MigrationFunc func(ctx context.Context, store Store) error
+
+type migration struct {
+	from int
+	to   int
+	fn   MigrationFunc
+}
+
+// Migrate registers a migration of the state kept in the store from one schema version to a later
+// one. Before handling events, Listen runs the migrations leading on from the version of the store,
+// zero when it was never migrated, and records each version reached. Replicas sharing a store may
+// run a migration at the same time, so migrations should be idempotent.
+func (s *Slacker) Migrate(from int, to int, fn MigrationFunc) error {
+	if fn == nil || to <= from {
+		return ErrInvalidMigration
+	}
+
+	return s.register(func() {
+		s.migrations = append(s.migrations, &migration{from: from, to: to, fn: fn})
+	})
+}
+
+// SchemaVersion returns the schema version of the state kept in the store
+func (s *Slacker) SchemaVersion(ctx context.Context) (int, error) {
+	// The version is not encoded with the codec, since a migration may be what changes it
+	value, err := s.store.Get(ctx, storeKey(schemaKeyPrefix, schemaVersionKey))
+	if errors.Is(err, ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// migrate runs the registered migrations leading on from the version of the store
+func (s *Slacker) migrate(ctx context.Context) error {
+	s.mutex.RLock()
+	migrations := s.migrations
+	s.mutex.RUnlock()
+
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed reading the schema version: %w", err)
+	}
+
+	for {
+		next := nextMigration(migrations, version)
+		if next == nil {
+			return nil
+		}
+
+		fmt.Printf("migrating the store from version %d to %d\n", next.from, next.to)
+		if err := next.fn(ctx, s.store); err != nil {
+			return fmt.Errorf("failed migrating the store from version %d to %d: %w", next.from, next.to, err)
+		}
+
+		key := storeKey(schemaKeyPrefix, schemaVersionKey)
+		if err := s.store.Set(ctx, key, []byte(strconv.Itoa(next.to)), 0); err != nil {
+			return fmt.Errorf("failed saving the schema version: %w", err)
+		}
+		version = next.to
+	}
+}
+
+// nextMigration returns the first registered migration from the version
+func nextMigration(migrations []*migration, version int) *migration {
+	for _, m := range migrations {
+		if m.from == version {
+			return m
+		}
+	}
+	return nil
+}
+
+// scheduleStoreSweep sweeps the expired values of the store periodically, when it is a Sweeper
+func (s *Slacker) scheduleStoreSweep(period time.Duration) error {
+	sweeper, ok := s.store.(Sweeper)
+	if !ok || period <= 0 {
+		return nil
+	}
+
+	return s.scheduler.add(storeSweepJob, &JobDefinition{
+		Description: storeSweepDesc,
+		Schedule:    Every(period),
+		Handler: func(jobCtx JobContext) error {
+			_, err := sweeper.Sweep(jobCtx.Context())
+			return err
+		},
+	})
+}
@@ -0,0 +1,110 @@
+package slacker
+
+import (
+	"errors"
+
+	"github.com/slack-go/slack"
+)
+
+var (
+	errNoTrigger = errors.New("Modals can only be opened in response to a slash command or an interaction")
+)
+
+// ViewSubmissionHandler handles the submission of a modal, whose entered values are in
+// callback.View.State. The returned response, if any, is sent back to Slack to update the modal
+// instead of closing it. A returned error keeps the modal open as well, ValidationErrors showing
+// below their inputs and other errors as a generic error below the first input.
+type ViewSubmissionHandler func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error)
+
+// ViewSubmission handles the submissions of the modals with the callback ID
+func (s *Slacker) ViewSubmission(callbackID string, viewSubmissionHandler ViewSubmissionHandler) error {
+	return s.register(func() {
+		handlers := make(map[string]ViewSubmissionHandler, len(s.submissionHandlers)+1)
+		for id, handler := range s.submissionHandlers {
+			handlers[id] = handler
+		}
+		handlers[callbackID] = viewSubmissionHandler
+		s.submissionHandlers = handlers
+	})
+}
+
+// ViewClosed handles the modals with the callback ID being closed without submitting them, which
+// Slack only reports for the views opened with NotifyOnClose
+func (s *Slacker) ViewClosed(callbackID string, viewClosedHandler func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback)) error {
+	return s.register(func() {
+		handlers := make(map[string]func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback), len(s.closedHandlers)+1)
+		for id, handler := range s.closedHandlers {
+			handlers[id] = handler
+		}
+		handlers[callbackID] = viewClosedHandler
+		s.closedHandlers = handlers
+	})
+}
+
+// handleViewSubmission runs the handler of the submitted view, returning the response to update it with, if any
+func (s *Slacker) handleViewSubmission(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) interface{} {
+	if route, ok := s.viewSubmissionRoutes[callback.View.CallbackID]; ok {
+		if payload := route(botCtx, response, callback); payload != nil {
+			return payload
+		}
+		return nil
+	}
+
+	s.mutex.RLock()
+	handler := s.submissionHandlers[callback.View.CallbackID]
+	s.mutex.RUnlock()
+
+	if handler == nil {
+		s.tracef("no handler for the submission of view %q", callback.View.CallbackID)
+		return nil
+	}
+	payload, err := handler(botCtx, response, callback)
+	if err != nil {
+		payload = submissionResponse(callback.View, err)
+	}
+	if payload != nil {
+		return payload
+	}
+	return nil
+}
+
+// handleViewClosed runs the handler of the closed view
+func (s *Slacker) handleViewClosed(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) {
+	s.mutex.RLock()
+	handler := s.closedHandlers[callback.View.CallbackID]
+	s.mutex.RUnlock()
+
+	if handler == nil {
+		s.tracef("no handler for the closing of view %q", callback.View.CallbackID)
+		return
+	}
+	handler(botCtx, response, callback)
+}
+
+// OpenModal opens the modal for the user who ran the slash command or clicked the button being handled
+func (r *response) OpenModal(view slack.ModalViewRequest) error {
+	triggerID := eventTriggerID(r.botCtx.Event())
+	if len(triggerID) == 0 {
+		return errNoTrigger
+	}
+
+	return withRateLimitRetry(r.botCtx.Context(), func() error {
+		_, err := r.botCtx.Client().OpenViewContext(r.botCtx.Context(), triggerID, view)
+		return err
+	})
+}
+
+// eventTriggerID returns the trigger ID of the slash command or interaction, modals needing one to open
+func eventTriggerID(ev *MessageEvent) string {
+	if ev == nil {
+		return empty
+	}
+
+	switch data := ev.Data.(type) {
+	case *slack.SlashCommand:
+		return data.TriggerID
+	case *slack.InteractionCallback:
+		return data.TriggerID
+	}
+	return empty
+}
@@ -0,0 +1,142 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	muteKeyPrefix       = "mute"
+	muteCommand         = "mute <duration>"
+	unmuteCommand       = "unmute"
+	muteDurationParam   = "duration"
+	muteDescription     = "Stops the bot posting in this channel for a while, such as `mute 2h`"
+	unmuteDescription   = "Lets the bot post in this channel again"
+	defaultMuteDuration = time.Hour
+	mutedFormat         = "I will stay quiet in this channel until %s"
+	unmutedMessage      = "I can post in this channel again"
+	muteTimeFormat      = "<!date^%d^{date_short_pretty} at {time}|%s>"
+)
+
+// mutes reads the channels muted in the store from the responses
+type mutes struct {
+	store Store
+	codec Codec
+	clock Clock
+}
+
+type mutesKey struct{}
+
+// muteState is the stored state of a muted channel
+type muteState struct {
+	Until time.Time `json:"until"`
+}
+
+func withMutes(ctx context.Context, store Store, codec Codec, clock Clock) context.Context {
+	return context.WithValue(ctx, mutesKey{}, &mutes{store: store, codec: codec, clock: clock})
+}
+
+// channelMuted reports whether the channel is muted. Responses created outside of Listen never are.
+func channelMuted(ctx context.Context, teamID string, channelID string) bool {
+	m, ok := ctx.Value(mutesKey{}).(*mutes)
+	if !ok {
+		return false
+	}
+
+	until, muted, err := m.mutedUntil(ctx, teamID, channelID)
+	if err != nil {
+		fmt.Printf("failed loading mute: %v\n", err)
+		return false
+	}
+	return muted && m.clock.Now().Before(until)
+}
+
+func (m *mutes) mutedUntil(ctx context.Context, teamID string, channelID string) (time.Time, bool, error) {
+	state := &muteState{}
+	found, err := loadStoreValue(ctx, m.store, m.codec, storeKey(muteKeyPrefix, teamID, channelID), state)
+	return state.Until, found, err
+}
+
+// Mute stops the bot posting in the channel for the duration, except for replies sent WithCritical
+// and error reports
+func (s *Slacker) Mute(ctx context.Context, teamID string, channelID string, duration time.Duration) error {
+	state := &muteState{Until: s.clock.Now().Add(duration)}
+	return s.saveValue(ctx, storeKey(muteKeyPrefix, teamID, channelID), state, duration)
+}
+
+// Unmute lets the bot post in the channel again
+func (s *Slacker) Unmute(ctx context.Context, teamID string, channelID string) error {
+	err := s.store.Delete(ctx, storeKey(muteKeyPrefix, teamID, channelID))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// MutedUntil returns when the channel stops being muted, and whether it is muted
+func (s *Slacker) MutedUntil(ctx context.Context, teamID string, channelID string) (time.Time, bool, error) {
+	until, muted, err := (&mutes{store: s.store, codec: s.codec, clock: s.clock}).mutedUntil(ctx, teamID, channelID)
+	if err != nil || !muted || !s.clock.Now().Before(until) {
+		return time.Time{}, false, err
+	}
+	return until, true, nil
+}
+
+// appendMuteHandles adds the mute and unmute commands when they are enabled, it is called with the lock held
+func (s *Slacker) appendMuteHandles() {
+	if !s.muteCommands {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(muteCommand, &CommandDefinition{
+		Description:       muteDescription,
+		Example:           "mute 30m",
+		Handler:           s.muteHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead},
+	}))
+	s.addBuiltinCommand(NewBotCommand(unmuteCommand, &CommandDefinition{
+		Description:       unmuteDescription,
+		Handler:           s.unmuteHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead},
+	}))
+}
+
+func (s *Slacker) muteHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	duration := defaultMuteDuration
+	if value := request.Param(muteDurationParam); len(value) > 0 {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			response.ReportError(fmt.Errorf(invalidTypeError, muteDurationParam, durationType))
+			return
+		}
+		duration = parsed
+	}
+
+	ev := botCtx.Event()
+	if err := s.Mute(botCtx.Context(), ev.TeamID, ev.Channel, duration); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	until := s.clock.Now().Add(duration)
+	text := fmt.Sprintf(mutedFormat, fmt.Sprintf(muteTimeFormat, until.Unix(), until.Format(time.RFC1123)))
+	if err := response.Reply(text, WithCritical(true)); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func (s *Slacker) unmuteHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	if err := s.Unmute(botCtx.Context(), ev.TeamID, ev.Channel); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if err := response.Reply(unmutedMessage); err != nil {
+		response.ReportError(err)
+	}
+}
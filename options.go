@@ -0,0 +1,284 @@
+package slacker
+
+import (
+	"net/http"
+	"time"
+)
+
+// Options groups the configuration of a client by concern, as an alternative to passing one
+// ClientOption per setting, covering every setting of ClientDefaults. It is applied with
+// WithOptions, which can be mixed with the other options, the last one given winning. Fields
+// left zero or nil keep the current value, so toggles are pointers that can turn a setting off
+// with Bool(false).
+type Options struct {
+	Routing       RoutingOptions
+	Limits        LimitsOptions
+	Logging       LoggingOptions
+	Observability ObservabilityOptions
+	Transport     TransportOptions
+	Storage       StorageOptions
+	Features      FeatureOptions
+	Testing       TestingOptions
+}
+
+// RoutingOptions decide which messages reach which commands
+type RoutingOptions struct {
+	MentionAnywhere    *bool
+	StopWords          []string
+	ModalFallback      *bool
+	AttachmentMatching *bool
+	EditedCommands     *bool
+	EmbeddingProvider  EmbeddingProvider
+	SemanticThreshold  float64
+	Translator         Translator
+	Language           string
+
+	IgnoredBots    []string
+	IgnoredApps    []string
+	IgnoreAllBots  *bool
+	IgnoreSlackbot *bool
+	AllowedBots    []string
+	AllowedApps    []string
+}
+
+// LimitsOptions bound how much the bot remembers and how much work it does at once
+type LimitsOptions struct {
+	HistorySize             int
+	UndoWindow              time.Duration
+	ConversationSize        int
+	ConversationTTL         time.Duration
+	ConversationTokenBudget int
+	WorkerPool              *WorkerPool
+	Concurrency             int
+	QueueSize               int
+	EventPooling            *bool
+}
+
+// LoggingOptions decide what the bot prints and records about the events it receives.
+// Debug toggles the API and Socket Mode debugging too, unless they are set themselves.
+type LoggingOptions struct {
+	Debug              *bool
+	APIDebug           *bool
+	SocketModeDebug    *bool
+	EventDumpRate      float64
+	MatcherTrace       *bool
+	UnroutedSampleRate float64
+	UnroutedTTL        time.Duration
+	ScopeAlertUser     string
+	ErrorPresenter     ErrorPresenter
+	ErrorReporter      ErrorReporter
+	EnvelopeLogging    *EnvelopeLogging
+}
+
+// ObservabilityOptions decide how the bot measures itself
+type ObservabilityOptions struct {
+	Metrics               Metrics
+	MetricLabels          *MetricLabels
+	SlowHandlerThreshold  time.Duration
+	EventBacklogThreshold int
+}
+
+// TransportOptions configure how the Slack API is reached
+type TransportOptions struct {
+	HTTPClient *http.Client
+	APIURL     string
+}
+
+// StorageOptions configure where and how the bot keeps its state
+type StorageOptions struct {
+	Store       Store
+	Codec       Codec
+	Namespace   string
+	KeyProvider KeyProvider
+	SweepPeriod time.Duration
+}
+
+// FeatureOptions enable the built-in commands and behaviors of the bot
+type FeatureOptions struct {
+	HotReload          *bool
+	Setup              *bool
+	SetupFeatures      []string
+	FeedbackSink       FeedbackSink
+	MuteCommands       *bool
+	QuietHours         *QuietHours
+	ReceiptsCommand    *bool
+	StatusCommand      *bool
+	ForgetMeCommand    *bool
+	DiagnosticsCommand *bool
+	DelayedCommands    *bool
+	RecurringCommands  *bool
+	BatchCommands      *bool
+	Pipelines          *bool
+	Sudo               *SudoDefinition
+	ArchivePolicy      *ArchivePolicy
+}
+
+// TestingOptions replace the time and the reliability of the Slack API, to exercise the bot
+type TestingOptions struct {
+	Clock          Clock
+	FaultInjection *FaultInjection
+}
+
+// Bool returns a pointer to the value, to set the toggles of Options
+func Bool(value bool) *bool {
+	return &value
+}
+
+// WithOptions applies the settings of the options that are not zero
+func WithOptions(options Options) ClientOption {
+	return func(defaults *ClientDefaults) {
+		routing := options.Routing
+		setBool(&defaults.MentionAnywhere, routing.MentionAnywhere)
+		setStrings(&defaults.StopWords, routing.StopWords)
+		setBool(&defaults.ModalFallback, routing.ModalFallback)
+		setBool(&defaults.AttachmentMatching, routing.AttachmentMatching)
+		setBool(&defaults.EditedCommands, routing.EditedCommands)
+		if routing.EmbeddingProvider != nil {
+			defaults.EmbeddingProvider = routing.EmbeddingProvider
+		}
+		setFloat(&defaults.SemanticThreshold, routing.SemanticThreshold)
+		if routing.Translator != nil {
+			defaults.Translator = routing.Translator
+		}
+		setString(&defaults.Language, routing.Language)
+		setStrings(&defaults.IgnoredBots, routing.IgnoredBots)
+		setStrings(&defaults.IgnoredApps, routing.IgnoredApps)
+		setBool(&defaults.IgnoreAllBots, routing.IgnoreAllBots)
+		setBool(&defaults.IgnoreSlackbot, routing.IgnoreSlackbot)
+		setStrings(&defaults.AllowedBots, routing.AllowedBots)
+		setStrings(&defaults.AllowedApps, routing.AllowedApps)
+
+		limits := options.Limits
+		setInt(&defaults.HistorySize, limits.HistorySize)
+		setDuration(&defaults.UndoWindow, limits.UndoWindow)
+		setInt(&defaults.ConversationSize, limits.ConversationSize)
+		setDuration(&defaults.ConversationTTL, limits.ConversationTTL)
+		setInt(&defaults.ConversationTokenBudget, limits.ConversationTokenBudget)
+		if limits.WorkerPool != nil {
+			defaults.WorkerPool = limits.WorkerPool
+		}
+		setInt(&defaults.Concurrency, limits.Concurrency)
+		setInt(&defaults.QueueSize, limits.QueueSize)
+		setBool(&defaults.EventPooling, limits.EventPooling)
+
+		logging := options.Logging
+		if logging.Debug != nil {
+			WithDebug(*logging.Debug)(defaults)
+		}
+		setBool(&defaults.APIDebug, logging.APIDebug)
+		setBool(&defaults.SocketModeDebug, logging.SocketModeDebug)
+		setFloat(&defaults.EventDumpRate, logging.EventDumpRate)
+		setBool(&defaults.MatcherTrace, logging.MatcherTrace)
+		setFloat(&defaults.UnroutedSampleRate, logging.UnroutedSampleRate)
+		setDuration(&defaults.UnroutedTTL, logging.UnroutedTTL)
+		setString(&defaults.ScopeAlertUser, logging.ScopeAlertUser)
+		if logging.ErrorPresenter != nil {
+			defaults.ErrorPresenter = logging.ErrorPresenter
+		}
+		if logging.ErrorReporter != nil {
+			defaults.ErrorReporter = logging.ErrorReporter
+		}
+		if logging.EnvelopeLogging != nil {
+			defaults.EnvelopeLogging = logging.EnvelopeLogging
+		}
+
+		observability := options.Observability
+		if observability.Metrics != nil {
+			defaults.Metrics = observability.Metrics
+		}
+		if observability.MetricLabels != nil {
+			defaults.MetricLabels = observability.MetricLabels
+		}
+		setDuration(&defaults.SlowHandlerThreshold, observability.SlowHandlerThreshold)
+		setInt(&defaults.EventBacklogThreshold, observability.EventBacklogThreshold)
+
+		transport := options.Transport
+		if transport.HTTPClient != nil {
+			defaults.HTTPClient = transport.HTTPClient
+		}
+		setString(&defaults.APIURL, transport.APIURL)
+
+		storage := options.Storage
+		if storage.Store != nil {
+			defaults.Store = storage.Store
+		}
+		if storage.Codec != nil {
+			defaults.Codec = storage.Codec
+		}
+		setString(&defaults.StoreNamespace, storage.Namespace)
+		if storage.KeyProvider != nil {
+			defaults.StoreKeyProvider = storage.KeyProvider
+		}
+		setDuration(&defaults.StoreSweepPeriod, storage.SweepPeriod)
+
+		features := options.Features
+		setBool(&defaults.HotReload, features.HotReload)
+		setBool(&defaults.Setup, features.Setup)
+		setStrings(&defaults.SetupFeatures, features.SetupFeatures)
+		if features.FeedbackSink != nil {
+			defaults.FeedbackSink = features.FeedbackSink
+		}
+		setBool(&defaults.MuteCommands, features.MuteCommands)
+		if features.QuietHours != nil {
+			defaults.QuietHours = features.QuietHours
+		}
+		setBool(&defaults.ReceiptsCommand, features.ReceiptsCommand)
+		setBool(&defaults.StatusCommand, features.StatusCommand)
+		setBool(&defaults.ForgetMeCommand, features.ForgetMeCommand)
+		setBool(&defaults.DiagnosticsCommand, features.DiagnosticsCommand)
+		setBool(&defaults.DelayedCommands, features.DelayedCommands)
+		setBool(&defaults.RecurringCommands, features.RecurringCommands)
+		setBool(&defaults.BatchCommands, features.BatchCommands)
+		setBool(&defaults.Pipelines, features.Pipelines)
+		if features.Sudo != nil {
+			defaults.Sudo = features.Sudo
+		}
+		if features.ArchivePolicy != nil {
+			defaults.ArchivePolicy = features.ArchivePolicy
+		}
+
+		testing := options.Testing
+		if testing.Clock != nil {
+			defaults.Clock = testing.Clock
+		}
+		if testing.FaultInjection != nil {
+			defaults.FaultInjection = testing.FaultInjection
+		}
+	}
+}
+
+func setBool(target *bool, value *bool) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+func setString(target *string, value string) {
+	if len(value) > 0 {
+		*target = value
+	}
+}
+
+func setStrings(target *[]string, values []string) {
+	if len(values) > 0 {
+		*target = values
+	}
+}
+
+func setInt(target *int, value int) {
+	if value != 0 {
+		*target = value
+	}
+}
+
+func setFloat(target *float64, value float64) {
+	if value != 0 {
+		*target = value
+	}
+}
+
+func setDuration(target *time.Duration, value time.Duration) {
+	if value != 0 {
+		*target = value
+	}
+}
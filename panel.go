@@ -0,0 +1,182 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	panelKeyPrefix  = "panel"
+	panelMaxRetries = 5
+)
+
+var (
+	// ErrControlPanelNotFound is returned when posting a control panel that was not registered
+	ErrControlPanelNotFound = errors.New("control panel not found")
+	// ErrControlPanelConflict is returned when a control panel kept changing while handling an interaction
+	ErrControlPanelConflict = errors.New("control panel was changed concurrently, please try again")
+)
+
+// ControlPanelDefinition structure contains the definition of a long-lived interactive message,
+// such as a deploy control panel or a feature flag dashboard. Its state is kept in the store and
+// the message is rendered again after every interaction.
+type ControlPanelDefinition struct {
+	Title  string
+	Render func(state map[string]string) []slack.Block
+	// Handler applies the interaction to the state. It is run again when another interaction changed
+	// the panel concurrently, so it must be free of side effects.
+	Handler func(botCtx BotContext, state map[string]string, actionID string, value string) error
+	// OnChange acts on the interaction, such as starting the deploy, once its state is stored
+	OnChange          func(botCtx BotContext, state map[string]string, actionID string, value string) error
+	AuthorizationFunc func(botCtx BotContext) bool
+}
+
+// panelRecord is the stored state of a posted control panel.
+// Version increases with every change so concurrent interactions can be detected.
+type panelRecord struct {
+	Name    string            `json:"name"`
+	Version int               `json:"version"`
+	State   map[string]string `json:"state"`
+}
+
+// ControlPanel registers a control panel that can then be posted with PostControlPanel
+func (s *Slacker) ControlPanel(name string, definition *ControlPanelDefinition) error {
+	return s.register(func() {
+		s.panels[name] = definition
+	})
+}
+
+// PostControlPanel posts the control panel to the channel with its initial state, returning the message timestamp
+func (s *Slacker) PostControlPanel(ctx context.Context, name string, channelID string, state map[string]string) (string, error) {
+	definition := s.controlPanel(name)
+	if definition == nil {
+		return empty, ErrControlPanelNotFound
+	}
+
+	if state == nil {
+		state = make(map[string]string)
+	}
+
+	_, timestamp, err := s.client.PostMessageContext(ctx, channelID, panelMessageOptions(definition, state)...)
+	if err != nil {
+		return empty, err
+	}
+
+	record := &panelRecord{Name: name, State: state}
+	return timestamp, s.saveValue(ctx, storeKey(panelKeyPrefix, channelID, timestamp), record, 0)
+}
+
+func (s *Slacker) controlPanel(name string) *ControlPanelDefinition {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.panels[name]
+}
+
+func (s *Slacker) hasControlPanels() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.panels) > 0
+}
+
+// handleControlPanelAction applies the action to the state of the control panel it was clicked in,
+// reporting whether the message was a control panel
+func (s *Slacker) handleControlPanelAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) bool {
+	if !s.hasControlPanels() {
+		return false
+	}
+
+	key := storeKey(panelKeyPrefix, callback.Container.ChannelID, callback.Container.MessageTs)
+	data, err := s.store.Get(botCtx.Context(), key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false
+	}
+	if err != nil {
+		response.ReportError(err)
+		return true
+	}
+
+	for attempt := 0; attempt < panelMaxRetries; attempt++ {
+		record := &panelRecord{}
+		if err := s.codec.Unmarshal(data, record); err != nil {
+			response.ReportError(err)
+			return true
+		}
+
+		definition := s.controlPanel(record.Name)
+		if definition == nil {
+			return false
+		}
+
+		if definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx) {
+			response.ReportError(s.authorizationError())
+			return true
+		}
+
+		// The handler works on a copy so a conflicting attempt leaves no trace
+		state := make(map[string]string)
+		for name, value := range record.State {
+			state[name] = value
+		}
+
+		if err := definition.Handler(botCtx, state, action.ActionID, actionValue(action)); err != nil {
+			response.ReportError(err)
+			return true
+		}
+
+		updated, err := s.codec.Marshal(&panelRecord{Name: record.Name, Version: record.Version + 1, State: state})
+		if err != nil {
+			response.ReportError(err)
+			return true
+		}
+
+		swapped, err := s.compareAndSwap(botCtx.Context(), key, data, updated, 0)
+		if err != nil {
+			response.ReportError(err)
+			return true
+		}
+
+		if swapped {
+			s.renderControlPanel(botCtx, callback, definition, state)
+			if definition.OnChange != nil {
+				if err := definition.OnChange(botCtx, state, action.ActionID, actionValue(action)); err != nil {
+					response.ReportError(err)
+				}
+			}
+			return true
+		}
+
+		// Another interaction changed the panel first, try again against its state
+		data, err = s.store.Get(botCtx.Context(), key)
+		if err != nil {
+			response.ReportError(err)
+			return true
+		}
+	}
+
+	response.ReportError(ErrControlPanelConflict)
+	return true
+}
+
+func (s *Slacker) renderControlPanel(botCtx BotContext, callback *slack.InteractionCallback, definition *ControlPanelDefinition, state map[string]string) {
+	_, _, _, err := s.client.UpdateMessageContext(
+		botCtx.Context(),
+		callback.Container.ChannelID,
+		callback.Container.MessageTs,
+		panelMessageOptions(definition, state)...,
+	)
+	if err != nil {
+		fmt.Printf("failed updating control panel: %v\n", err)
+	}
+}
+
+func panelMessageOptions(definition *ControlPanelDefinition, state map[string]string) []slack.MsgOption {
+	return []slack.MsgOption{
+		slack.MsgOptionText(definition.Title, false),
+		slack.MsgOptionBlocks(definition.Render(state)...),
+	}
+}
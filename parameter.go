@@ -0,0 +1,237 @@
+package slacker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	requiredParameter     = "required"
+	choicesFormat         = "one of: %s"
+	defaultFormat         = "default: %s"
+	parameterHelpFormat   = "    • `%s`"
+	missingParameterError = "Missing required parameter `%s`"
+	invalidTypeError      = "Parameter `%s` must be a %s"
+	invalidChoiceError    = "Parameter `%s` must be one of: %s"
+	choicesSeparator      = ", "
+	usageErrorFormat      = "%w (usage: `%s`)"
+)
+
+var (
+	userParameterRegex    = regexp.MustCompile(`^(?:<@([UW][A-Z0-9]+)(?:\|[^>]*)?>|([UW][A-Z0-9]+))$`)
+	channelParameterRegex = regexp.MustCompile(`^(?:<#([CGD][A-Z0-9]+)(?:\|[^>]*)?>|([CGD][A-Z0-9]+))$`)
+)
+
+// ParameterType describes the kind of value a parameter accepts
+type ParameterType string
+
+const (
+	// StringParameter accepts any text, it is the default type
+	StringParameter ParameterType = "string"
+	// IntegerParameter accepts whole numbers
+	IntegerParameter ParameterType = "integer"
+	// FloatParameter accepts decimal numbers
+	FloatParameter ParameterType = "float"
+	// BooleanParameter accepts values such as true, false, 1 and 0
+	BooleanParameter ParameterType = "boolean"
+	// DurationParameter accepts durations such as 90s, 15m and 1h30m
+	DurationParameter ParameterType = "duration"
+	// UserParameter accepts a mention of a user, such as @alice, or a user ID
+	UserParameter ParameterType = "user"
+	// ChannelParameter accepts a mention of a channel, such as #general, or a channel ID
+	ChannelParameter ParameterType = "channel"
+)
+
+// ParameterDefinition structure contains definition of a command parameter
+type ParameterDefinition struct {
+	Name        string
+	Description string
+	Type        ParameterType
+	Required    bool
+	Choices     []string
+	Secret      bool
+
+	// Default is the value of the parameter when it is not supplied, which satisfies Required
+	Default string
+
+	// Suggestions lists values for what the user typed, to autocomplete the parameter's menu in modals
+	Suggestions func(botCtx BotContext, query string) ([]string, error)
+
+	// Provider returns the valid values of the parameter, such as environment names fetched from an
+	// API. Other values are rejected with the nearest valid ones, and they fill its menu in modals.
+	Provider func(botCtx BotContext) ([]string, error)
+
+	// ProviderTTL is how long the values returned by Provider are cached, a minute unless set
+	ProviderTTL time.Duration
+}
+
+// Validate checks that a value supplied for the parameter has the right type and is one of its choices
+func (p *ParameterDefinition) Validate(value string) error {
+	var err error
+	valid := true
+	switch p.Type {
+	case IntegerParameter:
+		_, err = strconv.Atoi(value)
+	case FloatParameter:
+		_, err = strconv.ParseFloat(value, 64)
+	case BooleanParameter:
+		_, err = strconv.ParseBool(value)
+	case DurationParameter:
+		_, err = time.ParseDuration(value)
+	case UserParameter:
+		_, valid = mentionedID(userParameterRegex, value)
+	case ChannelParameter:
+		_, valid = mentionedID(channelParameterRegex, value)
+	}
+	if err != nil || !valid {
+		return fmt.Errorf(invalidTypeError, p.Name, p.Type)
+	}
+
+	if len(p.Choices) == 0 {
+		return nil
+	}
+
+	for _, choice := range p.Choices {
+		if strings.EqualFold(value, choice) {
+			return nil
+		}
+	}
+	return fmt.Errorf(invalidChoiceError, p.Name, strings.Join(p.Choices, choicesSeparator))
+}
+
+// mentionedID returns the ID of the user or channel the value mentions, or the value itself when
+// it is an ID
+func mentionedID(mention *regexp.Regexp, value string) (string, bool) {
+	match := mention.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return empty, false
+	}
+	if len(match[1]) > 0 {
+		return match[1], true
+	}
+	return match[2], true
+}
+
+// missingParameters returns the names of required parameters that were not supplied and have no default
+func missingParameters(definitions []ParameterDefinition, parameters *proper.Properties) []string {
+	missing := []string{}
+	for _, definition := range definitions {
+		if definition.Required && len(definition.Default) == 0 && !hasParameter(parameters, definition.Name) {
+			missing = append(missing, definition.Name)
+		}
+	}
+	return missing
+}
+
+// parameterValues returns the values supplied for each of the command's parameters
+func parameterValues(cmd BotCommand, parameters *proper.Properties) map[string]string {
+	values := make(map[string]string)
+	for _, token := range cmd.Tokenize() {
+		name := strings.TrimSuffix(token.Word, multiLineSuffix)
+		if token.IsParameter() && hasParameter(parameters, name) {
+			values[name] = parameters.StringParam(name, empty)
+		}
+	}
+	return values
+}
+
+// withDefaults returns the parameters along with the Default of each definition not supplied
+func withDefaults(cmd BotCommand, parameters *proper.Properties) *proper.Properties {
+	definitions := cmd.Definition().Parameters
+	defaulted := false
+	for _, definition := range definitions {
+		if len(definition.Default) > 0 && !hasParameter(parameters, definition.Name) {
+			defaulted = true
+		}
+	}
+	if !defaulted {
+		return parameters
+	}
+
+	values := parameterValues(cmd, parameters)
+	for _, definition := range definitions {
+		if hasParameter(parameters, definition.Name) {
+			values[definition.Name] = parameters.StringParam(definition.Name, empty)
+		} else if len(definition.Default) > 0 {
+			values[definition.Name] = definition.Default
+		}
+	}
+	return proper.NewProperties(values)
+}
+
+// usageError adds the usage of the command to the error of its invalid parameters
+func usageError(cmd BotCommand, err error) error {
+	return fmt.Errorf(usageErrorFormat, err, cmd.Usage())
+}
+
+// validateParameters checks the parsed parameters against the definitions
+func validateParameters(definitions []ParameterDefinition, parameters *proper.Properties) error {
+	for i := range definitions {
+		definition := &definitions[i]
+		if !hasParameter(parameters, definition.Name) {
+			if definition.Required {
+				return fmt.Errorf(missingParameterError, definition.Name)
+			}
+			continue
+		}
+
+		if err := definition.Validate(parameters.StringParam(definition.Name, empty)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateInputs checks the values entered in a modal against the definitions,
+// returning the error of each invalid input
+func validateInputs(definitions []ParameterDefinition, values map[string]string) error {
+	validationErrors := ValidationErrors{}
+	for i := range definitions {
+		definition := &definitions[i]
+		value, ok := values[definition.Name]
+		if !ok {
+			continue
+		}
+
+		if err := definition.Validate(value); err != nil {
+			validationErrors[definition.Name] = err.Error()
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+// parameterHelp describes a parameter as a line of the help message
+func parameterHelp(definition *ParameterDefinition) string {
+	help := fmt.Sprintf(parameterHelpFormat, definition.Name)
+
+	details := []string{}
+	if definition.Type != empty && definition.Type != StringParameter {
+		details = append(details, string(definition.Type))
+	}
+	if definition.Required {
+		details = append(details, requiredParameter)
+	}
+	if len(definition.Choices) > 0 {
+		details = append(details, fmt.Sprintf(choicesFormat, strings.Join(definition.Choices, choicesSeparator)))
+	}
+	if len(definition.Default) > 0 {
+		details = append(details, fmt.Sprintf(defaultFormat, definition.Default))
+	}
+	if len(details) > 0 {
+		help += space + fmt.Sprintf(italicMessageFormat, "("+strings.Join(details, choicesSeparator)+")")
+	}
+
+	if len(definition.Description) > 0 {
+		help += space + dash + space + definition.Description
+	}
+	return help
+}
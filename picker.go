@@ -0,0 +1,55 @@
+package slacker
+
+import (
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	datePickerLayout = "2006-01-02"
+	timePickerLayout = "15:04"
+)
+
+// NewDatePicker creates a date picker showing the day of the initial time, in its location.
+// Use initial.In with the location returned by UserLocation to show the user's day.
+func NewDatePicker(actionID string, initial time.Time) *slack.DatePickerBlockElement {
+	picker := slack.NewDatePickerBlockElement(actionID)
+	if !initial.IsZero() {
+		picker.InitialDate = initial.Format(datePickerLayout)
+	}
+	return picker
+}
+
+// NewTimePicker creates a time picker showing the clock of the initial time, in its location.
+// Use initial.In with the location returned by UserLocation to show the user's time.
+func NewTimePicker(actionID string, initial time.Time) *slack.TimePickerBlockElement {
+	picker := slack.NewTimePickerBlockElement(actionID)
+	if !initial.IsZero() {
+		picker.InitialTime = initial.Format(timePickerLayout)
+	}
+	return picker
+}
+
+// UserLocation returns the time zone of the user, as set in their Slack profile
+func UserLocation(botCtx BotContext, userID string) (*time.Location, error) {
+	user, err := botCtx.Client().GetUserInfoContext(botCtx.Context(), userID)
+	if err != nil {
+		return nil, scopeError(botCtx.Context(), err, featureUsers, scopeUsersRead)
+	}
+	return time.LoadLocation(user.TZ)
+}
+
+// ParsePickedDate parses the value of a date picker as midnight of that day in the location
+func ParsePickedDate(value string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(datePickerLayout, value, location)
+}
+
+// ParsePickedTime parses the value of a time picker as that time on the day of the date, in its location
+func ParsePickedTime(value string, date time.Time) (time.Time, error) {
+	clock, err := time.Parse(timePickerLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, date.Location()), nil
+}
@@ -0,0 +1,271 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	pipeSeparator        = '|'
+	pipeOutputLimit      = 50
+	pipeTruncatedFormat  = "\n… %d more lines"
+	noPipeOutputMessage  = "The pipeline produced no output"
+	notPipeableFormat    = "`%s` has no output to pipe into the next command"
+	pipeApprovalFormat   = "`%s` needs a second approver, so it cannot run within a pipeline"
+	grepCommand          = "grep <pattern>"
+	grepDescription      = "Keeps the piped lines matching the regular expression, ignoring case"
+	grepParam            = "pattern"
+	headCommand          = "head <count?>"
+	headDescription      = "Keeps the first piped lines, 10 unless given"
+	headParam            = "count"
+	headDefaultCount     = 10
+	countCommand         = "count"
+	countDescription     = "Replaces the piped lines by their number"
+	caseInsensitiveRegex = "(?i)"
+)
+
+type pipedInputKey struct{}
+
+// PipedInput returns the lines piped into the command by the previous one, when it runs at the
+// end of a pipeline such as `list pods | grep api | restart`
+func PipedInput(botCtx BotContext) []string {
+	input, _ := botCtx.Context().Value(pipedInputKey{}).([]string)
+	return input
+}
+
+// pipelineFilters returns the built-in commands that only run within pipelines, tried before the
+// others so that their names do not have to be unique
+func pipelineFilters() []BotCommand {
+	return []BotCommand{
+		&leadingCommand{BotCommand: NewBotCommand(grepCommand, &CommandDefinition{
+			Description: grepDescription,
+			Example:     "list pods | grep api",
+			Pipe:        grepPipe,
+		})},
+		&leadingCommand{BotCommand: NewBotCommand(headCommand, &CommandDefinition{
+			Description: headDescription,
+			Example:     "list pods | head 5",
+			Parameters:  []ParameterDefinition{{Name: headParam, Type: IntegerParameter}},
+			Pipe:        headPipe,
+		})},
+		&leadingCommand{BotCommand: NewBotCommand(countCommand, &CommandDefinition{
+			Description: countDescription,
+			Example:     "list pods | count",
+			Pipe:        countPipe,
+		})},
+	}
+}
+
+func grepPipe(botCtx BotContext, request Request, input []string) ([]string, error) {
+	expression, err := regexp.Compile(caseInsensitiveRegex + request.Param(grepParam))
+	if err != nil {
+		return nil, fmt.Errorf(invalidTypeError, grepParam, "regular expression")
+	}
+
+	output := []string{}
+	for _, line := range input {
+		if expression.MatchString(line) {
+			output = append(output, line)
+		}
+	}
+	return output, nil
+}
+
+func headPipe(botCtx BotContext, request Request, input []string) ([]string, error) {
+	count := request.IntegerParam(headParam, headDefaultCount)
+	if count < 0 {
+		count = 0
+	}
+	if count < len(input) {
+		return input[:count], nil
+	}
+	return input, nil
+}
+
+func countPipe(botCtx BotContext, request Request, input []string) ([]string, error) {
+	return []string{strconv.Itoa(len(input))}, nil
+}
+
+// splitPipeline splits the text on `|`, except within code, links and mentions, which Slack
+// writes as `<url|label>`
+func splitPipeline(text string) []string {
+	stages := []string{}
+	start, brackets, backticks := 0, 0, false
+	for i, char := range text {
+		switch {
+		case char == '`':
+			backticks = !backticks
+		case char == '<' && !backticks:
+			brackets++
+		case char == '>' && !backticks && brackets > 0:
+			brackets--
+		case char == pipeSeparator && !backticks && brackets == 0:
+			stages = append(stages, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	return append(stages, strings.TrimSpace(text[start:]))
+}
+
+// executePipeline runs a message such as `list pods | grep api | restart` when pipelines are
+// enabled, and reports whether it did. Each command but the last one hands the lines returned by
+// its Pipe to the next, the last one running its Handler with them as PipedInput, or replying with
+// the lines of its Pipe without a Handler. Texts where a stage matches no command are left to run
+// as a single command, and commands needing a second approver cannot be piped.
+func (s *Slacker) executePipeline(botCtx BotContext, response ResponseWriter, text string) bool {
+	if !s.pipelines || !strings.ContainsRune(text, pipeSeparator) {
+		return false
+	}
+
+	stages := splitPipeline(text)
+	if len(stages) < 2 {
+		return false
+	}
+
+	filters, commands := newCommandIndex(pipelineFilters()), s.commands()
+	cmds := make([]BotCommand, 0, len(stages))
+	parameters := make([]*proper.Properties, 0, len(stages))
+	filtered := make([]bool, 0, len(stages))
+	for _, stage := range stages {
+		cmd, params := filters.match(stage)
+		filtered = append(filtered, cmd != nil)
+		if cmd == nil {
+			cmd, params = s.matcher.indexFor(commands).match(stage)
+		}
+		if cmd == nil {
+			s.tracef("pipeline stage %q matched no command", stage)
+			return false
+		}
+		cmds, parameters = append(cmds, cmd), append(parameters, params)
+	}
+
+	// The approval would run the command again on its own, without the stages around it
+	for _, cmd := range cmds {
+		if cmd.Definition().RequireSecondApprover {
+			response.ReportError(fmt.Errorf(pipeApprovalFormat, cmd.Usage()))
+			return true
+		}
+	}
+
+	var input []string
+	last := len(cmds) - 1
+	for i, cmd := range cmds {
+		definition := cmd.Definition()
+		if i == last && definition.Handler != nil {
+			s.tracef("pipeline stage %d of %d runs `%s` with %d lines", i+1, len(cmds), cmd.Usage(), len(input))
+			pipedCtx := s.newBotContext(context.WithValue(botCtx.Context(), pipedInputKey{}, input), botCtx.Event())
+			s.runCommand(pipedCtx, response, cmd, parameters[i])
+			return true
+		}
+
+		if definition.Pipe == nil {
+			response.ReportError(fmt.Errorf(notPipeableFormat, cmd.Usage()))
+			return true
+		}
+
+		output, ok := s.runPipe(botCtx, response, cmd, parameters[i], input, !filtered[i])
+		if !ok {
+			return true
+		}
+		s.tracef("pipeline stage %d of %d ran `%s`, turning %d lines into %d", i+1, len(cmds), cmd.Usage(), len(input), len(output))
+		input = output
+	}
+
+	if err := response.Reply(formatPipeOutput(input), WithThreadReply(botCtx.Event().IsThread())); err != nil {
+		response.ReportError(err)
+	}
+	return true
+}
+
+// runPipe runs a command of a pipeline with the checks of runCommand, returning the output of its
+// Pipe, or false when the pipeline stops there, its error having been reported
+func (s *Slacker) runPipe(botCtx BotContext, response ResponseWriter, cmd BotCommand, parameters *proper.Properties, input []string, tracked bool) (output []string, ok bool) {
+	if !s.claimCommand(botCtx.Context()) {
+		s.tracef("`%s` was not run, another version of message %s already ran a command", cmd.Usage(), botCtx.Event().TimeStamp)
+		return nil, false
+	}
+
+	definition := cmd.Definition()
+	ctx, cancel := context.WithCancel(botCtx.Context())
+	defer cancel()
+
+	parameters = withDefaults(cmd, parameters)
+	exec := &execution{command: cmd, parameters: parameters, cancel: cancel}
+	defer exec.finishTasks()
+	botCtx = s.newBotContext(withExecution(ctx, exec), botCtx.Event())
+	request := s.newRequest(botCtx, parameters)
+	defer s.releaseRequest(request)
+
+	if definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx, request) {
+		s.tracef("`%s` was not authorized for user %s", cmd.Usage(), botCtx.Event().User)
+		response.ReportError(s.authorizationError())
+		return nil, false
+	}
+
+	if err := validateParameters(definition.Parameters, parameters); err != nil {
+		response.ReportError(usageError(cmd, err))
+		return nil, false
+	}
+	if err := s.validateProvided(botCtx, definition.Parameters, parameterValues(cmd, parameters)); err != nil {
+		response.ReportError(err)
+		return nil, false
+	}
+
+	if !s.breakers.allow(cmd) {
+		s.tracef("`%s` is short-circuited by its circuit breaker", cmd.Usage())
+		response.ReportError(errCommandUnavailable)
+		return nil, false
+	}
+
+	// The pipeline filters are not worth redoing or undoing on their own
+	if tracked {
+		if err := s.recordHistory(botCtx, cmd, parameterValues(cmd, parameters)); err != nil {
+			fmt.Printf("failed recording history: %v\n", err)
+		}
+		if err := s.recordUndo(botCtx, cmd, parameterValues(cmd, parameters)); err != nil {
+			fmt.Printf("failed recording undo: %v\n", err)
+		}
+	}
+
+	outcome := &outcomeResponse{ResponseWriter: response}
+	defer s.recordExecution(botCtx, cmd, outcome, s.clock.Now())
+	defer s.breakers.record(cmd, outcome)
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(botCtx, outcome, cmd, r)
+			output, ok = nil, false
+		}
+	}()
+
+	defer s.observeHandler(botCtx, cmd, s.clock.Now())
+	// Middleware that does not call the Pipe stops the pipeline, as it would stop a command
+	s.withMiddleware(cmd, func(botCtx BotContext, request Request, response ResponseWriter) {
+		var err error
+		if output, err = definition.Pipe(botCtx, request, input); err != nil {
+			response.ReportError(err)
+			return
+		}
+		ok = true
+	})(botCtx, request, outcome)
+	return output, ok
+}
+
+// formatPipeOutput writes the lines in a code block, leaving out those past the limit
+func formatPipeOutput(lines []string) string {
+	if len(lines) == 0 {
+		return noPipeOutputMessage
+	}
+
+	truncated := empty
+	if len(lines) > pipeOutputLimit {
+		truncated = fmt.Sprintf(pipeTruncatedFormat, len(lines)-pipeOutputLimit)
+		lines = lines[:pipeOutputLimit]
+	}
+	return codeFence + newLine + strings.Join(lines, newLine) + newLine + codeFence + truncated
+}
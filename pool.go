@@ -0,0 +1,57 @@
+package slacker
+
+// WorkerPool bounds the number of events handled at the same time.
+// A pool can be shared by several bots so that together they stay within the limit.
+type WorkerPool struct {
+	slots   chan struct{}
+	pending chan struct{}
+}
+
+// NewWorkerPool creates a pool running at most size handlers at once
+func NewWorkerPool(size int) *WorkerPool {
+	return NewQueuedWorkerPool(size, 0)
+}
+
+// NewQueuedWorkerPool creates a pool running at most size handlers at once, with up to queueSize
+// more waiting for a free slot without holding up the events coming after them
+func NewQueuedWorkerPool(size int, queueSize int) *WorkerPool {
+	if size <= 0 {
+		size = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	return &WorkerPool{slots: make(chan struct{}, size), pending: make(chan struct{}, size+queueSize)}
+}
+
+// Go runs the function in a goroutine once a slot is free. It only blocks when the queue is full,
+// which holds up the events coming after until a handler returns.
+func (p *WorkerPool) Go(fn func()) {
+	p.pending <- struct{}{}
+	go func() {
+		p.slots <- struct{}{}
+		defer func() {
+			<-p.slots
+			<-p.pending
+		}()
+		fn()
+	}()
+}
+
+// queued returns the number of functions waiting for a slot and how many can wait at most
+func (p *WorkerPool) queued() (int, int) {
+	queued := len(p.pending) - len(p.slots)
+	if queued < 0 {
+		queued = 0
+	}
+	return queued, cap(p.pending) - cap(p.slots)
+}
+
+// dispatch runs the function in the bot's worker pool, or in its own goroutine without one
+func (s *Slacker) dispatch(fn func()) {
+	if s.workerPool == nil {
+		go fn()
+		return
+	}
+	s.workerPool.Go(fn)
+}
@@ -0,0 +1,258 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"text/template"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	errorReportID        = "slacker_error_report"
+	errorReportButton    = "Report this"
+	errorReportedText    = "Thanks, the error was reported to the admins"
+	errorCodeFormat      = "%s _(code `%s`)_"
+	errorReportFormat    = ":memo: <@%s> reported an error in <#%s>%s\n*Code:* `%s`\n```%s```"
+	errorCommandFormat   = " while running `%s`"
+	errorReportKeyPrefix = "error-report"
+	errorReportIDBytes   = 8
+	errorReportTTL       = 24 * time.Hour
+	maxErrorReportLength = 1500
+)
+
+// ErrorPresentation is how an error is shown to the user. Template is a text/template
+// receiving the ErrorData. With Reportable, a button lets the user send the details
+// to the admin channel.
+type ErrorPresentation struct {
+	Code       string
+	Template   string
+	Reportable bool
+
+	// template is the Template parsed once by ErrorTemplates
+	template *template.Template
+}
+
+// ErrorData is the data given to the template of an ErrorPresentation
+type ErrorData struct {
+	Error string
+	Code  string
+}
+
+// An ErrorPresenter interface chooses how errors reported by handlers are shown to users.
+// Returning nil shows the error as is.
+type ErrorPresenter interface {
+	Present(err error) *ErrorPresentation
+}
+
+// ErrorTemplates is an ErrorPresenter matching errors against rules, in the order they were added
+type ErrorTemplates struct {
+	rules    []*errorRule
+	fallback *ErrorPresentation
+}
+
+type errorRule struct {
+	matches      func(err error) bool
+	presentation *ErrorPresentation
+}
+
+// NewErrorTemplates creates an empty ErrorTemplates
+func NewErrorTemplates() *ErrorTemplates {
+	return &ErrorTemplates{}
+}
+
+// Is presents errors matching the target with errors.Is
+func (t *ErrorTemplates) Is(target error, presentation *ErrorPresentation) *ErrorTemplates {
+	t.rules = append(t.rules, &errorRule{
+		matches:      func(err error) bool { return errors.Is(err, target) },
+		presentation: parsedPresentation(presentation),
+	})
+	return t
+}
+
+// As presents errors matching the type of the target with errors.As, such as (*ScopeError)(nil)
+func (t *ErrorTemplates) As(target interface{}, presentation *ErrorPresentation) *ErrorTemplates {
+	targetType := reflect.TypeOf(target)
+	t.rules = append(t.rules, &errorRule{
+		matches: func(err error) bool {
+			// A fresh target for each error, as errors.As writes into it
+			return errors.As(err, reflect.New(targetType).Interface())
+		},
+		presentation: parsedPresentation(presentation),
+	})
+	return t
+}
+
+// Default presents the errors no rule matched
+func (t *ErrorTemplates) Default(presentation *ErrorPresentation) *ErrorTemplates {
+	t.fallback = parsedPresentation(presentation)
+	return t
+}
+
+// parsedPresentation returns a copy of the presentation with its template parsed, or the
+// presentation itself when its template does not parse, the error being reported when rendering
+func parsedPresentation(presentation *ErrorPresentation) *ErrorPresentation {
+	if presentation == nil || len(presentation.Template) == 0 {
+		return presentation
+	}
+
+	tmpl, err := template.New(presentation.Code).Parse(presentation.Template)
+	if err != nil {
+		return presentation
+	}
+	parsed := *presentation
+	parsed.template = tmpl
+	return &parsed
+}
+
+// Present returns the presentation of the first rule matching the error
+func (t *ErrorTemplates) Present(err error) *ErrorPresentation {
+	for _, rule := range t.rules {
+		if rule.matches(err) {
+			return rule.presentation
+		}
+	}
+	return t.fallback
+}
+
+type errorPresenterKey struct{}
+
+// errorPresenting is the presenter of the bot, with the store keeping the errors users may report
+type errorPresenting struct {
+	presenter ErrorPresenter
+	store     Store
+	codec     Codec
+}
+
+func withErrorPresenter(ctx context.Context, presenter ErrorPresenter, store Store, codec Codec) context.Context {
+	if presenter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, errorPresenterKey{}, &errorPresenting{presenter: presenter, store: store, codec: codec})
+}
+
+func errorPresentingFromContext(ctx context.Context) *errorPresenting {
+	presenting, _ := ctx.Value(errorPresenterKey{}).(*errorPresenting)
+	return presenting
+}
+
+// errorReport is an error the user may report, kept in the store so that the button only carries its ID
+type errorReport struct {
+	Code    string `json:"code"`
+	Error   string `json:"error"`
+	Command string `json:"command"`
+	Channel string `json:"channel"`
+}
+
+// presentError renders the error for the user, with the button to report it when reportable
+func presentError(ctx context.Context, channelID string, err error) (string, []slack.Block) {
+	text := fmt.Sprintf(errorFormat, err.Error())
+	presenting := errorPresentingFromContext(ctx)
+	if presenting == nil {
+		return text, nil
+	}
+
+	presentation := presenting.presenter.Present(err)
+	if presentation == nil {
+		return text, nil
+	}
+
+	if len(presentation.Template) > 0 {
+		rendered, renderErr := renderErrorTemplate(presentation, err)
+		if renderErr != nil {
+			fmt.Printf("failed rendering error template: %v\n", renderErr)
+		} else {
+			text = rendered
+		}
+	}
+	if len(presentation.Code) > 0 {
+		text = fmt.Sprintf(errorCodeFormat, text, presentation.Code)
+	}
+
+	if !presentation.Reportable || !adminNotifierFromContext(ctx).enabled() {
+		return text, nil
+	}
+
+	id := make([]byte, errorReportIDBytes)
+	if _, randErr := rand.Read(id); randErr != nil {
+		fmt.Printf("failed creating error report: %v\n", randErr)
+		return text, nil
+	}
+
+	report := &errorReport{Code: presentation.Code, Error: truncate(err.Error(), maxErrorReportLength), Channel: channelID}
+	if exec := executionFromContext(ctx); exec != nil {
+		report.Command = exec.command.Usage()
+	}
+	reportID := hex.EncodeToString(id)
+	if saveErr := saveStoreValue(ctx, presenting.store, presenting.codec, storeKey(errorReportKeyPrefix, reportID), report, errorReportTTL); saveErr != nil {
+		fmt.Printf("failed saving error report: %v\n", saveErr)
+		return text, nil
+	}
+
+	button := slack.NewButtonBlockElement(errorReportID, reportID, slack.NewTextBlockObject(slack.PlainTextType, errorReportButton, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewActionBlock(errorReportID, button),
+	}
+	return text, blocks
+}
+
+func renderErrorTemplate(presentation *ErrorPresentation, err error) (string, error) {
+	tmpl := presentation.template
+	if tmpl == nil {
+		parsed, parseErr := template.New(presentation.Code).Parse(presentation.Template)
+		if parseErr != nil {
+			return empty, parseErr
+		}
+		tmpl = parsed
+	}
+
+	text := &bytes.Buffer{}
+	if execErr := tmpl.Execute(text, &ErrorData{Error: err.Error(), Code: presentation.Code}); execErr != nil {
+		return empty, execErr
+	}
+	return text.String(), nil
+}
+
+// handleErrorReport files the details of the error to the admin channel when the user clicks the
+// button, once, as kept in the store rather than as sent back by the client
+func (s *Slacker) handleErrorReport(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	ctx := botCtx.Context()
+	key := storeKey(errorReportKeyPrefix, action.Value)
+	report := &errorReport{}
+	found, err := s.loadValue(ctx, key, report)
+	if err != nil {
+		fmt.Printf("failed loading error report: %v\n", err)
+		return
+	}
+	if !found {
+		s.replaceInteractionMessage(botCtx, callback, errorReportedText)
+		return
+	}
+	if err := s.store.Delete(ctx, key); err != nil {
+		fmt.Printf("failed deleting error report: %v\n", err)
+	}
+
+	command := empty
+	if len(report.Command) > 0 {
+		command = fmt.Sprintf(errorCommandFormat, report.Command)
+	}
+
+	message := fmt.Sprintf(errorReportFormat, callback.User.ID, report.Channel, command, report.Code, report.Error)
+	s.adminNotifier.send(botCtx.Context(), message)
+	s.replaceInteractionMessage(botCtx, callback, errorReportedText)
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length]) + multiLineSuffix
+}
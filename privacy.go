@@ -0,0 +1,346 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	forgetMeCommand     = "forget me"
+	forgetMeDescription = "Deletes everything the bot stored about you"
+	forgetMeDone        = "Everything I stored about you was deleted."
+)
+
+// userKeyPrefixes are the features keeping values under keys of the form prefix:team:user
+var userKeyPrefixes = []string{
+	conversationKeyPrefix,
+	delayedKeyPrefix,
+	recurringKeyPrefix,
+	historyKeyPrefix,
+	localeKeyPrefix,
+	quietUserKeyPrefix,
+	sudoKeyPrefix,
+	undoKeyPrefix,
+	wizardKeyPrefix,
+}
+
+// A UserDataSink interface is implemented by sinks keeping data about users outside the store, such
+// as a FeedbackSink or an ErrorReporter, so that they take part in exports and deletions
+type UserDataSink interface {
+	ExportUser(ctx context.Context, teamID string, userID string) (interface{}, error)
+	DeleteUser(ctx context.Context, teamID string, userID string) error
+}
+
+// UserData is everything stored about a user. Values holds the values kept under the user's keys,
+// encoded with the codec, Executions and UnroutedEvents the records of the user's messages,
+// Approvals the audit records of the commands the user requested or approved, Broadcasts when the
+// user acknowledged the broadcasts they received, zero when they did not, and Sinks what the sinks
+// implementing UserDataSink exported.
+type UserData struct {
+	TeamID         string
+	UserID         string
+	Values         map[string][]byte
+	Executions     map[string][]*ExecutionEntry
+	UnroutedEvents []*UnroutedEvent
+	Approvals      []*ApprovalEntry
+	Broadcasts     map[string]time.Time
+	Sinks          []interface{}
+}
+
+// ExportUserData returns everything stored about the user in the workspace
+func (s *Slacker) ExportUserData(ctx context.Context, teamID string, userID string) (*UserData, error) {
+	data := &UserData{TeamID: teamID, UserID: userID, Values: make(map[string][]byte), Executions: make(map[string][]*ExecutionEntry), Broadcasts: make(map[string]time.Time)}
+
+	keys, err := s.userKeys(ctx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		value, err := s.store.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data.Values[key] = value
+	}
+
+	if err := s.visitUserExecutions(ctx, teamID, userID, func(usage string, entries []*ExecutionEntry) error {
+		data.Executions[usage] = entries
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.visitUserUnrouted(ctx, teamID, userID, func(key string, event *UnroutedEvent) error {
+		data.UnroutedEvents = append(data.UnroutedEvents, event)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	approvals, err := s.Approvals(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range approvals {
+		if entry.Requester == userID || entry.Approver == userID {
+			data.Approvals = append(data.Approvals, entry)
+		}
+	}
+
+	if err := s.visitUserBroadcasts(ctx, userID, func(key string, record *broadcastRecord) error {
+		data.Broadcasts[strings.TrimPrefix(key, storeKey(broadcastKeyPrefix)+storeKeySeparator)] = record.Acknowledged[userID]
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, sink := range s.userDataSinks() {
+		exported, err := sink.ExportUser(ctx, teamID, userID)
+		if err != nil {
+			return nil, err
+		}
+		data.Sinks = append(data.Sinks, exported)
+	}
+	return data, nil
+}
+
+// DeleteUserData deletes everything stored about the user in the workspace, including the records
+// kept by the sinks implementing UserDataSink
+func (s *Slacker) DeleteUserData(ctx context.Context, teamID string, userID string) error {
+	keys, err := s.userKeys(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	if err := s.visitUserExecutions(ctx, teamID, userID, func(usage string, entries []*ExecutionEntry) error {
+		return s.removeExecutions(ctx, teamID, usage, userID)
+	}); err != nil {
+		return err
+	}
+
+	if err := s.visitUserUnrouted(ctx, teamID, userID, func(key string, event *UnroutedEvent) error {
+		return s.store.Delete(ctx, key)
+	}); err != nil {
+		return err
+	}
+
+	if err := s.removeApprovals(ctx, teamID, userID); err != nil {
+		return err
+	}
+
+	if err := s.visitUserBroadcasts(ctx, userID, func(key string, record *broadcastRecord) error {
+		recipients := []string{}
+		for _, recipient := range record.Recipients {
+			if recipient != userID {
+				recipients = append(recipients, recipient)
+			}
+		}
+		record.Recipients = recipients
+		delete(record.Acknowledged, userID)
+		return s.saveValue(ctx, key, record, broadcastRetention)
+	}); err != nil {
+		return err
+	}
+
+	for _, sink := range s.userDataSinks() {
+		if err := sink.DeleteUser(ctx, teamID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userKeys returns the keys of the features keeping values per user
+func (s *Slacker) userKeys(ctx context.Context, teamID string, userID string) ([]string, error) {
+	keys := []string{}
+	for _, prefix := range userKeyPrefixes {
+		key := storeKey(prefix, teamID, userID)
+		nested, err := s.store.Keys(ctx, key+storeKeySeparator)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(append(keys, key), nested...)
+	}
+	return keys, nil
+}
+
+// visitUserExecutions calls found with the recorded runs of each command by the user
+func (s *Slacker) visitUserExecutions(ctx context.Context, teamID string, userID string, found func(usage string, entries []*ExecutionEntry) error) error {
+	prefix := storeKey(executionKeyPrefix, teamID) + storeKeySeparator
+	keys, err := s.store.Keys(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		entries := []*ExecutionEntry{}
+		if _, err := s.loadValue(ctx, key, &entries); err != nil {
+			return err
+		}
+
+		own := []*ExecutionEntry{}
+		for _, entry := range entries {
+			if entry.User == userID {
+				own = append(own, entry)
+			}
+		}
+		if len(own) == 0 {
+			continue
+		}
+		if err := found(strings.TrimPrefix(key, prefix), own); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExecutions removes the user's runs from the recorded runs of the command
+func (s *Slacker) removeExecutions(ctx context.Context, teamID string, usage string, userID string) error {
+	key := storeKey(executionKeyPrefix, teamID, usage)
+	entries := []*ExecutionEntry{}
+	if _, err := s.loadValue(ctx, key, &entries); err != nil {
+		return err
+	}
+
+	kept := []*ExecutionEntry{}
+	for _, entry := range entries {
+		if entry.User != userID {
+			kept = append(kept, entry)
+		}
+	}
+	return s.saveValue(ctx, key, kept, 0)
+}
+
+// removeApprovals removes the audit records of the commands the user requested or approved, along
+// with the commands the user requested that are still waiting for an approver
+func (s *Slacker) removeApprovals(ctx context.Context, teamID string, userID string) error {
+	entries, err := s.Approvals(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	kept := []*ApprovalEntry{}
+	for _, entry := range entries {
+		if entry.Requester != userID && entry.Approver != userID {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) < len(entries) {
+		if err := s.saveValue(ctx, storeKey(approvalLogKeyPrefix, teamID), kept, 0); err != nil {
+			return err
+		}
+	}
+
+	keys, err := s.store.Keys(ctx, storeKey(approvalKeyPrefix, teamID)+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		request := &approvalRequest{}
+		ok, err := s.loadValue(ctx, key, request)
+		if err != nil {
+			return err
+		}
+		if ok && request.Requester == userID {
+			if err := s.store.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visitUserBroadcasts calls found with each broadcast the user received
+func (s *Slacker) visitUserBroadcasts(ctx context.Context, userID string, found func(key string, record *broadcastRecord) error) error {
+	keys, err := s.store.Keys(ctx, storeKey(broadcastKeyPrefix)+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		record := &broadcastRecord{}
+		ok, err := s.loadValue(ctx, key, record)
+		if err != nil {
+			return err
+		}
+		recipient := false
+		for _, r := range record.Recipients {
+			recipient = recipient || r == userID
+		}
+		if !ok || !recipient {
+			continue
+		}
+		if err := found(key, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitUserUnrouted calls found with each sampled message of the user
+func (s *Slacker) visitUserUnrouted(ctx context.Context, teamID string, userID string, found func(key string, event *UnroutedEvent) error) error {
+	keys, err := s.store.Keys(ctx, storeKey(unroutedKeyPrefix, teamID)+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		event := &UnroutedEvent{}
+		ok, err := s.loadValue(ctx, key, event)
+		if err != nil {
+			return err
+		}
+		if !ok || event.User != userID {
+			continue
+		}
+		if err := found(key, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userDataSinks returns the configured sinks implementing UserDataSink
+func (s *Slacker) userDataSinks() []UserDataSink {
+	sinks := []UserDataSink{}
+	for _, candidate := range []interface{}{s.feedbackSink, s.errorReporter} {
+		if sink, ok := candidate.(UserDataSink); ok {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// appendForgetMeHandle adds the forget me command when enabled, it is called with the lock held
+func (s *Slacker) appendForgetMeHandle() {
+	if !s.forgetMeCommand {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(forgetMeCommand, &CommandDefinition{
+		Description: forgetMeDescription,
+		Handler:     s.forgetMeHandler,
+	}))
+}
+
+func (s *Slacker) forgetMeHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	if err := s.DeleteUserData(botCtx.Context(), ev.TeamID, ev.User); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if err := response.Reply(forgetMeDone, WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
@@ -0,0 +1,187 @@
+package slacker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProviderTTL = time.Minute
+	maxNearbyValues    = 5
+	maxListedValues    = 10
+	nearbyValueError   = "`%s` is not valid, did you mean %s?"
+	listedValuesError  = "`%s` is not valid, try one of: %s"
+	nearbySeparator    = " or "
+	listedValueFormat  = "`%s`"
+	moreValuesSuffix   = ", …"
+)
+
+// providerCache keeps the values returned by the Provider of each parameter for its ProviderTTL,
+// by workspace since providers may answer differently for each
+type providerCache struct {
+	mutex   sync.Mutex
+	clock   Clock
+	entries map[providerCacheKey]*providerCacheEntry
+}
+
+type providerCacheKey struct {
+	definition *ParameterDefinition
+	teamID     string
+}
+
+type providerCacheEntry struct {
+	values  []string
+	expires time.Time
+}
+
+func newProviderCache(clock Clock) *providerCache {
+	return &providerCache{clock: clock, entries: make(map[providerCacheKey]*providerCacheEntry)}
+}
+
+// values returns the values of the parameter's Provider, calling it when they are not cached
+func (c *providerCache) values(botCtx BotContext, definition *ParameterDefinition) ([]string, error) {
+	key := providerCacheKey{definition: definition, teamID: botCtx.Event().TeamID}
+	now := c.clock.Now()
+
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.values, nil
+	}
+
+	values, err := definition.Provider(botCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := definition.ProviderTTL
+	if ttl <= 0 {
+		ttl = defaultProviderTTL
+	}
+
+	c.mutex.Lock()
+	c.entries[key] = &providerCacheEntry{values: values, expires: now.Add(ttl)}
+	c.mutex.Unlock()
+	return values, nil
+}
+
+// validateProvided checks the values of the parameters having a Provider against what it returns,
+// suggesting the nearest valid values for each invalid one. Values are accepted when a provider
+// fails, the handler being left to reject them.
+func (s *Slacker) validateProvided(botCtx BotContext, definitions []ParameterDefinition, values map[string]string) error {
+	validationErrors := ValidationErrors{}
+	for i := range definitions {
+		definition := &definitions[i]
+		value, ok := values[definition.Name]
+		if definition.Provider == nil || !ok {
+			continue
+		}
+
+		valid, err := s.providers.values(botCtx, definition)
+		if err != nil {
+			fmt.Printf("failed providing values: %v\n", err)
+			continue
+		}
+		if err := providedValueError(valid, value); err != nil {
+			validationErrors[definition.Name] = err.Error()
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+// providedValueError returns nil when the value is one of those valid, and otherwise an error
+// listing those nearest to it, or the first of them when none is near
+func providedValueError(valid []string, value string) error {
+	for _, candidate := range valid {
+		if strings.EqualFold(candidate, value) {
+			return nil
+		}
+	}
+
+	if nearby := nearestValues(valid, value); len(nearby) > 0 {
+		quoted := make([]string, 0, len(nearby))
+		for _, candidate := range nearby {
+			quoted = append(quoted, fmt.Sprintf(listedValueFormat, candidate))
+		}
+		return fmt.Errorf(nearbyValueError, value, strings.Join(quoted, nearbySeparator))
+	}
+
+	listed := strings.Join(valid, choicesSeparator)
+	if len(valid) > maxListedValues {
+		listed = strings.Join(valid[:maxListedValues], choicesSeparator) + moreValuesSuffix
+	}
+	return fmt.Errorf(listedValuesError, value, listed)
+}
+
+// nearestValues returns the values containing the text or a few edits away from it, the nearest first
+func nearestValues(values []string, text string) []string {
+	text = strings.ToLower(text)
+	maxDistance := len(text) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type candidate struct {
+		value    string
+		distance int
+	}
+	candidates := []candidate{}
+	for _, value := range values {
+		lower := strings.ToLower(value)
+		distance := editDistance(lower, text)
+		if len(text) > 0 && (strings.Contains(lower, text) || strings.Contains(text, lower)) {
+			distance = 0
+		}
+		if distance <= maxDistance {
+			candidates = append(candidates, candidate{value: value, distance: distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	nearest := []string{}
+	for i := 0; i < len(candidates) && i < maxNearbyValues; i++ {
+		nearest = append(nearest, candidates[i].value)
+	}
+	return nearest
+}
+
+// editDistance returns the number of insertions, deletions and substitutions turning a into b
+func editDistance(a string, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(first); i++ {
+		current := make([]int, len(second)+1)
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(second)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,276 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	quietKeyPrefix       = "quiet"
+	quietUserKeyPrefix   = "quiet-user"
+	quietDeliveryJob     = "quiet-hours-delivery"
+	quietDeliveryDesc    = "Delivers the messages held during quiet hours"
+	quietDeliveryPeriod  = time.Minute
+	quietQueueRetention  = 7 * 24 * time.Hour
+	quietMaxPeriods      = 4
+	quietQueuedKeyFormat = "%020d"
+)
+
+// QuietHours is a period of each day, between Start and End since midnight, during which messages that
+// are not critical are held and delivered once it ends. End may be before Start for periods spanning
+// midnight, such as from 22h to 7h. Weekends makes Saturdays and Sundays quiet all day. Timezone is
+// the IANA name of the time zone of the clock, the local time zone when empty.
+type QuietHours struct {
+	Start    time.Duration `json:"start"`
+	End      time.Duration `json:"end"`
+	Weekends bool          `json:"weekends"`
+	Timezone string        `json:"timezone"`
+}
+
+// Until returns when the quiet hours the time falls in end, and whether it falls in any
+func (q *QuietHours) Until(t time.Time) (time.Time, bool) {
+	location := time.Local
+	if len(q.Timezone) > 0 {
+		if loaded, err := time.LoadLocation(q.Timezone); err == nil {
+			location = loaded
+		}
+	}
+
+	quiet := false
+	// A quiet night may follow a quiet weekend, so the end of one period is checked again
+	for i := 0; i < quietMaxPeriods; i++ {
+		end, ok := q.periodEnd(t.In(location))
+		if !ok {
+			break
+		}
+		t, quiet = end, true
+	}
+	return t, quiet
+}
+
+func (q *QuietHours) periodEnd(t time.Time) (time.Time, bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if q.Weekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		days := 1
+		if t.Weekday() == time.Saturday {
+			days = 2
+		}
+		return midnight.AddDate(0, 0, days), true
+	}
+
+	offset := t.Sub(midnight)
+	switch {
+	case q.Start < q.End && offset >= q.Start && offset < q.End:
+		return midnight.Add(q.End), true
+	case q.Start > q.End && offset >= q.Start:
+		return midnight.AddDate(0, 0, 1).Add(q.End), true
+	case q.Start > q.End && offset < q.End:
+		return midnight.Add(q.End), true
+	}
+	return time.Time{}, false
+}
+
+// queuedMessage is a message held during quiet hours
+type queuedMessage struct {
+	Channel     string             `json:"channel"`
+	ThreadTS    string             `json:"thread_ts"`
+	Text        string             `json:"text"`
+	Attachments []slack.Attachment `json:"attachments"`
+	Blocks      slack.Blocks       `json:"blocks"`
+	DeliverAt   time.Time          `json:"deliver_at"`
+	Retention   time.Duration      `json:"retention"`
+	Redaction   string             `json:"redaction"`
+}
+
+// quietPolicy decides which messages are held, and delivers them with a job once their quiet hours end
+type quietPolicy struct {
+	store     Store
+	codec     Codec
+	scheduler *scheduler
+	retention *retention
+	defaults  *QuietHours
+	clock     Clock
+	swap      func(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)
+
+	mutex    sync.RWMutex
+	channels map[string]*QuietHours
+	started  sync.Once
+}
+
+type quietPolicyKey struct{}
+
+func newQuietPolicy(store Store, codec Codec, scheduler *scheduler, retention *retention, defaults *QuietHours, clock Clock, swap func(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)) *quietPolicy {
+	return &quietPolicy{store: store, codec: codec, scheduler: scheduler, retention: retention, defaults: defaults, clock: clock, swap: swap, channels: make(map[string]*QuietHours)}
+}
+
+func withQuietPolicy(ctx context.Context, policy *quietPolicy) context.Context {
+	return context.WithValue(ctx, quietPolicyKey{}, policy)
+}
+
+func quietPolicyFromContext(ctx context.Context) *quietPolicy {
+	policy, _ := ctx.Value(quietPolicyKey{}).(*quietPolicy)
+	return policy
+}
+
+// hours returns the quiet hours of the channel. In a direct message, those of the user come first.
+func (p *quietPolicy) hours(ctx context.Context, ev *MessageEvent, channelID string) (*QuietHours, error) {
+	if channelID == ev.Channel && strings.HasPrefix(channelID, directChannelMarker) {
+		hours := &QuietHours{}
+		found, err := loadStoreValue(ctx, p.store, p.codec, storeKey(quietUserKeyPrefix, ev.TeamID, ev.User), hours)
+		if err != nil || found {
+			return hours, err
+		}
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if hours, ok := p.channels[channelID]; ok {
+		return hours, nil
+	}
+	return p.defaults, nil
+}
+
+// hold queues the message when the channel is in its quiet hours, reporting whether it did
+func (p *quietPolicy) hold(ctx context.Context, ev *MessageEvent, message *queuedMessage) (bool, error) {
+	hours, err := p.hours(ctx, ev, message.Channel)
+	if err != nil || hours == nil {
+		return false, err
+	}
+
+	now := p.clock.Now()
+	until, quiet := hours.Until(now)
+	if !quiet {
+		return false, nil
+	}
+
+	p.started.Do(func() {
+		err = p.scheduler.add(quietDeliveryJob, &JobDefinition{
+			Description: quietDeliveryDesc,
+			Schedule:    Every(quietDeliveryPeriod),
+			Handler:     p.deliver,
+		})
+		if errors.Is(err, ErrJobExists) {
+			err = nil
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	message.DeliverAt = until
+	key := storeKey(quietKeyPrefix, ev.TeamID, message.Channel, fmt.Sprintf(quietQueuedKeyFormat, now.UnixNano()))
+	return true, saveStoreValue(ctx, p.store, p.codec, key, message, until.Sub(now)+quietQueueRetention)
+}
+
+// deliver posts the held messages whose quiet hours ended, in the order they were sent. Each message
+// is claimed before it is posted, so that bots sharing the store deliver it once, and a message
+// failing to post does not hold up the others. Messages Slack refuses, as in a channel the bot
+// left, are given up on, the others being put back for the next run.
+func (p *quietPolicy) deliver(jobCtx JobContext) error {
+	ctx := jobCtx.Context()
+	keys, err := p.store.Keys(ctx, quietKeyPrefix+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		data, err := p.store.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) || (err == nil && len(data) == 0) {
+			// Delivered already, or being delivered by another bot
+			continue
+		}
+		if err != nil {
+			fmt.Printf("failed loading held message: %v\n", err)
+			continue
+		}
+
+		message := &queuedMessage{}
+		if err := p.codec.Unmarshal(data, message); err != nil {
+			fmt.Printf("failed loading held message: %v\n", err)
+			continue
+		}
+		if p.clock.Now().Before(message.DeliverAt) {
+			continue
+		}
+
+		claimed, err := p.swap(ctx, key, data, []byte{}, quietDeliveryPeriod)
+		if err != nil {
+			fmt.Printf("failed claiming held message: %v\n", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := p.post(jobCtx, message); err != nil {
+			fmt.Printf("failed delivering held message to %s: %v\n", message.Channel, err)
+			if !isPermanentError(err) {
+				if err := p.store.Set(ctx, key, data, quietQueueRetention); err != nil {
+					fmt.Printf("failed holding message: %v\n", err)
+				}
+				continue
+			}
+		}
+		if err := p.store.Delete(ctx, key); err != nil {
+			fmt.Printf("failed deleting held message: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// post sends the held message, tracking its retention
+func (p *quietPolicy) post(jobCtx JobContext, message *queuedMessage) error {
+	ctx := jobCtx.Context()
+	options := []slack.MsgOption{
+		slack.MsgOptionText(message.Text, false),
+		slack.MsgOptionAttachments(message.Attachments...),
+		slack.MsgOptionBlocks(message.Blocks.BlockSet...),
+	}
+	if len(message.ThreadTS) > 0 {
+		options = append(options, slack.MsgOptionTS(message.ThreadTS))
+	}
+	_, timestamp, err := jobCtx.Client().PostMessageContext(ctx, message.Channel, options...)
+	if err != nil {
+		return err
+	}
+	if message.Retention > 0 {
+		if err := p.retention.track(ctx, message.Channel, timestamp, message.Retention, message.Redaction); err != nil {
+			fmt.Printf("failed tracking message retention: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// SetQuietHours sets the quiet hours of the channel, nil removing them in favor of the default ones
+func (s *Slacker) SetQuietHours(channelID string, hours *QuietHours) {
+	s.quietPolicy.mutex.Lock()
+	defer s.quietPolicy.mutex.Unlock()
+
+	if hours == nil {
+		delete(s.quietPolicy.channels, channelID)
+		return
+	}
+	s.quietPolicy.channels[channelID] = hours
+}
+
+// SetUserQuietHours sets the quiet hours of the user's direct messages with the bot, nil removing them
+func (s *Slacker) SetUserQuietHours(ctx context.Context, teamID string, userID string, hours *QuietHours) error {
+	key := storeKey(quietUserKeyPrefix, teamID, userID)
+	if hours == nil {
+		err := s.store.Delete(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.saveValue(ctx, key, hours, 0)
+}
@@ -0,0 +1,139 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	reactionMarker       = ":"
+	skinToneSeparator    = "::"
+	reactionMessageType  = "message"
+	reactionTextParam    = "text"
+	reactionAuthorParam  = "author"
+	reactionNameParam    = "reaction"
+	reactionMessageParam = "timestamp"
+)
+
+var (
+	errReactedMessageNotFound = errors.New("reacted message not found")
+)
+
+// ReactionEvent is the Data of the event received by reaction commands.
+// Message is the message that was reacted to, fetched from the conversation history.
+type ReactionEvent struct {
+	Reaction string
+	User     string
+	Message  *slack.Message
+	Event    *slackevents.ReactionAddedEvent
+}
+
+// ReactionCommand registers a command triggered by adding the reaction, such as ":rocket:", to any
+// message the bot can see. The request holds the message's text, author, timestamp and the reaction.
+func (s *Slacker) ReactionCommand(reaction string, definition *CommandDefinition) error {
+	name := reactionName(reaction)
+	return s.register(func() {
+		s.reactionCommands[name] = NewBotCommand(reactionMarker+name+reactionMarker, definition)
+	})
+}
+
+func (s *Slacker) reactionCommand(reaction string) BotCommand {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.reactionCommands[reactionName(reaction)]
+}
+
+// handleReactionEvent runs the command bound to the added reaction, on behalf of the user who reacted
+func (s *Slacker) handleReactionEvent(ctx context.Context, event *slackevents.ReactionAddedEvent, teamID string) {
+	if event.Item.Type != reactionMessageType || event.User == s.botUserID {
+		return
+	}
+	s.acknowledgeReaction(ctx, event)
+	s.acknowledgeBroadcastReaction(ctx, event)
+
+	cmd := s.reactionCommand(event.Reaction)
+	if cmd == nil {
+		return
+	}
+
+	message, err := s.reactedMessage(ctx, event.Item.Channel, event.Item.Timestamp)
+	if err != nil {
+		fmt.Printf("failed fetching reacted message: %v\n", err)
+		return
+	}
+
+	ev := &MessageEvent{
+		Channel:         event.Item.Channel,
+		User:            event.User,
+		Text:            message.Text,
+		TimeStamp:       message.Timestamp,
+		ThreadTimeStamp: message.ThreadTimestamp,
+		Data:            &ReactionEvent{Reaction: event.Reaction, User: event.User, Message: message, Event: event},
+		Type:            string(slackevents.ReactionAdded),
+		TeamID:          teamID,
+	}
+
+	botCtx := s.newBotContext(ctx, ev)
+	parameters := proper.NewProperties(map[string]string{
+		reactionTextParam:    message.Text,
+		reactionAuthorParam:  message.User,
+		reactionNameParam:    event.Reaction,
+		reactionMessageParam: message.Timestamp,
+	})
+	s.runCommand(botCtx, s.newResponse(botCtx), cmd, parameters)
+}
+
+// reactedMessage fetches the message with the timestamp, looking in threads for replies
+func (s *Slacker) reactedMessage(ctx context.Context, channelID string, timestamp string) (*slack.Message, error) {
+	history, err := s.client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Latest:    timestamp,
+		Oldest:    timestamp,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, scopeError(ctx, err, featureReactions, scopeHistory)
+	}
+
+	if len(history.Messages) > 0 && history.Messages[0].Timestamp == timestamp {
+		return &history.Messages[0], nil
+	}
+
+	// Thread replies are not part of the channel history. The parent of the thread always comes
+	// first, so the bounds leave the reply itself second.
+	replies, _, _, err := s.client.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: timestamp,
+		Latest:    timestamp,
+		Oldest:    timestamp,
+		Inclusive: true,
+		Limit:     2,
+	})
+	if err != nil {
+		return nil, scopeError(ctx, err, featureReactions, scopeHistory)
+	}
+
+	for i := range replies {
+		if replies[i].Timestamp == timestamp {
+			return &replies[i], nil
+		}
+	}
+	return nil, errReactedMessageNotFound
+}
+
+// reactionName returns the name of a reaction without colons or skin tone
+func reactionName(reaction string) string {
+	name := strings.Trim(reaction, reactionMarker)
+	if index := strings.Index(name, skinToneSeparator); index >= 0 {
+		name = name[:index]
+	}
+	return name
+}
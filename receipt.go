@@ -0,0 +1,273 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	broadcastKeyPrefix        = "broadcast"
+	broadcastMessageKeyPrefix = "broadcast-message"
+	broadcastAckID            = "slacker_broadcast_ack"
+	broadcastAckText          = "Got it"
+	broadcastAckedText        = "Thanks, your acknowledgment was recorded"
+	broadcastMaxRetries       = 5
+	broadcastRetention        = 90 * 24 * time.Hour
+	receiptsCommand           = "receipts <broadcast>"
+	receiptsDescription       = "Shows who has not acknowledged a broadcast yet"
+	receiptsParam             = "broadcast"
+	receiptsParamDescription  = "ID returned by Broadcast"
+	receiptsTextLength        = 200
+	receiptsSummaryFormat     = "*%s*\n%d of %d acknowledged"
+	receiptsPendingFormat     = "\nWaiting for: %s"
+	receiptsAllAcked          = "\nEveryone acknowledged it"
+	receiptsUserFormat        = "<@%s>"
+	featureBroadcastChannels  = "broadcasts to channels"
+	scopeChannelsRead         = "channels:read"
+)
+
+var (
+	// ErrInvalidBroadcast is returned when a broadcast has no text or no recipients
+	ErrInvalidBroadcast = errors.New("a broadcast needs a text and recipients")
+	// ErrBroadcastNotFound is returned when looking up a broadcast that is unknown or expired
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+	// ErrBroadcastConflict is returned when an acknowledgment kept conflicting with concurrent ones
+	ErrBroadcastConflict = errors.New("broadcast was changed concurrently, please try again")
+)
+
+// BroadcastDefinition structure contains the definition of an announcement whose recipients are
+// asked to acknowledge it. Users receive it in a direct message, and Channels get one message each,
+// whose members are the recipients. Clicking the button or reacting to the message acknowledges it.
+type BroadcastDefinition struct {
+	Text     string
+	Users    []string
+	Channels []string
+}
+
+// BroadcastStatus tells who acknowledged a broadcast and who did not yet
+type BroadcastStatus struct {
+	ID           string
+	Text         string
+	Acknowledged map[string]time.Time
+	Pending      []string
+}
+
+// broadcastRecord is the stored state of a broadcast
+type broadcastRecord struct {
+	Text         string               `json:"text"`
+	Recipients   []string             `json:"recipients"`
+	Acknowledged map[string]time.Time `json:"acknowledged"`
+}
+
+// Broadcast posts the announcement to its recipients and tracks their acknowledgments, returning its ID
+func (s *Slacker) Broadcast(ctx context.Context, definition *BroadcastDefinition) (string, error) {
+	if definition == nil || len(definition.Text) == 0 || len(definition.Users)+len(definition.Channels) == 0 {
+		return empty, ErrInvalidBroadcast
+	}
+
+	id := newTaskID()
+	recipients := stringSet(definition.Users)
+	for _, channelID := range definition.Channels {
+		members, err := s.channelMembers(ctx, channelID)
+		if err != nil {
+			return empty, err
+		}
+		for _, member := range members {
+			if member != s.botUserID {
+				recipients[member] = true
+			}
+		}
+	}
+
+	record := &broadcastRecord{Text: definition.Text, Acknowledged: make(map[string]time.Time)}
+	for recipient := range recipients {
+		record.Recipients = append(record.Recipients, recipient)
+	}
+	sort.Strings(record.Recipients)
+
+	if err := s.saveValue(ctx, storeKey(broadcastKeyPrefix, id), record, broadcastRetention); err != nil {
+		return empty, err
+	}
+
+	for _, channelID := range append(append([]string{}, definition.Users...), definition.Channels...) {
+		channel, timestamp, err := s.client.PostMessageContext(ctx, channelID, broadcastMessage(id, definition.Text)...)
+		if err != nil {
+			return id, scopeError(ctx, err, featureMessages, scopeChatWrite)
+		}
+		if err := s.saveValue(ctx, storeKey(broadcastMessageKeyPrefix, channel, timestamp), id, broadcastRetention); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+func (s *Slacker) channelMembers(ctx context.Context, channelID string) ([]string, error) {
+	members := []string{}
+	params := &slack.GetUsersInConversationParameters{ChannelID: channelID}
+	for {
+		page, cursor, err := s.client.GetUsersInConversationContext(ctx, params)
+		if err != nil {
+			return nil, scopeError(ctx, err, featureBroadcastChannels, scopeChannelsRead)
+		}
+		members = append(members, page...)
+		if len(cursor) == 0 {
+			return members, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+func broadcastMessage(id string, text string) []slack.MsgOption {
+	ack := slack.NewButtonBlockElement(broadcastAckID, id, slack.NewTextBlockObject(slack.PlainTextType, broadcastAckText, false, false))
+	ack.Style = slack.StylePrimary
+	return []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewActionBlock(broadcastAckID, ack),
+		),
+	}
+}
+
+// BroadcastStatus returns the acknowledgments of the broadcast
+func (s *Slacker) BroadcastStatus(ctx context.Context, id string) (*BroadcastStatus, error) {
+	record := &broadcastRecord{}
+	found, err := s.loadValue(ctx, storeKey(broadcastKeyPrefix, id), record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrBroadcastNotFound
+	}
+
+	status := &BroadcastStatus{ID: id, Text: record.Text, Acknowledged: record.Acknowledged, Pending: []string{}}
+	for _, recipient := range record.Recipients {
+		if _, ok := record.Acknowledged[recipient]; !ok {
+			status.Pending = append(status.Pending, recipient)
+		}
+	}
+	return status, nil
+}
+
+// acknowledgeBroadcast records that the user acknowledged the broadcast, reporting whether they are a recipient
+func (s *Slacker) acknowledgeBroadcast(ctx context.Context, id string, userID string) (bool, error) {
+	key := storeKey(broadcastKeyPrefix, id)
+	for attempt := 0; attempt < broadcastMaxRetries; attempt++ {
+		data, err := s.store.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			return false, ErrBroadcastNotFound
+		}
+		if err != nil {
+			return false, err
+		}
+
+		record := &broadcastRecord{}
+		if err := s.codec.Unmarshal(data, record); err != nil {
+			return false, err
+		}
+
+		recipient := false
+		for _, r := range record.Recipients {
+			recipient = recipient || r == userID
+		}
+		if _, acked := record.Acknowledged[userID]; acked || !recipient {
+			return recipient, nil
+		}
+
+		record.Acknowledged[userID] = s.clock.Now()
+		updated, err := s.codec.Marshal(record)
+		if err != nil {
+			return false, err
+		}
+
+		swapped, err := s.compareAndSwap(ctx, key, data, updated, broadcastRetention)
+		if err != nil {
+			return false, err
+		}
+		if swapped {
+			return true, nil
+		}
+	}
+	return false, ErrBroadcastConflict
+}
+
+// handleBroadcastAck records the acknowledgment of the user who clicked the button
+func (s *Slacker) handleBroadcastAck(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	recipient, err := s.acknowledgeBroadcast(botCtx.Context(), action.Value, callback.User.ID)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+	if !recipient {
+		return
+	}
+
+	if _, err := s.client.PostEphemeralContext(botCtx.Context(), callback.Channel.ID, callback.User.ID, slack.MsgOptionText(broadcastAckedText, false)); err != nil {
+		fmt.Printf("failed posting message: %v\n", err)
+	}
+}
+
+// acknowledgeBroadcastReaction records a reaction to a broadcast message as an acknowledgment
+func (s *Slacker) acknowledgeBroadcastReaction(ctx context.Context, event *slackevents.ReactionAddedEvent) {
+	var id string
+	found, err := s.loadValue(ctx, storeKey(broadcastMessageKeyPrefix, event.Item.Channel, event.Item.Timestamp), &id)
+	if err != nil {
+		fmt.Printf("failed loading broadcast: %v\n", err)
+		return
+	}
+	if !found {
+		return
+	}
+
+	if _, err := s.acknowledgeBroadcast(ctx, id, event.User); err != nil {
+		fmt.Printf("failed acknowledging broadcast: %v\n", err)
+	}
+}
+
+// appendReceiptsHandle adds the receipts command when it is enabled, it is called with the lock held
+func (s *Slacker) appendReceiptsHandle() {
+	if !s.receiptsCommand {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(receiptsCommand, &CommandDefinition{
+		Description: receiptsDescription,
+		Parameters: []ParameterDefinition{
+			{Name: receiptsParam, Description: receiptsParamDescription, Required: true},
+		},
+		Handler:           s.receiptsHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead},
+	}))
+}
+
+func (s *Slacker) receiptsHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	status, err := s.BroadcastStatus(botCtx.Context(), request.Param(receiptsParam))
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	total := len(status.Acknowledged) + len(status.Pending)
+	text := fmt.Sprintf(receiptsSummaryFormat, truncate(status.Text, receiptsTextLength), len(status.Acknowledged), total)
+	if len(status.Pending) == 0 {
+		text += receiptsAllAcked
+	} else {
+		mentions := make([]string, 0, len(status.Pending))
+		for _, userID := range status.Pending {
+			mentions = append(mentions, fmt.Sprintf(receiptsUserFormat, userID))
+		}
+		text += fmt.Sprintf(receiptsPendingFormat, strings.Join(mentions, choicesSeparator))
+	}
+
+	if err := response.Reply(text); err != nil {
+		response.ReportError(err)
+	}
+}
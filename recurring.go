@@ -0,0 +1,480 @@
+package slacker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	recurringKeyPrefix    = "recurring"
+	recurringRunKeyPrefix = "recurring-run"
+	recurringJobPrefix    = "recurring-"
+	recurringJobDesc      = "Runs `%s` every %s for <@%s>"
+	recurringIDBytes      = 4
+	recurringRunTTL       = time.Hour
+	minimumRecurrence     = time.Minute
+	everyCommand          = "every <schedule?> run <command...>"
+	everyDescription      = "Runs a command on a schedule, such as `every weekday 9am run standup-report`"
+	listRecurringCommand  = "recurring"
+	listRecurringDesc     = "Lists the commands running on a schedule"
+	pauseCommand          = "recurring pause <id>"
+	pauseDescription      = "Pauses a command running on a schedule"
+	resumeCommand         = "recurring resume <id>"
+	resumeDescription     = "Resumes a paused command running on a schedule"
+	deleteCommand         = "recurring delete <id>"
+	deleteDescription     = "Stops a command running on a schedule for good"
+	recurringScheduleParm = "schedule"
+	recurringCommandParam = "command"
+	recurringIDParam      = "id"
+	recurringCreatedText  = "`%s` will run every %s, next %s. Manage it with `recurring pause %s` or `recurring delete %s`"
+	recurringEntryFormat  = "`%s` `%s` every %s by <@%s>%s"
+	recurringPausedMarker = " _(paused)_"
+	recurringPausedText   = "`%s` is paused"
+	recurringResumedText  = "`%s` is running every %s again"
+	recurringDeletedText  = "`%s` will not run anymore"
+	noRecurringMessage    = "No commands are running on a schedule"
+	recurringDaily        = "day"
+	recurringWeekdays     = "weekday"
+	recurringWeekend      = "weekend"
+	recurringDaySeparator = ","
+)
+
+var (
+	errRecurringUnknownCommand = errors.New("There is no such command to run on a schedule")
+	errRecurringSchedule       = errors.New("That schedule is not valid, try `weekday 9am`, `monday,friday 17:30`, `day 8am` or `2h`")
+	errRecurringTooOften       = errors.New("Commands can run once a minute at most")
+	errRecurringNotFound       = errors.New("There is no command running on a schedule with that ID")
+
+	recurringClockFormats = []string{"3pm", "3:04pm", "15:04"}
+)
+
+// recurringCommand is a command that a user set to run on a schedule
+type recurringCommand struct {
+	ID              string            `json:"id"`
+	Schedule        string            `json:"schedule"`
+	Usage           string            `json:"usage"`
+	Parameters      map[string]string `json:"parameters"`
+	TeamID          string            `json:"team"`
+	User            string            `json:"user"`
+	Channel         string            `json:"channel"`
+	ThreadTimeStamp string            `json:"thread_ts"`
+	Paused          bool              `json:"paused"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// key returns the key of the command, under those of its user
+func (r *recurringCommand) key() string {
+	return storeKey(recurringKeyPrefix, r.TeamID, r.User, r.ID)
+}
+
+// jobName returns the name of the scheduler job running the command
+func (r *recurringCommand) jobName() string {
+	return recurringJobPrefix + r.ID
+}
+
+// daysSchedule runs at the hour and minute on some days of the week, in local time
+type daysSchedule struct {
+	days   map[time.Weekday]bool
+	hour   int
+	minute int
+}
+
+// Next returns the first time after the given time that matches the clock on one of the days
+func (d *daysSchedule) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, after.Location())
+	for i := 0; i <= 7; i++ {
+		if d.days[next.Weekday()] && next.After(after) {
+			return next
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// stoppableSchedule ends once stopped, so that the job of a deleted command exits
+type stoppableSchedule struct {
+	Schedule
+	stopped int32
+}
+
+// Next returns the zero time once the schedule is stopped
+func (s *stoppableSchedule) Next(after time.Time) time.Time {
+	if atomic.LoadInt32(&s.stopped) == 1 {
+		return time.Time{}
+	}
+	return s.Schedule.Next(after)
+}
+
+// parseRecurrence parses a schedule such as `weekday 9am`, `monday,friday 17:30`, `9am` or `2h`
+func parseRecurrence(text string) (Schedule, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if interval, err := time.ParseDuration(text); err == nil {
+		if interval < minimumRecurrence {
+			return nil, errRecurringTooOften
+		}
+		return Every(interval), nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 1 {
+		fields = []string{recurringDaily, fields[0]}
+	}
+	if len(fields) != 2 {
+		return nil, errRecurringSchedule
+	}
+
+	days, err := parseRecurrenceDays(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, format := range recurringClockFormats {
+		if clock, err := time.Parse(format, fields[1]); err == nil {
+			return &daysSchedule{days: days, hour: clock.Hour(), minute: clock.Minute()}, nil
+		}
+	}
+	return nil, errRecurringSchedule
+}
+
+// parseRecurrenceDays parses days such as `day`, `weekday`, `weekend` or `monday,wednesday`
+func parseRecurrenceDays(text string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	for _, name := range strings.Split(text, recurringDaySeparator) {
+		name = strings.TrimSuffix(name, "s")
+		switch name {
+		case recurringDaily, "daily", "everyday":
+			for day := time.Sunday; day <= time.Saturday; day++ {
+				days[day] = true
+			}
+			continue
+		case recurringWeekdays:
+			for day := time.Monday; day <= time.Friday; day++ {
+				days[day] = true
+			}
+			continue
+		case recurringWeekend:
+			days[time.Saturday], days[time.Sunday] = true, true
+			continue
+		}
+
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			dayName := strings.ToLower(day.String())
+			if name == dayName || (len(name) >= 3 && strings.HasPrefix(dayName, name)) {
+				days[day], found = true, true
+			}
+		}
+		if !found {
+			return nil, errRecurringSchedule
+		}
+	}
+	return days, nil
+}
+
+// appendRecurringHandles adds the commands running other commands on a schedule when they are
+// enabled, it is called with the lock held. Every comes first, since the command it schedules
+// would match the message otherwise.
+func (s *Slacker) appendRecurringHandles() {
+	if !s.recurringCommands {
+		return
+	}
+
+	every := NewBotCommand(everyCommand, &CommandDefinition{
+		Description:       everyDescription,
+		Example:           "every weekday 9am run standup-report",
+		Handler:           s.everyHandler,
+		AuthorizationFunc: s.setupAuthorization,
+	})
+	s.botCommands = append([]BotCommand{&builtinCommand{BotCommand: &leadingCommand{BotCommand: every}}}, s.botCommands...)
+
+	for _, cmd := range []BotCommand{
+		NewBotCommand(pauseCommand, &CommandDefinition{Description: pauseDescription, Handler: s.pauseRecurringHandler, AuthorizationFunc: s.setupAuthorization}),
+		NewBotCommand(resumeCommand, &CommandDefinition{Description: resumeDescription, Handler: s.resumeRecurringHandler, AuthorizationFunc: s.setupAuthorization}),
+		NewBotCommand(deleteCommand, &CommandDefinition{Description: deleteDescription, Handler: s.deleteRecurringHandler, AuthorizationFunc: s.setupAuthorization}),
+		NewBotCommand(listRecurringCommand, &CommandDefinition{Description: listRecurringDesc, Handler: s.recurringHandler, AuthorizationFunc: s.setupAuthorization}),
+	} {
+		s.addBuiltinCommand(cmd)
+	}
+}
+
+// restoreRecurringCommands adds the jobs of the commands set to run on a schedule before a restart
+func (s *Slacker) restoreRecurringCommands(ctx context.Context) error {
+	if !s.recurringCommands {
+		return nil
+	}
+
+	recurring, err := s.loadRecurringCommands(ctx, recurringKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for _, command := range recurring {
+		if err := s.scheduleRecurringCommand(command); err != nil && !errors.Is(err, ErrJobExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Slacker) everyHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	scheduleText := strings.Join(strings.Fields(request.Param(recurringScheduleParm)), space)
+	schedule, err := parseRecurrence(scheduleText)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	cmd, parameters := s.matcher.indexFor(s.commands()).match(request.Param(recurringCommandParam))
+	if cmd == nil || isDelayCommand(cmd) || isRecurringCommand(cmd) {
+		response.ReportError(errRecurringUnknownCommand)
+		return
+	}
+
+	definition := cmd.Definition()
+	if definition.AuthorizationFunc != nil && !definition.AuthorizationFunc(botCtx, s.newRequest(botCtx, parameters)) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	id := make([]byte, recurringIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	ev := botCtx.Event()
+	recurring := &recurringCommand{
+		ID:              hex.EncodeToString(id),
+		Schedule:        scheduleText,
+		Usage:           cmd.Usage(),
+		Parameters:      parameterValues(cmd, parameters),
+		TeamID:          ev.TeamID,
+		User:            ev.User,
+		Channel:         ev.Channel,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		CreatedAt:       s.clock.Now(),
+	}
+	if err := s.saveValue(botCtx.Context(), recurring.key(), recurring, 0); err != nil {
+		response.ReportError(err)
+		return
+	}
+	if err := s.scheduleRecurringCommand(recurring); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	invocation := s.redactedInvocation(recurring.Usage, recurring.Parameters)
+	next := formatDelayedTime(schedule.Next(s.clock.Now()))
+	text := fmt.Sprintf(recurringCreatedText, invocation, recurring.Schedule, next, recurring.ID, recurring.ID)
+	if err := response.Reply(text, WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func (s *Slacker) recurringHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	recurring, err := s.loadRecurringCommands(botCtx.Context(), storeKey(recurringKeyPrefix, ev.TeamID))
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	lines := []string{}
+	for _, command := range recurring {
+		paused := empty
+		if command.Paused {
+			paused = recurringPausedMarker
+		}
+		lines = append(lines, fmt.Sprintf(recurringEntryFormat, command.ID, s.redactedInvocation(command.Usage, command.Parameters), command.Schedule, command.User, paused))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, noRecurringMessage)
+	}
+
+	if err := response.Reply(strings.Join(lines, newLine), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func (s *Slacker) pauseRecurringHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	s.updateRecurringCommand(botCtx, request, response, func(command *recurringCommand) string {
+		command.Paused = true
+		return fmt.Sprintf(recurringPausedText, s.redactedInvocation(command.Usage, command.Parameters))
+	})
+}
+
+func (s *Slacker) resumeRecurringHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	s.updateRecurringCommand(botCtx, request, response, func(command *recurringCommand) string {
+		command.Paused = false
+		return fmt.Sprintf(recurringResumedText, s.redactedInvocation(command.Usage, command.Parameters), command.Schedule)
+	})
+}
+
+// updateRecurringCommand saves the change made to the command with the requested ID
+func (s *Slacker) updateRecurringCommand(botCtx BotContext, request Request, response ResponseWriter, change func(command *recurringCommand) string) {
+	command, err := s.findRecurringCommand(botCtx, request.Param(recurringIDParam))
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	text := change(command)
+	if err := s.saveValue(botCtx.Context(), command.key(), command, 0); err != nil {
+		response.ReportError(err)
+		return
+	}
+	if err := response.Reply(text, WithThreadReply(botCtx.Event().IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+func (s *Slacker) deleteRecurringHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	command, err := s.findRecurringCommand(botCtx, request.Param(recurringIDParam))
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if err := s.store.Delete(botCtx.Context(), command.key()); err != nil {
+		response.ReportError(err)
+		return
+	}
+	s.stopRecurringCommand(command)
+
+	text := fmt.Sprintf(recurringDeletedText, s.redactedInvocation(command.Usage, command.Parameters))
+	if err := response.Reply(text, WithThreadReply(botCtx.Event().IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// scheduleRecurringCommand adds the job running the command on its schedule
+func (s *Slacker) scheduleRecurringCommand(command *recurringCommand) error {
+	schedule, err := parseRecurrence(command.Schedule)
+	if err != nil {
+		return err
+	}
+
+	stoppable := &stoppableSchedule{Schedule: schedule}
+	s.mutex.Lock()
+	if s.recurringSchedules == nil {
+		s.recurringSchedules = make(map[string]*stoppableSchedule)
+	}
+	s.recurringSchedules[command.ID] = stoppable
+	s.mutex.Unlock()
+
+	key := command.key()
+	return s.scheduler.add(command.jobName(), &JobDefinition{
+		Description: fmt.Sprintf(recurringJobDesc, s.redactedInvocation(command.Usage, command.Parameters), command.Schedule, command.User),
+		Schedule:    stoppable,
+		Handler: func(jobCtx JobContext) error {
+			return s.runRecurringCommand(jobCtx, key)
+		},
+	})
+}
+
+// stopRecurringCommand removes the job running the command, which exits before its next run
+func (s *Slacker) stopRecurringCommand(command *recurringCommand) {
+	s.mutex.Lock()
+	stoppable := s.recurringSchedules[command.ID]
+	delete(s.recurringSchedules, command.ID)
+	s.mutex.Unlock()
+
+	if stoppable != nil {
+		atomic.StoreInt32(&stoppable.stopped, 1)
+	}
+	s.scheduler.remove(command.jobName())
+}
+
+// runRecurringCommand runs the command as the user who set it, so that their authorization is
+// checked each time. Bots sharing a store run each occurrence once.
+func (s *Slacker) runRecurringCommand(jobCtx JobContext, key string) error {
+	ctx := jobCtx.Context()
+	command := &recurringCommand{}
+	found, err := s.loadValue(ctx, key, command)
+	if err != nil || !found || command.Paused {
+		return err
+	}
+
+	occurrence := storeKey(recurringRunKeyPrefix, command.ID, fmt.Sprint(s.clock.Now().Truncate(minimumRecurrence).Unix()))
+	claimed, err := s.compareAndSwap(ctx, occurrence, nil, []byte(command.ID), recurringRunTTL)
+	if err != nil || !claimed {
+		return err
+	}
+
+	cmd := s.findCommand(command.Usage)
+	if cmd == nil {
+		return fmt.Errorf("%s is not registered", command.Usage)
+	}
+
+	ev := &MessageEvent{
+		Channel:         command.Channel,
+		User:            command.User,
+		Type:            recurringKeyPrefix,
+		ThreadTimeStamp: command.ThreadTimeStamp,
+		TeamID:          command.TeamID,
+	}
+	botCtx := s.newBotContext(ctx, ev)
+	parameters := proper.NewProperties(command.Parameters)
+	s.dispatch(func() {
+		s.runCommand(botCtx, s.newResponse(botCtx), cmd, parameters)
+	})
+	return nil
+}
+
+// findRecurringCommand returns the command of the workspace with the ID
+func (s *Slacker) findRecurringCommand(botCtx BotContext, id string) (*recurringCommand, error) {
+	recurring, err := s.loadRecurringCommands(botCtx.Context(), storeKey(recurringKeyPrefix, botCtx.Event().TeamID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, command := range recurring {
+		if command.ID == id {
+			return command, nil
+		}
+	}
+	return nil, errRecurringNotFound
+}
+
+// loadRecurringCommands returns the commands set under the prefix, the oldest first
+func (s *Slacker) loadRecurringCommands(ctx context.Context, prefix string) ([]*recurringCommand, error) {
+	keys, err := s.store.Keys(ctx, prefix+storeKeySeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	recurring := []*recurringCommand{}
+	for _, key := range keys {
+		command := &recurringCommand{}
+		found, err := s.loadValue(ctx, key, command)
+		if err != nil {
+			return nil, err
+		}
+		if found && len(command.ID) > 0 {
+			recurring = append(recurring, command)
+		}
+	}
+
+	sort.Slice(recurring, func(i, j int) bool {
+		return recurring[i].CreatedAt.Before(recurring[j].CreatedAt)
+	})
+	return recurring, nil
+}
+
+// isRecurringCommand reports whether the command is one of those managing recurring commands,
+// which cannot run on a schedule in turn
+func isRecurringCommand(cmd BotCommand) bool {
+	switch cmd.Usage() {
+	case everyCommand, listRecurringCommand, pauseCommand, resumeCommand, deleteCommand:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"text/template"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	reportCommand     = "report run <name>"
+	reportDescription = "Runs a report right away"
+	reportExample     = "report run weekly-signups"
+	reportJobPrefix   = "report"
+)
+
+var (
+	// ErrReportNotFound is returned when running a report that was not registered
+	ErrReportNotFound = errors.New("report not found")
+	// ErrInvalidReport is returned when registering a report without a data provider
+	ErrInvalidReport = errors.New("a report needs a data provider")
+)
+
+// ReportDefinition structure contains the definition of a report. The data returned by Data is
+// rendered with Template, a text/template, and posted to Channel on the Schedule. Reports without
+// a schedule only run with the `report run` command, which replies in the channel it was used in.
+type ReportDefinition struct {
+	Description       string
+	Schedule          Schedule
+	Channel           string
+	Template          string
+	Data              func(ctx context.Context) (interface{}, error)
+	AuthorizationFunc func(botCtx BotContext, request Request) bool
+}
+
+// ReportData is passed to the template of a report
+type ReportData struct {
+	Name string
+	Time time.Time
+	Data interface{}
+}
+
+type report struct {
+	name       string
+	definition *ReportDefinition
+	template   *template.Template
+}
+
+// Report registers a report, scheduling it when it has a schedule
+func (s *Slacker) Report(name string, definition *ReportDefinition) error {
+	if definition == nil || definition.Data == nil {
+		return ErrInvalidReport
+	}
+
+	tmpl, err := template.New(name).Parse(definition.Template)
+	if err != nil {
+		return err
+	}
+
+	report := &report{name: name, definition: definition, template: tmpl}
+	if definition.Schedule != nil {
+		err := s.Job(storeKey(reportJobPrefix, name), &JobDefinition{
+			Description: definition.Description,
+			Schedule:    definition.Schedule,
+			Handler: func(jobCtx JobContext) error {
+				return report.post(jobCtx.Context(), jobCtx.Client(), definition.Channel)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.register(func() {
+		s.reports[name] = report
+		if s.initialized {
+			s.appendReportHandle()
+		}
+	})
+}
+
+// RunReport posts the report to its channel right away
+func (s *Slacker) RunReport(ctx context.Context, name string) error {
+	report := s.report(name)
+	if report == nil {
+		return ErrReportNotFound
+	}
+	return report.post(ctx, s.client, report.definition.Channel)
+}
+
+func (s *Slacker) report(name string) *report {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.reports[name]
+}
+
+// post renders the report and posts it to the channel
+func (r *report) post(ctx context.Context, client *slack.Client, channelID string) error {
+	text, err := r.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	return withRateLimitRetry(ctx, func() error {
+		_, _, err := client.PostMessageContext(ctx, channelID, slack.MsgOptionText(text, false))
+		return err
+	})
+}
+
+func (r *report) render(ctx context.Context) (string, error) {
+	data, err := r.definition.Data(ctx)
+	if err != nil {
+		return empty, err
+	}
+
+	text := &bytes.Buffer{}
+	if err := r.template.Execute(text, &ReportData{Name: r.name, Time: clockFromContext(ctx).Now(), Data: data}); err != nil {
+		return empty, err
+	}
+	return text.String(), nil
+}
+
+func (s *Slacker) reportHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	report := s.report(request.Param("name"))
+	if report == nil {
+		response.ReportError(ErrReportNotFound)
+		return
+	}
+
+	text, err := report.render(botCtx.Context())
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+	response.Reply(text, WithThreadReply(botCtx.Event().IsThread()))
+}
+
+// reportAuthorization lets users run a report when they pass its own authorization, if any
+func (s *Slacker) reportAuthorization(botCtx BotContext, request Request) bool {
+	report := s.report(request.Param("name"))
+	if report == nil || report.definition.AuthorizationFunc == nil {
+		return true
+	}
+	return report.definition.AuthorizationFunc(botCtx, request)
+}
+
+// appendReportHandle adds the report command once a report is registered, it is called with the lock held
+func (s *Slacker) appendReportHandle() {
+	if len(s.reports) == 0 {
+		return
+	}
+
+	for _, cmd := range s.botCommands {
+		if cmd.Usage() == reportCommand {
+			return
+		}
+	}
+
+	s.addBuiltinCommand(NewBotCommand(reportCommand, &CommandDefinition{
+		Description:       reportDescription,
+		Example:           reportExample,
+		Handler:           s.reportHandler,
+		AuthorizationFunc: s.reportAuthorization,
+	}))
+}
@@ -0,0 +1,75 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+)
+
+const (
+	panicErrorFormat = "panic: %v"
+)
+
+// ErrorReport holds what is known about an error reported by a handler, or a handler panic.
+// Panic is the value recovered from a panic and nil otherwise. Parameters redacts the values of
+// Secret parameters, which the text of Event still holds.
+type ErrorReport struct {
+	Err        error
+	Panic      interface{}
+	Stack      []byte
+	Event      *MessageEvent
+	Command    string
+	Parameters map[string]string
+}
+
+// An ErrorReporter interface sends the errors of handlers to an error tracker, such as Sentry
+// with the contrib/sentry package. Report is called from the handler's goroutine.
+type ErrorReporter interface {
+	Report(ctx context.Context, report *ErrorReport)
+}
+
+type errorReporterKey struct{}
+
+func withErrorReporter(ctx context.Context, reporter ErrorReporter) context.Context {
+	if reporter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, errorReporterKey{}, reporter)
+}
+
+func errorReporterFromContext(ctx context.Context) ErrorReporter {
+	reporter, _ := ctx.Value(errorReporterKey{}).(ErrorReporter)
+	return reporter
+}
+
+// reportHandlerError sends an error reported while a command was running to the error reporter.
+// Errors reported before the handler ran, such as failed authorization, are not sent.
+func reportHandlerError(botCtx BotContext, err error, recovered interface{}) {
+	reporter := errorReporterFromContext(botCtx.Context())
+	exec := executionFromContext(botCtx.Context())
+	if reporter == nil || exec == nil {
+		return
+	}
+
+	// The panic itself was reported, not the message telling the user about it
+	if recovered == nil && errors.Is(err, errHandlerPanic) {
+		return
+	}
+
+	report := &ErrorReport{
+		Err:     err,
+		Panic:   recovered,
+		Stack:   debug.Stack(),
+		Event:   botCtx.Event(),
+		Command: exec.command.Usage(),
+	}
+	if exec.parameters != nil {
+		report.Parameters = parameterValues(exec.command, exec.parameters)
+		for _, definition := range exec.command.Definition().Parameters {
+			if _, ok := report.Parameters[definition.Name]; ok && definition.Secret {
+				report.Parameters[definition.Name] = redactedValue
+			}
+		}
+	}
+	reporter.Report(botCtx.Context(), report)
+}
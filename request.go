@@ -1,6 +1,8 @@
 package slacker
 
 import (
+	"time"
+
 	"github.com/shomali11/proper"
 )
 
@@ -20,7 +22,11 @@ type Request interface {
 	BooleanParam(key string, defaultValue bool) bool
 	IntegerParam(key string, defaultValue int) int
 	FloatParam(key string, defaultValue float64) float64
+	DurationParam(key string, defaultValue time.Duration) time.Duration
+	UserParam(key string, defaultValue string) string
+	ChannelParam(key string, defaultValue string) string
 	Properties() *proper.Properties
+	Origin() MatchOrigin
 }
 
 // request contains the Event received and parameters
@@ -54,7 +60,42 @@ func (r *request) FloatParam(key string, defaultValue float64) float64 {
 	return r.properties.FloatParam(key, defaultValue)
 }
 
+// DurationParam attempts to look up a duration value by key, such as 15m. If not found, return the default duration value
+func (r *request) DurationParam(key string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(r.properties.StringParam(key, empty))
+	if err != nil {
+		return defaultValue
+	}
+	return duration
+}
+
+// UserParam attempts to look up the ID of the user mentioned by key. If not found, return the default user ID
+func (r *request) UserParam(key string, defaultValue string) string {
+	if userID, ok := mentionedID(userParameterRegex, r.properties.StringParam(key, empty)); ok {
+		return userID
+	}
+	return defaultValue
+}
+
+// ChannelParam attempts to look up the ID of the channel mentioned by key. If not found, return the default channel ID
+func (r *request) ChannelParam(key string, defaultValue string) string {
+	if channelID, ok := mentionedID(channelParameterRegex, r.properties.StringParam(key, empty)); ok {
+		return channelID
+	}
+	return defaultValue
+}
+
 // Properties returns the properties of the request
 func (r *request) Properties() *proper.Properties {
 	return r.properties
 }
+
+// Origin returns where the text the command matched came from
+func (r *request) Origin() MatchOrigin {
+	if r.botCtx != nil {
+		if origin := matchOriginFromContext(r.botCtx.Context()); origin != nil {
+			return *origin
+		}
+	}
+	return MatchOrigin{Source: MessageSource}
+}
@@ -3,6 +3,7 @@ package slacker
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/slack-go/slack"
 )
@@ -16,6 +17,16 @@ type ResponseWriter interface {
 	Reply(text string, options ...ReplyOption) error
 	ReportError(err error, options ...ReportErrorOption)
 	FileUpload(title string, comment string, filename string, filetype string, reader io.Reader, options ...ReplyOption) error
+	StartTask(title string, options ...ReplyOption) Task
+	StartTimer(title string, until time.Time, options ...ReplyOption) Timer
+	PostTo(channelID string, message string, options ...ReplyOption) error
+	Pin(timestamp string) error
+	Unpin(timestamp string) error
+	ReplyTable(name string, rows [][]string, options ...ReplyOption) error
+	ReplyChart(spec *ChartSpec, options ...ReplyOption) error
+	OpenModal(view slack.ModalViewRequest) error
+	ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error
+	PostBlocks(channelID string, blocks []slack.Block, options ...ReplyOption) error
 }
 
 // NewResponse creates a new response structure
@@ -30,47 +41,84 @@ type response struct {
 // ReportError sends back a formatted error message to the channel where we received the event from
 func (r *response) ReportError(err error, options ...ReportErrorOption) {
 	defaults := NewReportErrorDefaults(options...)
+	reportHandlerError(r.botCtx, err, nil)
 
 	client := r.botCtx.Client()
 	ev := r.botCtx.Event()
 
+	text, blocks := presentError(r.botCtx.Context(), ev.Channel, err)
 	opts := []slack.MsgOption{
-		slack.MsgOptionText(fmt.Sprintf(errorFormat, err.Error()), false),
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(blocks...),
 	}
 	if defaults.ThreadResponse {
 		opts = append(opts, slack.MsgOptionTS(ev.MakeThreadTimestamp()))
 	}
-	_, _, err = client.PostMessageContext(r.botCtx.Context(), ev.Channel, opts...)
+	_, timestamp, err := client.PostMessageContext(r.botCtx.Context(), ev.Channel, opts...)
 	if err != nil {
 		fmt.Printf("failed posting message: %v\n", err)
+		return
 	}
+
+	archived := &ReplyDefaults{Blocks: blocks}
+	r.archive(r.archiveChannel(archived, true), ev.Channel, timestamp, text, archived)
 }
 
 // Reply send a attachments to the current channel with a message
 func (r *response) Reply(message string, options ...ReplyOption) error {
 	defaults := NewReplyDefaults(options...)
 
-	client := r.botCtx.Client()
 	ev := r.botCtx.Event()
 	if ev == nil {
 		return fmt.Errorf("Unable to get message event details")
 	}
 
+	threadTS := empty
+	if defaults.ThreadResponse {
+		threadTS = ev.MakeThreadTimestamp()
+	}
+	return r.post(ev.Channel, threadTS, message, defaults)
+}
+
+// PostTo send a message to another channel, the thread reply option is ignored
+func (r *response) PostTo(channelID string, message string, options ...ReplyOption) error {
+	return r.post(channelID, empty, message, NewReplyDefaults(options...))
+}
+
+// post sends a message to the channel, retrying when rate limited. During quiet hours, it is held instead.
+func (r *response) post(channelID string, threadTS string, message string, defaults *ReplyDefaults) error {
+	if r.muted(channelID, defaults) {
+		return nil
+	}
+
+	if held, err := r.hold(channelID, threadTS, message, defaults); held || err != nil {
+		return err
+	}
+
 	opts := []slack.MsgOption{
 		slack.MsgOptionText(message, false),
 		slack.MsgOptionAttachments(defaults.Attachments...),
 		slack.MsgOptionBlocks(defaults.Blocks...),
 	}
-	if defaults.ThreadResponse {
-		opts = append(opts, slack.MsgOptionTS(ev.MakeThreadTimestamp()))
+	if len(threadTS) > 0 {
+		opts = append(opts, slack.MsgOptionTS(threadTS))
+	}
+	if metadata, ok := commandMetadata(r.botCtx); ok {
+		opts = append(opts, slack.MsgOptionMetadata(metadata))
+	}
+
+	var timestamp string
+	err := withRateLimitRetry(r.botCtx.Context(), func() error {
+		var err error
+		_, timestamp, err = r.botCtx.Client().PostMessageContext(r.botCtx.Context(), channelID, opts...)
+		return err
+	})
+	if err != nil {
+		return scopeError(r.botCtx.Context(), err, featureMessages, scopeChatWrite)
 	}
 
-	_, _, err := client.PostMessageContext(
-		r.botCtx.Context(),
-		ev.Channel,
-		opts...,
-	)
-	return err
+	r.archive(r.archiveChannel(defaults, false), channelID, timestamp, message, defaults)
+	return r.retain(channelID, timestamp, defaults)
 }
 
 // FileUpload send a file to the current channel
@@ -83,6 +131,10 @@ func (r *response) FileUpload(title string, comment string, filename string, fil
 		return fmt.Errorf("Unable to get message event details")
 	}
 
+	if r.muted(ev.Channel, defaults) {
+		return nil
+	}
+
 	params := slack.FileUploadParameters{
 		Title:          title,
 		InitialComment: comment,
@@ -97,5 +149,70 @@ func (r *response) FileUpload(title string, comment string, filename string, fil
 	}
 
 	_, err := client.UploadFileContext(r.botCtx.Context(), params)
-	return err
+	return scopeError(r.botCtx.Context(), err, featureFiles, scopeFilesWrite)
+}
+
+// StartTask posts a message tracking the progress of a long-running task to the current channel
+func (r *response) StartTask(title string, options ...ReplyOption) Task {
+	return newTask(r.botCtx, title, options...)
+}
+
+// StartTimer posts a message counting down to the time, or counting the time elapsed when it is zero,
+// updated every interval set WithTimerInterval until it is over or stopped
+func (r *response) StartTimer(title string, until time.Time, options ...ReplyOption) Timer {
+	return newTimer(r.botCtx, title, until, options...)
+}
+
+// Pin pins the message with the timestamp to the current channel
+func (r *response) Pin(timestamp string) error {
+	ev := r.botCtx.Event()
+	if ev == nil {
+		return fmt.Errorf("Unable to get message event details")
+	}
+	err := r.botCtx.Client().AddPinContext(r.botCtx.Context(), ev.Channel, slack.NewRefToMessage(ev.Channel, timestamp))
+	return scopeError(r.botCtx.Context(), err, featurePins, scopePinsWrite)
+}
+
+// Unpin removes the message with the timestamp from the current channel's pins
+func (r *response) Unpin(timestamp string) error {
+	ev := r.botCtx.Event()
+	if ev == nil {
+		return fmt.Errorf("Unable to get message event details")
+	}
+	err := r.botCtx.Client().RemovePinContext(r.botCtx.Context(), ev.Channel, slack.NewRefToMessage(ev.Channel, timestamp))
+	return scopeError(r.botCtx.Context(), err, featurePins, scopePinsWrite)
+}
+
+// muted reports whether the message is not sent because the bot was muted in the channel
+func (r *response) muted(channelID string, defaults *ReplyDefaults) bool {
+	ev := r.botCtx.Event()
+	return !defaults.Critical && ev != nil && channelMuted(r.botCtx.Context(), ev.TeamID, channelID)
+}
+
+// retain tracks the message posted with a retention, so that it is deleted or redacted once it expires
+func (r *response) retain(channelID string, timestamp string, defaults *ReplyDefaults) error {
+	retention := retentionFromContext(r.botCtx.Context())
+	if defaults.Retention <= 0 || retention == nil {
+		return nil
+	}
+	return retention.track(r.botCtx.Context(), channelID, timestamp, defaults.Retention, defaults.Redaction)
+}
+
+// hold queues the message until the quiet hours of the channel end, reporting whether it did
+func (r *response) hold(channelID string, threadTS string, message string, defaults *ReplyDefaults) (bool, error) {
+	policy := quietPolicyFromContext(r.botCtx.Context())
+	ev := r.botCtx.Event()
+	if defaults.Critical || policy == nil || ev == nil {
+		return false, nil
+	}
+
+	return policy.hold(r.botCtx.Context(), ev, &queuedMessage{
+		Channel:     channelID,
+		ThreadTS:    threadTS,
+		Text:        message,
+		Attachments: defaults.Attachments,
+		Blocks:      slack.Blocks{BlockSet: defaults.Blocks},
+		Retention:   defaults.Retention,
+		Redaction:   defaults.Redaction,
+	})
 }
@@ -0,0 +1,112 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	retentionKeyPrefix   = "retention"
+	retentionJob         = "message-retention"
+	retentionDesc        = "Deletes or redacts the bot's messages whose retention expired"
+	retentionPeriod      = time.Minute
+	retentionRecordGrace = 7 * 24 * time.Hour
+	retentionKeyFormat   = "%020d"
+	errorMessageNotFound = "message_not_found"
+)
+
+// retainedMessage is a message of the bot to delete, or redact, once its retention expires
+type retainedMessage struct {
+	Channel   string    `json:"channel"`
+	Timestamp string    `json:"timestamp"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Redaction string    `json:"redaction"`
+}
+
+// retention keeps track of the messages posted with a retention, and deletes them with a job once it expires
+type retention struct {
+	store     Store
+	codec     Codec
+	scheduler *scheduler
+	clock     Clock
+}
+
+type retentionKey struct{}
+
+func newRetention(store Store, codec Codec, scheduler *scheduler, clock Clock) *retention {
+	return &retention{store: store, codec: codec, scheduler: scheduler, clock: clock}
+}
+
+func withRetention(ctx context.Context, retention *retention) context.Context {
+	return context.WithValue(ctx, retentionKey{}, retention)
+}
+
+func retentionFromContext(ctx context.Context) *retention {
+	retention, _ := ctx.Value(retentionKey{}).(*retention)
+	return retention
+}
+
+// schedule adds the job deleting expired messages, so that messages tracked before a restart expire too
+func (r *retention) schedule() error {
+	return r.scheduler.add(retentionJob, &JobDefinition{
+		Description: retentionDesc,
+		Schedule:    Every(retentionPeriod),
+		Handler:     r.expire,
+	})
+}
+
+// track records the message posted with a retention
+func (r *retention) track(ctx context.Context, channelID string, timestamp string, ttl time.Duration, redaction string) error {
+	// Keys start with the expiry, so that the job goes through the messages in the order they expire
+	message := &retainedMessage{Channel: channelID, Timestamp: timestamp, ExpiresAt: r.clock.Now().Add(ttl), Redaction: redaction}
+	key := storeKey(retentionKeyPrefix, fmt.Sprintf(retentionKeyFormat, message.ExpiresAt.UnixNano()), channelID, timestamp)
+	return saveStoreValue(ctx, r.store, r.codec, key, message, ttl+retentionRecordGrace)
+}
+
+// expire deletes or redacts the messages whose retention expired. A message failing to expire does
+// not hold up the others, and is given up on when Slack refuses it, as the bot cannot delete it.
+func (r *retention) expire(jobCtx JobContext) error {
+	ctx := jobCtx.Context()
+	keys, err := r.store.Keys(ctx, retentionKeyPrefix+storeKeySeparator)
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		message := &retainedMessage{}
+		found, err := loadStoreValue(ctx, r.store, r.codec, key, message)
+		if err != nil {
+			fmt.Printf("failed loading retained message: %v\n", err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if r.clock.Now().Before(message.ExpiresAt) {
+			return nil
+		}
+
+		if len(message.Redaction) > 0 {
+			_, _, _, err = jobCtx.Client().UpdateMessageContext(ctx, message.Channel, message.Timestamp,
+				slack.MsgOptionText(message.Redaction, false), slack.MsgOptionBlocks([]slack.Block{}...))
+		} else {
+			_, _, err = jobCtx.Client().DeleteMessageContext(ctx, message.Channel, message.Timestamp)
+		}
+		// Messages deleted by someone else are done with as well
+		if err != nil && slackErrorCode(err) != errorMessageNotFound {
+			fmt.Printf("failed expiring message %s in %s: %v\n", message.Timestamp, message.Channel, err)
+			if !isPermanentError(err) {
+				continue
+			}
+		}
+		if err := r.store.Delete(ctx, key); err != nil {
+			fmt.Printf("failed deleting retained message: %v\n", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	maxRateLimitRetries = 3
+)
+
+// withRateLimitRetry calls the function again after the delay requested by Slack when rate limited
+func withRateLimitRetry(ctx context.Context, call func() error) error {
+	err := call()
+	for attempt := 0; attempt < maxRateLimitRetries; attempt++ {
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) {
+			return err
+		}
+		adminNotifierFromContext(ctx).notify(ctx, adminRateLimitKind, fmt.Sprintf(adminRateLimitFormat, rateLimited.RetryAfter))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clockFromContext(ctx).After(rateLimited.RetryAfter):
+		}
+		err = call()
+	}
+	return err
+}
@@ -0,0 +1,290 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+var (
+	// ErrJobExists is returned when registering a job under a name that is already used
+	ErrJobExists = errors.New("job already exists")
+	// ErrInvalidSchedule is returned when registering a job without a schedule or handler
+	ErrInvalidSchedule = errors.New("a job needs a schedule and a handler")
+)
+
+// A Schedule interface determines when a job runs next.
+// Next returns the zero time when the job should not run again.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Every returns a schedule that runs at a fixed interval
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+// Daily returns a schedule that runs every day at the hour and minute, in local time
+func Daily(hour int, minute int) Schedule {
+	return &clockSchedule{hour: hour, minute: minute, days: 1}
+}
+
+// Weekly returns a schedule that runs every week on the day at the hour and minute, in local time
+func Weekly(day time.Weekday, hour int, minute int) Schedule {
+	return &clockSchedule{weekday: &day, hour: hour, minute: minute, days: 7}
+}
+
+type intervalSchedule time.Duration
+
+// Next returns the time one interval after the given time
+func (i intervalSchedule) Next(after time.Time) time.Time {
+	if i <= 0 {
+		return time.Time{}
+	}
+	return after.Add(time.Duration(i))
+}
+
+type clockSchedule struct {
+	weekday *time.Weekday
+	hour    int
+	minute  int
+	days    int
+}
+
+// Next returns the first time after the given time that matches the clock and day
+func (c *clockSchedule) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), c.hour, c.minute, 0, 0, after.Location())
+	if c.weekday != nil {
+		next = next.AddDate(0, 0, (int(*c.weekday)-int(next.Weekday())+7)%7)
+	}
+	if !next.After(after) {
+		next = next.AddDate(0, 0, c.days)
+	}
+	return next
+}
+
+// A JobContext interface is used by a job to act on the workspace
+type JobContext interface {
+	Context() context.Context
+	Client() *slack.Client
+	Channels() ChannelManager
+}
+
+// NewJobContext creates a new job context
+func NewJobContext(ctx context.Context, client *slack.Client) JobContext {
+	return &jobContext{ctx: ctx, client: client}
+}
+
+type jobContext struct {
+	ctx    context.Context
+	client *slack.Client
+}
+
+// Context returns the context
+func (r *jobContext) Context() context.Context {
+	return r.ctx
+}
+
+// Client returns the slack client
+func (r *jobContext) Client() *slack.Client {
+	return r.client
+}
+
+// Channels returns a channel manager using the slack client
+func (r *jobContext) Channels() ChannelManager {
+	return NewChannelManager(r.ctx, r.client)
+}
+
+// JobDefinition structure contains the definition of a scheduled job
+type JobDefinition struct {
+	Description string
+	Schedule    Schedule
+	Handler     func(jobCtx JobContext) error
+}
+
+// JobInfo describes a scheduled job
+type JobInfo struct {
+	Name        string
+	Description string
+	NextRun     time.Time
+	LastRun     time.Time
+	LastError   error
+}
+
+// scheduler runs the registered jobs while the bot is listening
+type scheduler struct {
+	mutex    sync.Mutex
+	client   *slack.Client
+	clock    Clock
+	notifier *adminNotifier
+	ctx      context.Context
+	jobs     map[string]*job
+}
+
+func newScheduler(client *slack.Client, clock Clock, notifier *adminNotifier) *scheduler {
+	return &scheduler{client: client, clock: clock, notifier: notifier, jobs: make(map[string]*job)}
+}
+
+// add registers the job, starting it right away when the scheduler is running
+func (s *scheduler) add(name string, definition *JobDefinition) error {
+	if definition == nil || definition.Schedule == nil || definition.Handler == nil {
+		return ErrInvalidSchedule
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return ErrJobExists
+	}
+
+	job := &job{name: name, definition: definition}
+	s.jobs[name] = job
+	if s.ctx != nil {
+		s.run(s.ctx, job)
+	}
+	return nil
+}
+
+// remove forgets the job and cancels its context, stopping it right away
+func (s *scheduler) remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if job, ok := s.jobs[name]; ok && job.cancel != nil {
+		job.cancel()
+	}
+	delete(s.jobs, name)
+}
+
+// start runs every job until the context is cancelled
+func (s *scheduler) start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ctx = ctx
+	for _, job := range s.jobs {
+		s.run(ctx, job)
+	}
+}
+
+// run starts the job with a context of its own, canceled when the job is removed
+func (s *scheduler) run(ctx context.Context, job *job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	job.cancel = cancel
+	go job.run(jobCtx, s.client, s.clock, s.notifier)
+}
+
+// stop forgets the context of the last run, the jobs exit once it is cancelled
+func (s *scheduler) stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ctx = nil
+}
+
+func (s *scheduler) list() []*JobInfo {
+	s.mutex.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mutex.Unlock()
+
+	infos := []*JobInfo{}
+	for _, job := range jobs {
+		infos = append(infos, job.info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+type job struct {
+	mutex      sync.Mutex
+	name       string
+	definition *JobDefinition
+	cancel     context.CancelFunc
+	nextRun    time.Time
+	lastRun    time.Time
+	lastError  error
+}
+
+func (j *job) run(ctx context.Context, client *slack.Client, clock Clock, notifier *adminNotifier) {
+	for {
+		next := j.definition.Schedule.Next(clock.Now())
+		j.mutex.Lock()
+		j.nextRun = next
+		j.mutex.Unlock()
+
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(next.Sub(clock.Now())):
+		}
+
+		err := j.execute(ctx, client, notifier)
+		if err != nil {
+			fmt.Printf("failed running job %s: %v\n", j.name, err)
+		}
+
+		j.mutex.Lock()
+		j.lastRun = next
+		j.lastError = err
+		j.mutex.Unlock()
+	}
+}
+
+// execute runs the handler once, recovering from its panics so they fail the run rather than the bot
+func (j *job) execute(ctx context.Context, client *slack.Client, notifier *adminNotifier) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Printf("failed running job %s: panic: %v\n%s", j.name, recovered, debug.Stack())
+			notifier.notify(ctx, adminPanicKind, fmt.Sprintf(adminJobPanicFormat, j.name, recovered, debug.Stack()))
+			err = fmt.Errorf(panicErrorFormat, recovered)
+		}
+	}()
+
+	return j.definition.Handler(NewJobContext(ctx, client))
+}
+
+func (j *job) info() *JobInfo {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return &JobInfo{
+		Name:        j.name,
+		Description: j.definition.Description,
+		NextRun:     j.nextRun,
+		LastRun:     j.lastRun,
+		LastError:   j.lastError,
+	}
+}
+
+// Job registers a job that runs on its schedule while the bot is listening
+func (s *Slacker) Job(name string, definition *JobDefinition) error {
+	var err error
+	if registerErr := s.register(func() {
+		err = s.scheduler.add(name, definition)
+	}); registerErr != nil {
+		return registerErr
+	}
+	return err
+}
+
+// Jobs returns the registered jobs, sorted by name
+func (s *Slacker) Jobs() []*JobInfo {
+	return s.scheduler.list()
+}
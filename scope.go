@@ -0,0 +1,110 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	errorMissingScope  = "missing_scope"
+	scopeErrorFormat   = "missing OAuth scope `%s`, needed for %s"
+	scopeAlertFormat   = ":warning: I am missing the `%s` OAuth scope, which %s needs. Please add it to the app and reinstall it."
+	featureMessages    = "posting messages"
+	featureFiles       = "uploading files"
+	featurePins        = "pinning messages"
+	featureBookmarks   = "adding bookmarks"
+	featureChannels    = "managing channels"
+	featureInvites     = "inviting users to channels"
+	featureUsers       = "looking up users"
+	featureReactions   = "reaction commands"
+	scopeChatWrite     = "chat:write"
+	scopeFilesWrite    = "files:write"
+	scopePinsWrite     = "pins:write"
+	scopeBookmarks     = "bookmarks:write"
+	scopeChannels      = "channels:manage"
+	scopeInvites       = "channels:write.invites"
+	scopeUsersRead     = "users:read"
+	scopeHistory       = "channels:history"
+	scopeAlertedFormat = "%s:%s"
+)
+
+// ScopeError is returned when a Slack API call failed because the app lacks an OAuth scope
+type ScopeError struct {
+	Scope   string
+	Feature string
+	Err     error
+}
+
+// Error names the missing scope and the feature needing it
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf(scopeErrorFormat, e.Scope, e.Feature)
+}
+
+// Unwrap returns the error returned by the Slack API
+func (e *ScopeError) Unwrap() error {
+	return e.Err
+}
+
+type scopeAlerterKey struct{}
+
+// scopeAlerter sends a direct message to an admin the first time each scope is found missing
+type scopeAlerter struct {
+	mutex   sync.Mutex
+	client  *slack.Client
+	userID  string
+	alerted map[string]bool
+}
+
+func newScopeAlerter(client *slack.Client, userID string) *scopeAlerter {
+	if len(userID) == 0 {
+		return nil
+	}
+	return &scopeAlerter{client: client, userID: userID, alerted: make(map[string]bool)}
+}
+
+func withScopeAlerter(ctx context.Context, alerter *scopeAlerter) context.Context {
+	if alerter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeAlerterKey{}, alerter)
+}
+
+func scopeAlerterFromContext(ctx context.Context) *scopeAlerter {
+	alerter, _ := ctx.Value(scopeAlerterKey{}).(*scopeAlerter)
+	return alerter
+}
+
+func (a *scopeAlerter) alert(ctx context.Context, scopeErr *ScopeError) {
+	key := fmt.Sprintf(scopeAlertedFormat, scopeErr.Scope, scopeErr.Feature)
+
+	a.mutex.Lock()
+	alerted := a.alerted[key]
+	a.alerted[key] = true
+	a.mutex.Unlock()
+
+	if alerted {
+		return
+	}
+
+	_, _, err := a.client.PostMessageContext(ctx, a.userID, slack.MsgOptionText(fmt.Sprintf(scopeAlertFormat, scopeErr.Scope, scopeErr.Feature), false))
+	if err != nil {
+		fmt.Printf("failed sending scope alert: %v\n", err)
+	}
+}
+
+// scopeError turns a missing_scope error into a ScopeError naming the scope the feature needs,
+// alerting the admin if one is configured
+func scopeError(ctx context.Context, err error, feature string, scope string) error {
+	if slackErrorCode(err) != errorMissingScope {
+		return err
+	}
+
+	scopeErr := &ScopeError{Scope: scope, Feature: feature, Err: err}
+	if alerter := scopeAlerterFromContext(ctx); alerter != nil {
+		alerter.alert(ctx, scopeErr)
+	}
+	return scopeErr
+}
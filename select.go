@@ -0,0 +1,103 @@
+package slacker
+
+import (
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	selectionSeparator = ","
+)
+
+// NewUserSelect creates a menu to pick a single user
+func NewUserSelect(actionID string, placeholder string) *slack.SelectBlockElement {
+	return slack.NewOptionsSelectBlockElement(slack.OptTypeUser, selectPlaceholder(placeholder), actionID)
+}
+
+// NewUsersMultiSelect creates a menu to pick several users
+func NewUsersMultiSelect(actionID string, placeholder string) *slack.MultiSelectBlockElement {
+	return slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeUser, selectPlaceholder(placeholder), actionID)
+}
+
+// NewChannelSelect creates a menu to pick a single public channel
+func NewChannelSelect(actionID string, placeholder string) *slack.SelectBlockElement {
+	return slack.NewOptionsSelectBlockElement(slack.OptTypeChannels, selectPlaceholder(placeholder), actionID)
+}
+
+// NewChannelsMultiSelect creates a menu to pick several public channels
+func NewChannelsMultiSelect(actionID string, placeholder string) *slack.MultiSelectBlockElement {
+	return slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeChannels, selectPlaceholder(placeholder), actionID)
+}
+
+// NewConversationSelect creates a menu to pick a single conversation, including private channels and direct messages
+func NewConversationSelect(actionID string, placeholder string) *slack.SelectBlockElement {
+	return slack.NewOptionsSelectBlockElement(slack.OptTypeConversations, selectPlaceholder(placeholder), actionID)
+}
+
+// NewConversationsMultiSelect creates a menu to pick several conversations, including private channels and direct messages
+func NewConversationsMultiSelect(actionID string, placeholder string) *slack.MultiSelectBlockElement {
+	return slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeConversations, selectPlaceholder(placeholder), actionID)
+}
+
+func selectPlaceholder(placeholder string) *slack.TextBlockObject {
+	if len(placeholder) == 0 {
+		return nil
+	}
+	return slack.NewTextBlockObject(slack.PlainTextType, placeholder, false, false)
+}
+
+// BlockAction returns the block action of the interaction being handled, or nil outside of interactions
+func BlockAction(botCtx BotContext) *slack.BlockAction {
+	callback, ok := botCtx.Event().Data.(*slack.InteractionCallback)
+	if !ok || len(callback.ActionCallback.BlockActions) == 0 {
+		return nil
+	}
+	return callback.ActionCallback.BlockActions[0]
+}
+
+// SelectedIDs returns the IDs picked in a user, channel or conversation menu, single or multiple
+func SelectedIDs(action *slack.BlockAction) []string {
+	ids := []string{}
+	switch action.Type {
+	case slack.ActionType(slack.OptTypeUser):
+		ids = append(ids, action.SelectedUser)
+	case slack.ActionType(slack.OptTypeChannels):
+		ids = append(ids, action.SelectedChannel)
+	case slack.ActionType(slack.OptTypeConversations):
+		ids = append(ids, action.SelectedConversation)
+	case slack.ActionType(slack.MultiOptTypeUser):
+		ids = append(ids, action.SelectedUsers...)
+	case slack.ActionType(slack.MultiOptTypeChannels):
+		ids = append(ids, action.SelectedChannels...)
+	case slack.ActionType(slack.MultiOptTypeConversations):
+		ids = append(ids, action.SelectedConversations...)
+	}
+
+	selected := []string{}
+	for _, id := range ids {
+		if len(id) > 0 {
+			selected = append(selected, id)
+		}
+	}
+	return selected
+}
+
+// SelectedUsers returns the profiles of the users picked in a user menu
+func SelectedUsers(botCtx BotContext, action *slack.BlockAction) ([]slack.User, error) {
+	ids := SelectedIDs(action)
+	if len(ids) == 0 {
+		return []slack.User{}, nil
+	}
+
+	users, err := botCtx.Client().GetUsersInfoContext(botCtx.Context(), ids...)
+	if err != nil {
+		return nil, scopeError(botCtx.Context(), err, featureUsers, scopeUsersRead)
+	}
+	return *users, nil
+}
+
+// selectionValue returns the IDs picked in a menu, separated by commas
+func selectionValue(action *slack.BlockAction) string {
+	return strings.Join(SelectedIDs(action), selectionSeparator)
+}
@@ -0,0 +1,128 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+)
+
+var (
+	errEmbeddingCount = errors.New("embedding provider returned the wrong number of embeddings")
+)
+
+// An EmbeddingProvider interface turns texts into vectors whose cosine similarity
+// reflects how close their meanings are
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// semanticMatcher finds the command whose description is closest in meaning to a message
+type semanticMatcher struct {
+	provider  EmbeddingProvider
+	threshold float64
+
+	mutex      sync.Mutex
+	embeddings map[string][]float64
+}
+
+func newSemanticMatcher(provider EmbeddingProvider, threshold float64) *semanticMatcher {
+	if provider == nil {
+		return nil
+	}
+	return &semanticMatcher{provider: provider, threshold: threshold, embeddings: make(map[string][]float64)}
+}
+
+// match returns the closest command and its similarity, or nil when none reaches the threshold
+func (m *semanticMatcher) match(ctx context.Context, commands []BotCommand, text string) (BotCommand, float64, error) {
+	if err := m.embedCommands(ctx, commands); err != nil {
+		return nil, 0, err
+	}
+
+	embeddings, err := m.provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(embeddings) != 1 {
+		return nil, 0, errEmbeddingCount
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var closest BotCommand
+	best := 0.0
+	for _, cmd := range commands {
+		similarity := cosineSimilarity(embeddings[0], m.embeddings[cmd.Usage()])
+		if similarity >= m.threshold && (closest == nil || similarity > best) {
+			closest = cmd
+			best = similarity
+		}
+	}
+	return closest, best, nil
+}
+
+// embedCommands computes the embeddings of the commands that were not seen yet,
+// so commands registered with hot reload are picked up
+func (m *semanticMatcher) embedCommands(ctx context.Context, commands []BotCommand) error {
+	m.mutex.Lock()
+	missing := []BotCommand{}
+	for _, cmd := range commands {
+		if _, ok := m.embeddings[cmd.Usage()]; !ok {
+			missing = append(missing, cmd)
+		}
+	}
+	m.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	texts := []string{}
+	for _, cmd := range missing {
+		texts = append(texts, commandDescription(cmd))
+	}
+
+	embeddings, err := m.provider.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(embeddings) != len(texts) {
+		return errEmbeddingCount
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, cmd := range missing {
+		m.embeddings[cmd.Usage()] = embeddings[i]
+	}
+	return nil
+}
+
+// commandDescription is the text embedded for a command
+func commandDescription(cmd BotCommand) string {
+	parts := []string{cmd.Usage()}
+	if cmd.Definition() != nil {
+		parts = append(parts, cmd.Definition().Description, cmd.Definition().Example)
+	}
+	return strings.TrimSpace(strings.Join(parts, newLine))
+}
+
+func cosineSimilarity(a []float64, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
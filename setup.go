@@ -0,0 +1,257 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	setupCommand       = "setup"
+	setupDescription   = "Configures the bot for this workspace"
+	setupID            = "slacker_setup"
+	setupKeyPrefix     = "setup"
+	setupTitle         = "Setup"
+	setupChannelsBlock = "channels"
+	setupAdminsBlock   = "admins"
+	setupFeaturesBlock = "features"
+	setupChannelsTitle = "Channels"
+	setupChannelsLabel = "Default channels"
+	setupAdminsTitle   = "Admins"
+	setupAdminsLabel   = "Admin users"
+	setupFeaturesTitle = "Features"
+	setupFeaturesLabel = "Enabled features"
+	setupPrompt        = "Click the button to configure the bot for this workspace"
+	setupButton        = "Start setup"
+	setupSavedMessage  = "Setup saved :white_check_mark:"
+)
+
+// Settings are the workspace settings collected by the setup command
+type Settings struct {
+	Channels []string        `json:"channels"`
+	Admins   []string        `json:"admins"`
+	Features map[string]bool `json:"features"`
+}
+
+// Enabled reports whether the feature was turned on during setup
+func (s *Settings) Enabled(feature string) bool {
+	return s.Features[feature]
+}
+
+// IsAdmin reports whether the user was made an admin during setup
+func (s *Settings) IsAdmin(userID string) bool {
+	for _, admin := range s.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings returns the settings saved by the setup command for the workspace,
+// which are empty until setup has been completed
+func (s *Slacker) Settings(ctx context.Context, teamID string) (*Settings, error) {
+	settings := &Settings{Channels: []string{}, Admins: []string{}, Features: make(map[string]bool)}
+	if _, err := s.loadValue(ctx, storeKey(setupKeyPrefix, teamID), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// setupHandler opens the setup wizard. Messages are answered with a button that opens it,
+// since modals can only be opened in response to an interaction.
+func (s *Slacker) setupHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	if command, ok := ev.Data.(*slack.SlashCommand); ok {
+		if err := s.openSetup(botCtx, command.TriggerID); err != nil {
+			response.ReportError(err)
+		}
+		return
+	}
+
+	prompt := slack.NewTextBlockObject(slack.MarkdownType, setupPrompt, false, false)
+	button := slack.NewButtonBlockElement(setupID, empty, slack.NewTextBlockObject(slack.PlainTextType, setupButton, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(prompt, nil, nil),
+		slack.NewActionBlock(setupID, button),
+	}
+
+	if err := response.Reply(setupPrompt, WithBlocks(blocks), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// setupAuthorization lets the admins chosen during setup run it again. Before the first setup,
+// it is limited to the workspace's admins and owners.
+func (s *Slacker) setupAuthorization(botCtx BotContext, request Request) bool {
+	if s.Elevated(botCtx) {
+		return true
+	}
+
+	ev := botCtx.Event()
+	settings, err := s.Settings(botCtx.Context(), ev.TeamID)
+	if err != nil {
+		fmt.Printf("failed loading settings: %v\n", err)
+		return false
+	}
+
+	if len(settings.Admins) > 0 {
+		return settings.IsAdmin(ev.User)
+	}
+
+	user, err := s.client.GetUserInfoContext(botCtx.Context(), ev.User)
+	if err != nil {
+		fmt.Printf("failed getting user: %v\n", scopeError(botCtx.Context(), err, featureUsers, scopeUsersRead))
+		return false
+	}
+	return user.IsAdmin || user.IsOwner
+}
+
+// handleSetupAction opens the wizard when the button posted by setupHandler is clicked
+func (s *Slacker) handleSetupAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	if !s.setupAuthorization(botCtx, nil) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	if err := s.openSetup(botCtx, callback.TriggerID); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// openSetup opens the wizard filled in with the current settings
+func (s *Slacker) openSetup(botCtx BotContext, triggerID string) error {
+	settings, err := s.Settings(botCtx.Context(), botCtx.Event().TeamID)
+	if err != nil {
+		return err
+	}
+
+	features := []string{}
+	for _, feature := range s.setupFeatures {
+		if settings.Enabled(feature) {
+			features = append(features, feature)
+		}
+	}
+
+	values := map[string]string{
+		setupChannelsBlock: strings.Join(settings.Channels, selectionSeparator),
+		setupAdminsBlock:   strings.Join(settings.Admins, selectionSeparator),
+		setupFeaturesBlock: strings.Join(features, selectionSeparator),
+	}
+	return s.openWizard(botCtx, setupID, triggerID, values)
+}
+
+// saveSetup stores the settings submitted through the wizard
+func (s *Slacker) saveSetup(botCtx BotContext, response ResponseWriter, values map[string]string) {
+	settings := &Settings{
+		Channels: splitSelection(values[setupChannelsBlock]),
+		Admins:   splitSelection(values[setupAdminsBlock]),
+		Features: make(map[string]bool),
+	}
+
+	// The admin running setup is kept as an admin so they cannot lock themselves out
+	if !settings.IsAdmin(botCtx.Event().User) {
+		settings.Admins = append(settings.Admins, botCtx.Event().User)
+	}
+
+	for _, feature := range s.setupFeatures {
+		settings.Features[feature] = false
+	}
+	for _, feature := range splitSelection(values[setupFeaturesBlock]) {
+		settings.Features[feature] = true
+	}
+
+	if err := s.saveValue(botCtx.Context(), storeKey(setupKeyPrefix, botCtx.Event().TeamID), settings, 0); err != nil {
+		response.ReportError(err)
+		return
+	}
+	response.Reply(setupSavedMessage)
+}
+
+// setupWizard builds the wizard asking for channels, admins and, when there are any, feature toggles
+func (s *Slacker) setupWizard() *WizardDefinition {
+	steps := []*WizardStep{
+		{
+			Title: setupChannelsTitle,
+			Blocks: func(values map[string]string) []slack.Block {
+				element := NewChannelsMultiSelect(setupChannelsBlock, empty)
+				element.InitialChannels = splitSelection(values[setupChannelsBlock])
+				return []slack.Block{setupInput(setupChannelsBlock, setupChannelsLabel, element)}
+			},
+		},
+		{
+			Title: setupAdminsTitle,
+			Blocks: func(values map[string]string) []slack.Block {
+				element := NewUsersMultiSelect(setupAdminsBlock, empty)
+				element.InitialUsers = splitSelection(values[setupAdminsBlock])
+				return []slack.Block{setupInput(setupAdminsBlock, setupAdminsLabel, element)}
+			},
+		},
+	}
+
+	if len(s.setupFeatures) > 0 {
+		steps = append(steps, &WizardStep{
+			Title: setupFeaturesTitle,
+			Blocks: func(values map[string]string) []slack.Block {
+				selected := make(map[string]bool)
+				for _, feature := range splitSelection(values[setupFeaturesBlock]) {
+					selected[feature] = true
+				}
+
+				options := []*slack.OptionBlockObject{}
+				initial := []*slack.OptionBlockObject{}
+				for _, feature := range s.setupFeatures {
+					option := NewOption(feature, feature)
+					options = append(options, option)
+					if selected[feature] {
+						initial = append(initial, option)
+					}
+				}
+
+				element := NewCheckboxes(setupFeaturesBlock, options...)
+				element.InitialOptions = initial
+				return []slack.Block{setupInput(setupFeaturesBlock, setupFeaturesLabel, element)}
+			},
+		})
+	}
+
+	return &WizardDefinition{
+		Title:   setupTitle,
+		Steps:   steps,
+		Confirm: true,
+		Handler: s.saveSetup,
+	}
+}
+
+// appendSetupHandle adds the setup command when it is enabled, it is called with the lock held
+func (s *Slacker) appendSetupHandle() {
+	if !s.setup {
+		return
+	}
+
+	s.wizards[setupID] = s.setupWizard()
+	s.addBuiltinCommand(NewBotCommand(setupCommand, &CommandDefinition{
+		Description:       setupDescription,
+		Handler:           s.setupHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead},
+	}))
+}
+
+func setupInput(blockID string, label string, element slack.BlockElement) *slack.InputBlock {
+	input := slack.NewInputBlock(blockID, slack.NewTextBlockObject(slack.PlainTextType, label, false, false), nil, element)
+	input.Optional = true
+	return input
+}
+
+func splitSelection(value string) []string {
+	selection := []string{}
+	for _, item := range strings.Split(value, selectionSeparator) {
+		if len(item) > 0 {
+			selection = append(selection, item)
+		}
+	}
+	return selection
+}
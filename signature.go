@@ -0,0 +1,124 @@
+package slacker
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerSlackSignature      = "X-Slack-Signature"
+	headerSlackTimestamp      = "X-Slack-Request-Timestamp"
+	signatureVersion          = "v0"
+	signatureBaseFormat       = "v0:%s:"
+	signatureKeyPrefix        = "signature"
+	defaultSignatureTolerance = 5 * time.Minute
+	maxSignedBodyBytes        = 1 << 20
+	metricSignatures          = "slacker_http_signatures_total"
+	metricSignatureSkew       = "slacker_http_signature_skew_seconds"
+	metricLabelResult         = "result"
+	signatureResultValid      = "valid"
+	signatureResultMissing    = "missing"
+	signatureResultStale      = "stale"
+	signatureResultInvalid    = "invalid"
+	signatureResultReplayed   = "replayed"
+	signatureResultError      = "error"
+)
+
+var (
+	// ErrInvalidSignature is returned when a request is not signed with the signing secret
+	ErrInvalidSignature = errors.New("invalid request signature")
+
+	// ErrStaleSignature is returned when a request was signed too long ago, or in the future
+	ErrStaleSignature = errors.New("request timestamp is outside the tolerance")
+
+	// ErrReplayedSignature is returned when a request was already received
+	ErrReplayedSignature = errors.New("request signature was already seen")
+)
+
+// VerifySignatures rejects the requests that were not signed by Slack with the signing secret,
+// whose timestamp is off the clock by more than the tolerance, 5 minutes by default, or that were
+// already received. Seen signatures are kept in the store until their timestamp is out of the
+// tolerance, so that bots sharing a store reject replays sent to any of them. The results are
+// counted in the slacker_http_signatures_total metric, by result.
+func (s *Slacker) VerifySignatures(signingSecret string, tolerance time.Duration) HTTPMiddleware {
+	if tolerance <= 0 {
+		tolerance = defaultSignatureTolerance
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := s.verifySignature(w, r, signingSecret, tolerance)
+			if s.metrics != nil {
+				s.metrics.Count(metricSignatures, 1, map[string]string{metricLabelResult: result})
+			}
+
+			switch {
+			case result == signatureResultError:
+				fmt.Printf("failed verifying signature: %v\n", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			case err != nil:
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// verifySignature checks the request's signature, leaving its body readable again, and returns
+// the result to count along with the reason of the rejection
+func (s *Slacker) verifySignature(w http.ResponseWriter, r *http.Request, signingSecret string, tolerance time.Duration) (string, error) {
+	timestamp := r.Header.Get(headerSlackTimestamp)
+	signature := r.Header.Get(headerSlackSignature)
+	if len(timestamp) == 0 || !strings.HasPrefix(signature, signatureVersion+"=") {
+		return signatureResultMissing, ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return signatureResultMissing, ErrInvalidSignature
+	}
+
+	// Slack's clock and ours may drift apart either way
+	skew := s.clock.Now().Sub(time.Unix(seconds, 0))
+	if s.metrics != nil {
+		s.metrics.Observe(metricSignatureSkew, skew.Seconds(), nil)
+	}
+	if skew > tolerance || skew < -tolerance {
+		return signatureResultStale, ErrStaleSignature
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+	if err != nil {
+		return signatureResultInvalid, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(fmt.Sprintf(signatureBaseFormat, timestamp)))
+	mac.Write(body)
+	expected := signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return signatureResultInvalid, ErrInvalidSignature
+	}
+
+	// Only valid signatures are remembered, so that forged requests cannot fill the cache. The
+	// signature is seen once when it was absent.
+	fresh, err := s.compareAndSwap(r.Context(), storeKey(signatureKeyPrefix, signature), nil, []byte(timestamp), 2*tolerance)
+	if err != nil {
+		return signatureResultError, err
+	}
+	if !fresh {
+		return signatureResultReplayed, ErrReplayedSignature
+	}
+	return signatureResultValid, nil
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/url"
+	"net/http"
+	"path"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/shomali11/proper"
 	"github.com/slack-go/slack"
@@ -36,24 +41,47 @@ var (
 // NewClient creates a new client using the Slack API
 func NewClient(botToken, appToken string, options ...ClientOption) (*Slacker, error) {
 	defaults := newClientDefaults(options...)
+	if defaults.Sudo != nil && defaults.Sudo.AuthorizationFunc == nil {
+		return nil, ErrInvalidSudo
+	}
 
-	api := slack.New(
-		botToken,
-		slack.OptionDebug(defaults.Debug),
+	apiOptions := []slack.Option{
+		slack.OptionDebug(defaults.APIDebug),
 		slack.OptionAppLevelToken(appToken),
-	)
+	}
+	httpClient := defaults.HTTPClient
+	if defaults.FaultInjection != nil {
+		httpClient = withFaultInjection(httpClient, defaults.FaultInjection, defaults.Clock)
+	}
+	if defaults.EnvelopeLogging != nil {
+		httpClient = withEnvelopeLogging(httpClient, defaults.EnvelopeLogging)
+	}
+	if httpClient != nil {
+		apiOptions = append(apiOptions, slack.OptionHTTPClient(httpClient))
+	}
+	if len(defaults.APIURL) > 0 {
+		apiOptions = append(apiOptions, slack.OptionAPIURL(defaults.APIURL))
+	}
+	api := slack.New(botToken, apiOptions...)
 
 	info, err := api.AuthTest()
 	if err != nil {
 		return nil, err
 	}
 
+	workerPool := defaults.WorkerPool
+	if workerPool == nil && defaults.Concurrency > 0 {
+		workerPool = NewQueuedWorkerPool(defaults.Concurrency, defaults.QueueSize)
+	}
+
+	adminNotifier := newAdminNotifier(api, defaults.Clock)
 	smc := socketmode.New(
 		api,
-		socketmode.OptionDebug(defaults.Debug),
+		socketmode.OptionDebug(defaults.SocketModeDebug),
 	)
 	slacker := &Slacker{
 		client:                api,
+		botToken:              botToken,
 		socketModeClient:      smc,
 		commandChannel:        make(chan *CommandEvent, 100),
 		unAuthorizedError:     unAuthorizedError,
@@ -61,33 +89,206 @@ func NewClient(botToken, appToken string, options ...ClientOption) (*Slacker, er
 		requestConstructor:    NewRequest,
 		responseConstructor:   NewResponse,
 		botID:                 info.BotID,
+		botUserID:             info.UserID,
+		mentionAnywhere:       defaults.MentionAnywhere,
+		stopWords:             defaults.StopWords,
+		modalFallback:         defaults.ModalFallback,
+		store:                 defaults.Store,
+		historySize:           defaults.HistorySize,
+		undoWindow:            defaults.UndoWindow,
+		tasks:                 newTaskTracker(),
+		scheduler:             newScheduler(api, defaults.Clock, adminNotifier),
+		semanticMatcher:       newSemanticMatcher(defaults.EmbeddingProvider, defaults.SemanticThreshold),
+		actionRoutes:          make(map[string]interactionRoute),
+		viewSubmissionRoutes:  make(map[string]viewSubmissionRoute),
+		panels:                make(map[string]*ControlPanelDefinition),
+		wizards:               make(map[string]*WizardDefinition),
+		reports:               make(map[string]*report),
+		reactionCommands:      make(map[string]BotCommand),
+		translator:            defaults.Translator,
+		language:              defaults.Language,
+		scopeAlerter:          newScopeAlerter(api, defaults.ScopeAlertUser),
+		adminNotifier:         adminNotifier,
+		errorPresenter:        defaults.ErrorPresenter,
+		errorReporter:         defaults.ErrorReporter,
+		eventPooling:          defaults.EventPooling,
+		filter:                newMessageFilter(api, defaults),
+		setup:                 defaults.Setup,
+		workerPool:            workerPool,
+		setupFeatures:         defaults.SetupFeatures,
+		hotReload:             defaults.HotReload,
+		eventDumpRate:         defaults.EventDumpRate,
+		matcherTrace:          defaults.MatcherTrace,
+		unroutedSampleRate:    defaults.UnroutedSampleRate,
+		unroutedTTL:           defaults.UnroutedTTL,
+		feedbackSink:          defaults.FeedbackSink,
+		attachmentMatching:    defaults.AttachmentMatching,
+		muteCommands:          defaults.MuteCommands,
+		escalations:           newEscalations(),
+		breakers:              newBreakers(defaults.Clock),
+		providers:             newProviderCache(defaults.Clock),
+		acks:                  newAckTracker(defaults.Clock),
+		faults:                defaults.FaultInjection,
+		clock:                 defaults.Clock,
+		codec:                 defaults.Codec,
+		receiptsCommand:       defaults.ReceiptsCommand,
+		metrics:               defaults.Metrics,
+		metricLabeler:         newMetricLabeler(defaults.MetricLabels),
+		slowHandlerThreshold:  defaults.SlowHandlerThreshold,
+		eventBacklogThreshold: defaults.EventBacklogThreshold,
+		statusCommand:         defaults.StatusCommand,
+		forgetMeCommand:       defaults.ForgetMeCommand,
+		sudo:                  defaults.Sudo,
+		envelopeLogging:       defaults.EnvelopeLogging,
+		diagnosticsCommand:    defaults.DiagnosticsCommand,
+		delayedCommands:       defaults.DelayedCommands,
+		recurringCommands:     defaults.RecurringCommands,
+		batchCommands:         defaults.BatchCommands,
+		pipelines:             defaults.Pipelines,
+		archivePolicy:         defaults.ArchivePolicy,
+		editedCommands:        defaults.EditedCommands,
+		canvases:              newCanvasClient(httpClient, defaults.APIURL, botToken),
 	}
+
+	slacker.conversations = newConversations(defaults, slacker.compareAndSwap)
+	slacker.retention = newRetention(slacker.store, slacker.codec, slacker.scheduler, defaults.Clock)
+	slacker.quietPolicy = newQuietPolicy(slacker.store, slacker.codec, slacker.scheduler, slacker.retention, defaults.QuietHours, defaults.Clock, slacker.compareAndSwap)
+
+	if err := slacker.scheduleStoreSweep(defaults.StoreSweepPeriod); err != nil {
+		return nil, err
+	}
+	if err := slacker.retention.schedule(); err != nil {
+		return nil, err
+	}
+	if err := slacker.scheduleDelayedCommands(); err != nil {
+		return nil, err
+	}
+
+	slacker.routeAction(modalFallbackID, slacker.handleModalFallbackAction)
+	slacker.routeViewSubmission(modalFallbackID, slacker.handleModalFallbackSubmission)
+	slacker.routeAction(undoConfirmID, slacker.handleUndoAction)
+	slacker.routeAction(undoCancelID, slacker.handleUndoAction)
+	slacker.routeAction(taskCancelID, slacker.handleTaskCancel)
+	slacker.routeAction(feedbackPositiveID, slacker.handleFeedbackAction)
+	slacker.routeAction(feedbackNegativeID, slacker.handleFeedbackAction)
+	slacker.routeViewSubmission(wizardID, slacker.handleWizardSubmission)
+	slacker.routeAction(wizardBackID, slacker.handleWizardBack)
+	slacker.routeAction(setupID, slacker.handleSetupAction)
+	slacker.routeAction(errorReportID, slacker.handleErrorReport)
+	slacker.routeAction(escalationAckID, slacker.handleEscalationAck)
+	slacker.routeAction(broadcastAckID, slacker.handleBroadcastAck)
+	slacker.routeAction(approvalApproveID, slacker.handleApprovalAction)
+	slacker.routeAction(approvalRejectID, slacker.handleApprovalAction)
 	return slacker, nil
 }
 
 // Slacker contains the Slack API, botCommands, and handlers
 type Slacker struct {
-	client                  *slack.Client
-	socketModeClient        *socketmode.Client
-	botCommands             []BotCommand
-	botLinkShares           []BotLinkShare
-	botContextConstructor   func(ctx context.Context, api *slack.Client, client *socketmode.Client, evt *MessageEvent) BotContext
-	requestConstructor      func(botCtx BotContext, properties *proper.Properties) Request
-	responseConstructor     func(botCtx BotContext) ResponseWriter
-	interactiveEventHandler func(botCtx botContext, response ResponseWriter)
-	initHandler             func()
-	errorHandler            func(err string)
-	helpDefinition          *CommandDefinition
-	interactionHandler      func(botCtx BotContext, response ResponseWriter, callback_id string, block_id string, action_id string, value string)
-	messageHandler          func(botCtx BotContext, response ResponseWriter)
-	unAuthorizedError       error
-	commandChannel          chan *CommandEvent
-	botID                   string
+	client                *slack.Client
+	botToken              string
+	socketModeClient      *socketmode.Client
+	botCommands           []BotCommand
+	botLinkShares         []BotLinkShare
+	botContextConstructor func(ctx context.Context, api *slack.Client, client *socketmode.Client, evt *MessageEvent) BotContext
+	requestConstructor    func(botCtx BotContext, properties *proper.Properties) Request
+	customRequest         bool
+	responseConstructor   func(botCtx BotContext) ResponseWriter
+	initHandler           func()
+	errorHandler          func(err string)
+	helpDefinition        *CommandDefinition
+	interactionHandler    func(botCtx BotContext, response ResponseWriter, callback_id string, block_id string, action_id string, value string)
+	interactions          []*botInteraction
+	slashCommands         []*slashCommand
+	submissionHandlers    map[string]ViewSubmissionHandler
+	closedHandlers        map[string]func(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback)
+	messageHandler        func(botCtx BotContext, response ResponseWriter)
+	unAuthorizedError     error
+	commandChannel        chan *CommandEvent
+	commandEventsRead     int32
+	botID                 string
+	botUserID             string
+	mentionAnywhere       bool
+	stopWords             []string
+	modalFallback         bool
+	store                 Store
+	storeMutex            sync.Mutex
+	historySize           int
+	undoWindow            time.Duration
+	tasks                 *taskTracker
+	scheduler             *scheduler
+	conversations         *conversations
+	semanticMatcher       *semanticMatcher
+	actionRoutes          map[string]interactionRoute
+	viewSubmissionRoutes  map[string]viewSubmissionRoute
+	panels                map[string]*ControlPanelDefinition
+	wizards               map[string]*WizardDefinition
+	reports               map[string]*report
+	reactionCommands      map[string]BotCommand
+	topicChannels         []string
+	translator            Translator
+	translations          translatedCommands
+	language              string
+	scopeAlerter          *scopeAlerter
+	adminNotifier         *adminNotifier
+	errorPresenter        ErrorPresenter
+	errorReporter         ErrorReporter
+	matcher               commandMatcher
+	eventPooling          bool
+	filter                *messageFilter
+	setup                 bool
+	setupFeatures         []string
+	workerPool            *WorkerPool
+	fleet                 *Fleet
+	bridges               []*bridge
+	hotReload             bool
+	mutex                 sync.RWMutex
+	running               bool
+	initialized           bool
+	eventDumpRate         float64
+	matcherTrace          bool
+	unroutedSampleRate    float64
+	unroutedTTL           time.Duration
+	feedbackSink          FeedbackSink
+	attachmentMatching    bool
+	watchers              []*watcher
+	muteCommands          bool
+	quietPolicy           *quietPolicy
+	escalations           *escalations
+	receiptsCommand       bool
+	metrics               Metrics
+	metricLabeler         *metricLabeler
+	slowHandlerThreshold  time.Duration
+	eventBacklogThreshold int
+	backlogWarned         time.Time
+	statusCommand         bool
+	breakers              *breakers
+	faults                *FaultInjection
+	clock                 Clock
+	providers             *providerCache
+	acks                  *ackTracker
+	codec                 Codec
+	migrations            []*migration
+	forgetMeCommand       bool
+	retention             *retention
+	sudo                  *SudoDefinition
+	envelopeLogging       *EnvelopeLogging
+	diagnosticsCommand    bool
+	delayedCommands       bool
+	recurringCommands     bool
+	batchCommands         bool
+	pipelines             bool
+	archivePolicy         *ArchivePolicy
+	editedCommands        bool
+	canvases              *canvasClient
+	httpHandler           http.Handler
+	httpContext           context.Context
+	recurringSchedules    map[string]*stoppableSchedule
+	middleware            []MiddlewareFunc
 }
 
 // BotCommands returns Bot Commands
 func (s *Slacker) BotCommands() []BotCommand {
-	return s.botCommands
+	return s.commands()
 }
 
 // Client returns the internal slack.Client of Slacker struct
@@ -101,65 +302,162 @@ func (s *Slacker) SocketMode() *socketmode.Client {
 }
 
 // Init handle the event when the bot is first connected
-func (s *Slacker) Init(initHandler func()) {
-	s.initHandler = initHandler
+func (s *Slacker) Init(initHandler func()) error {
+	return s.register(func() {
+		s.initHandler = initHandler
+	})
 }
 
-// Err handle when errors are encountered
-func (s *Slacker) Err(errorHandler func(err string)) {
-	s.errorHandler = errorHandler
+// Err handle when errors are encountered, such as events that could not be acknowledged
+func (s *Slacker) Err(errorHandler func(err string)) error {
+	return s.register(func() {
+		s.errorHandler = errorHandler
+	})
+}
+
+// CustomBotContext creates the bot context given to every handler, so that applications can add
+// their own data, such as the tenant or a database handle. The custom type can embed the BotContext
+// returned by NewBotContext, and handlers get it back with a type assertion.
+func (s *Slacker) CustomBotContext(botContextConstructor func(ctx context.Context, api *slack.Client, client *socketmode.Client, evt *MessageEvent) BotContext) error {
+	return s.register(func() {
+		s.botContextConstructor = botContextConstructor
+	})
 }
 
 // CustomRequest creates a new request
-func (s *Slacker) CustomRequest(requestConstructor func(botCtx BotContext, properties *proper.Properties) Request) {
-	s.requestConstructor = requestConstructor
+func (s *Slacker) CustomRequest(requestConstructor func(botCtx BotContext, properties *proper.Properties) Request) error {
+	return s.register(func() {
+		s.requestConstructor = requestConstructor
+		s.customRequest = true
+	})
 }
 
 // CustomResponse creates a new response writer
-func (s *Slacker) CustomResponse(responseConstructor func(botCtx BotContext) ResponseWriter) {
-	s.responseConstructor = responseConstructor
+func (s *Slacker) CustomResponse(responseConstructor func(botCtx BotContext) ResponseWriter) error {
+	return s.register(func() {
+		s.responseConstructor = responseConstructor
+	})
 }
 
 // UnAuthorizedError error message
-func (s *Slacker) UnAuthorizedError(unAuthorizedError error) {
-	s.unAuthorizedError = unAuthorizedError
+func (s *Slacker) UnAuthorizedError(unAuthorizedError error) error {
+	return s.register(func() {
+		s.unAuthorizedError = unAuthorizedError
+	})
 }
 
-// Help handle the help message, it will use the default if not set
-func (s *Slacker) Help(definition *CommandDefinition) {
+// Help handle the help message, it will use the default if not set.
+// It has to be called before Listen, even when hot reload is enabled.
+func (s *Slacker) Help(definition *CommandDefinition) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.initialized {
+		return ErrAlreadyListening
+	}
+
 	s.helpDefinition = definition
+	return nil
 }
 
 // Command define a new command and append it to the list of existing commands
-func (s *Slacker) Command(usage string, definition *CommandDefinition) {
-	s.botCommands = append(s.botCommands, NewBotCommand(usage, definition))
+func (s *Slacker) Command(usage string, definition *CommandDefinition) error {
+	if definition != nil && definition.err != nil {
+		return definition.err
+	}
+
+	return s.register(func() {
+		s.addCommand(NewBotCommand(usage, definition))
+		if s.initialized {
+			s.appendUndoHandle()
+		}
+	})
 }
 
 // LinkShare define a new link handler and append it to the list of existing link handlers
-func (s *Slacker) Link(domain string, definition *LinkShareDefinition) {
-	s.botLinkShares = append(s.botLinkShares, NewBotLinkShare(domain, definition))
+func (s *Slacker) Link(domain string, definition *LinkShareDefinition) error {
+	return s.register(func() {
+		linkShares := make([]BotLinkShare, len(s.botLinkShares), len(s.botLinkShares)+1)
+		copy(linkShares, s.botLinkShares)
+		s.botLinkShares = append(linkShares, NewBotLinkShare(domain, definition))
+	})
 }
 
-// Interact handles all actions from buttons
-func (s *Slacker) Interact(interactionHandler func(botCtx BotContext, response ResponseWriter, callback_id string, block_id string, action_id string, value string)) {
-	s.interactionHandler = interactionHandler
+// Interact handles all actions from buttons and other block elements, the value being the selection of pickers.
+// Actions routed with Interaction do not reach it.
+func (s *Slacker) Interact(interactionHandler func(botCtx BotContext, response ResponseWriter, callback_id string, block_id string, action_id string, value string)) error {
+	return s.register(func() {
+		s.interactionHandler = interactionHandler
+	})
+}
+
+// Interaction handles the actions whose action ID, block ID or callback ID matches the pattern,
+// either exactly or as a glob such as `approve_*`. Exact patterns come first, then globs in the
+// order they were added, and actions matching none of them go to the handler set with Interact.
+func (s *Slacker) Interaction(pattern string, interactionHandler func(botCtx BotContext, response ResponseWriter, callbackID string, blockID string, actionID string, value string)) error {
+	if _, err := path.Match(pattern, empty); err != nil {
+		return fmt.Errorf("invalid interaction pattern %q: %w", pattern, err)
+	}
+
+	return s.register(func() {
+		interactions := make([]*botInteraction, len(s.interactions), len(s.interactions)+1)
+		copy(interactions, s.interactions)
+		s.interactions = append(interactions, &botInteraction{pattern: pattern, handler: interactionHandler})
+	})
 }
 
 // Message handle all messages
-func (s *Slacker) Message(messageHandler func(botCtx BotContext, response ResponseWriter)) {
-	s.messageHandler = messageHandler
+func (s *Slacker) Message(messageHandler func(botCtx BotContext, response ResponseWriter)) error {
+	return s.register(func() {
+		s.messageHandler = messageHandler
+	})
+}
+
+// Store returns the store used to persist state
+func (s *Slacker) Store() Store {
+	return s.store
 }
 
-// CommandEvents returns read only command events channel
+// CommandEvents returns read only command events channel. Once it was asked for, the admin channel
+// is alerted when events are dropped because the channel is full.
 func (s *Slacker) CommandEvents() <-chan *CommandEvent {
+	atomic.StoreInt32(&s.commandEventsRead, 1)
 	return s.commandChannel
 }
 
 // Listen receives events from Slack and each is handled as needed
 func (s *Slacker) Listen(ctx context.Context) error {
-	s.prependHelpHandle()
+	if err := s.start(); err != nil {
+		return err
+	}
+	defer s.stop()
+
+	ctx = withConversations(withTaskTracker(ctx, s.tasks), s.conversations)
+	ctx = withAdminNotifier(withScopeAlerter(ctx, s.scopeAlerter), s.adminNotifier)
+	ctx = withQuietPolicy(withMutes(ctx, s.store, s.codec, s.clock), s.quietPolicy)
+	ctx = withErrorReporter(withErrorPresenter(ctx, s.errorPresenter, s.store, s.codec), s.errorReporter)
+	ctx = withArchivePolicy(withRetention(ctx, s.retention), s.archivePolicy)
+	ctx = withClock(withCanvasClient(ctx, s.canvases), s.clock)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+
+	if err := s.restoreRecurringCommands(ctx); err != nil {
+		return err
+	}
+
+	s.scheduler.start(ctx)
+	defer s.scheduler.stop()
+
+	if s.httpHandler != nil {
+		return s.listenHTTP(ctx)
+	}
 
 	go func() {
+		connectionFailures := 0
 		for {
 			select {
 			case <-ctx.Done():
@@ -169,31 +467,46 @@ func (s *Slacker) Listen(ctx context.Context) error {
 					return
 				}
 
+				s.dumpEvent(evt)
+				s.logEnvelope(evt)
+				s.checkEventBacklog(ctx)
+
 				switch evt.Type {
 				case socketmode.EventTypeConnecting:
 					fmt.Println("Connecting to Slack with Socket Mode.")
-					if s.initHandler == nil {
+					s.mutex.RLock()
+					initHandler := s.initHandler
+					s.mutex.RUnlock()
+
+					if initHandler == nil {
 						continue
 					}
-					go s.initHandler()
+					go initHandler()
 				case socketmode.EventTypeConnectionError:
 					fmt.Println("Connection failed. Retrying later...")
+					connectionFailures++
+					if connectionFailures >= connectionFailureAlert {
+						s.adminNotifier.notify(ctx, adminConnectionKind, fmt.Sprintf(adminConnectionFormat, connectionFailures))
+					}
 				case socketmode.EventTypeConnected:
 					fmt.Println("Connected to Slack with Socket Mode.")
+					connectionFailures = 0
 
-				case socketmode.EventTypeInteractive:
+				case socketmode.EventTypeErrorWriteFailed:
+					failure, _ := evt.Data.(*socketmode.ErrorWriteFailed)
+					s.handleWriteFailure(ctx, failure)
 
-					if s.interactiveEventHandler == nil {
-						fmt.Printf("Ignored %+v\n", evt)
-						continue
-					}
+				case socketmode.EventTypeInteractive:
 					callback, ok := evt.Data.(slack.InteractionCallback)
 					if !ok {
 						fmt.Printf("Ignored %+v\n", evt)
 						continue
 					}
-					s.handleInteractionEvent(ctx, &callback)
-					s.socketModeClient.Ack(*evt.Request)
+					if payload := s.handleInteractionEvent(ctx, &callback); payload != nil {
+						s.ack(ctx, evt, payload)
+						continue
+					}
+					s.ack(ctx, evt)
 
 				case socketmode.EventTypeSlashCommand:
 					ev, ok := evt.Data.(slack.SlashCommand)
@@ -201,8 +514,10 @@ func (s *Slacker) Listen(ctx context.Context) error {
 						fmt.Printf("Ignored %+v\n", evt)
 						continue
 					}
-					s.handleCommandEvent(ctx, &ev)
-					s.socketModeClient.Ack(*evt.Request)
+					// Acknowledged first, so that Slack does not deliver the command again
+					// while it waits for a worker
+					s.ack(ctx, evt)
+					s.dispatch(func() { s.handleCommandEvent(ctx, &ev) })
 
 				case socketmode.EventTypeEventsAPI:
 					ev, ok := evt.Data.(slackevents.EventsAPIEvent)
@@ -211,14 +526,8 @@ func (s *Slacker) Listen(ctx context.Context) error {
 						continue
 					}
 
-					switch ev.InnerEvent.Type {
-					case slackevents.Message, slackevents.AppMention, slackevents.LinkShared: // message-based events
-						go s.handleMessageEvent(ctx, ev.InnerEvent.Data, ev.TeamID)
-					default:
-						fmt.Printf("unsupported inner event: %+v\n", ev.InnerEvent.Type)
-					}
-
-					s.socketModeClient.Ack(*evt.Request)
+					s.ack(ctx, evt)
+					s.handleEventsAPIEvent(ctx, ev)
 
 				default:
 					s.socketModeClient.Debugf("unsupported Events API event received")
@@ -229,7 +538,26 @@ func (s *Slacker) Listen(ctx context.Context) error {
 
 	// blocking call that handles listening for events and placing them in the
 	// Events channel as well as handling outgoing events.
-	return s.socketModeClient.Run()
+	return s.socketModeClient.RunContext(ctx)
+}
+
+// handleEventsAPIEvent dispatches the inner event of an Events API event, whether it came through
+// Socket Mode or over HTTP
+func (s *Slacker) handleEventsAPIEvent(ctx context.Context, ev slackevents.EventsAPIEvent) {
+	switch slackevents.EventsAPIType(ev.InnerEvent.Type) {
+	case slackevents.Message, slackevents.AppMention, slackevents.LinkShared: // message-based events
+		data, teamID := ev.InnerEvent.Data, ev.TeamID
+		s.dispatch(func() { s.handleMessageEvent(ctx, data, teamID) })
+	case slackevents.ReactionAdded:
+		if reaction, ok := ev.InnerEvent.Data.(*slackevents.ReactionAddedEvent); ok {
+			teamID := ev.TeamID
+			s.dispatch(func() { s.handleReactionEvent(ctx, reaction, teamID) })
+		}
+	default:
+		fmt.Printf("unsupported inner event: %+v\n", ev.InnerEvent.Type)
+		dropped := fmt.Sprintf(droppedUnsupportedEvent, ev.InnerEvent.Type)
+		s.adminNotifier.notify(ctx, adminDroppedKind, fmt.Sprintf(adminDroppedFormat, dropped))
+	}
 }
 
 // GetUserInfo retrieve complete user information
@@ -240,7 +568,7 @@ func (s *Slacker) GetUserInfo(user string) (*slack.User, error) {
 func (s *Slacker) defaultHelp(botCtx BotContext, request Request, response ResponseWriter) {
 	authorizedCommandAvailable := false
 	helpMessage := empty
-	for _, command := range s.botCommands {
+	for _, command := range s.commands() {
 		tokens := command.Tokenize()
 		for _, token := range tokens {
 			if token.IsParameter() {
@@ -261,6 +589,10 @@ func (s *Slacker) defaultHelp(botCtx BotContext, request Request, response Respo
 
 		helpMessage += newLine
 
+		for i := range command.Definition().Parameters {
+			helpMessage += parameterHelp(&command.Definition().Parameters[i]) + newLine
+		}
+
 		if len(command.Definition().Example) > 0 {
 			helpMessage += fmt.Sprintf(quoteMessageFormat, command.Definition().Example) + newLine
 		}
@@ -285,23 +617,7 @@ func (s *Slacker) prependHelpHandle() {
 		s.helpDefinition.Description = helpCommand
 	}
 
-	s.botCommands = append([]BotCommand{NewBotCommand(helpCommand, s.helpDefinition)}, s.botCommands...)
-}
-
-func (s *Slacker) handleInteractionEvent(ctx context.Context, callback *slack.InteractionCallback) {
-	me := &MessageEvent{
-		Channel: callback.Channel.ID,
-		User:    callback.User.ID,
-		Text:    "",
-		Data:    callback,
-		Type:    string(callback.Type),
-		TeamID:  callback.Team.ID,
-	}
-	botCtx := s.botContextConstructor(ctx, s.client, s.socketModeClient, me)
-	response := s.responseConstructor(botCtx)
-	action := callback.ActionCallback.BlockActions[0]
-
-	s.interactionHandler(botCtx, response, callback.CallbackID, action.BlockID, action.ActionID, action.Value)
+	s.botCommands = append([]BotCommand{&builtinCommand{BotCommand: NewBotCommand(helpCommand, s.helpDefinition)}}, s.botCommands...)
 }
 
 func (s *Slacker) handleCommandEvent(ctx context.Context, evt *slack.SlashCommand) {
@@ -316,72 +632,281 @@ func (s *Slacker) handleCommandEvent(ctx context.Context, evt *slack.SlashComman
 		//ThreadTimeStamp: ev.ThreadTimeStamp,
 	}
 
-	botCtx := s.botContextConstructor(ctx, s.client, s.socketModeClient, ev) // note: nil message event
-	response := s.responseConstructor(botCtx)
+	botCtx := s.newBotContext(ctx, ev) // note: nil message event
+	response := s.withTranslation(botCtx, s.newResponse(botCtx))
 
-	for _, cmd := range s.botCommands {
-		parameters, isMatch := cmd.Match(ev.Text)
-		if !isMatch {
-			continue
-		}
+	if s.executeSlashCommand(botCtx, response, evt.Command, ev.Text) {
+		return
+	}
+	s.executeCommand(botCtx, response, ev.Text)
+}
 
-		request := s.requestConstructor(botCtx, parameters)
-		if cmd.Definition().AuthorizationFunc != nil && !cmd.Definition().AuthorizationFunc(botCtx, request) {
-			response.ReportError(s.unAuthorizedError)
-			return
+// executeCommand runs the first command matching the text and reports whether one was found
+func (s *Slacker) executeCommand(botCtx BotContext, response ResponseWriter, text string) bool {
+	if s.forward(botCtx, text) || s.executePipeline(botCtx, response, text) {
+		return true
+	}
+
+	commands := s.commands()
+	if !s.matcherTrace {
+		if cmd, parameters := s.matcher.indexFor(commands).match(text); cmd != nil {
+			s.dispatchCommand(botCtx, response, cmd, parameters)
+			return true
 		}
+	} else {
+		// Tracing tries every command in turn, to explain why each one did not match
+		for _, cmd := range commands {
+			parameters, isMatch := cmd.Match(text)
+			if !isMatch {
+				s.tracef("`%s` did not match %q", cmd.Usage(), text)
+				continue
+			}
 
-		select {
-		case s.commandChannel <- NewCommandEvent(cmd.Usage(), parameters, ev):
-		default:
-			// full channel, dropped event
+			s.tracef("`%s` matched %q with parameters %v", cmd.Usage(), text, redactedValues(cmd, parameterValues(cmd, parameters)))
+			s.dispatchCommand(botCtx, response, cmd, parameters)
+			return true
 		}
+	}
+
+	if cmd, parameters := s.matchTranslated(botCtx, commands, text); cmd != nil {
+		s.tracef("`%s` matched %q in the user's language", cmd.Usage(), text)
+		s.dispatchCommand(botCtx, response, cmd, parameters)
+		return true
+	}
+
+	s.tracef("no command matched %q", text)
+	if s.semanticMatcher == nil || matchOriginFromContext(botCtx.Context()) != nil {
+		// Attachments and messages of integrations only run the commands they match exactly
+		return false
+	}
+
+	// Exact matching comes first, unmatched messages fall back to the closest command in meaning.
+	// Built-in commands are left out, since they would run without their parameters.
+	cmd, similarity, err := s.semanticMatcher.match(botCtx.Context(), userCommands(commands), text)
+	if err != nil {
+		fmt.Printf("failed matching semantically: %v\n", err)
+		return false
+	}
+	if cmd == nil {
+		s.tracef("no command is similar enough to %q", text)
+		return false
+	}
+
+	s.tracef("`%s` is similar to %q by %.2f", cmd.Usage(), text, similarity)
+	s.dispatchCommand(botCtx, response, cmd, proper.NewProperties(map[string]string{}))
+	return true
+}
+
+// dispatchCommand runs a matched command, asking for missing parameters first when the modal fallback is enabled
+func (s *Slacker) dispatchCommand(botCtx BotContext, response ResponseWriter, cmd BotCommand, parameters *proper.Properties) {
+	if s.modalFallback && len(missingParameters(cmd.Definition().Parameters, parameters)) > 0 {
+		s.requestMissingParameters(botCtx, response, cmd, parameters)
+		return
+	}
+
+	s.runCommand(botCtx, response, cmd, parameters)
+}
+
+// runCommand authorizes, validates and executes a command with the given parameters
+func (s *Slacker) runCommand(botCtx BotContext, response ResponseWriter, cmd BotCommand, parameters *proper.Properties) {
+	if !s.claimCommand(botCtx.Context()) {
+		s.tracef("`%s` was not run, another version of message %s already ran a command", cmd.Usage(), botCtx.Event().TimeStamp)
+		return
+	}
 
-		cmd.Execute(botCtx, request, response)
+	parameters = withDefaults(cmd, parameters)
+	request := s.newRequest(botCtx, parameters)
+	defer s.releaseRequest(request)
+	if cmd.Definition().AuthorizationFunc != nil && !cmd.Definition().AuthorizationFunc(botCtx, request) {
+		s.tracef("`%s` was not authorized for user %s", cmd.Usage(), botCtx.Event().User)
+		response.ReportError(s.authorizationError())
 		return
 	}
+
+	if err := validateParameters(cmd.Definition().Parameters, parameters); err != nil {
+		s.tracef("`%s` has invalid parameters: %v", cmd.Usage(), err)
+		response.ReportError(usageError(cmd, err))
+		return
+	}
+
+	if err := s.validateProvided(botCtx, cmd.Definition().Parameters, parameterValues(cmd, parameters)); err != nil {
+		s.tracef("`%s` has parameters its providers do not know: %v", cmd.Usage(), err)
+		response.ReportError(err)
+		return
+	}
+
+	// Approval comes first, so that a half-open breaker probes with the approved run rather than the request
+	if cmd.Definition().RequireSecondApprover && len(Approver(botCtx)) == 0 {
+		s.tracef("`%s` is waiting for a second approver", cmd.Usage())
+		s.requestApproval(botCtx, response, cmd, parameters)
+		return
+	}
+
+	if !s.breakers.allow(cmd) {
+		s.tracef("`%s` is short-circuited by its circuit breaker", cmd.Usage())
+		response.ReportError(errCommandUnavailable)
+		return
+	}
+
+	if err := s.recordHistory(botCtx, cmd, parameterValues(cmd, parameters)); err != nil {
+		fmt.Printf("failed recording history: %v\n", err)
+	}
+
+	if err := s.recordUndo(botCtx, cmd, parameterValues(cmd, parameters)); err != nil {
+		fmt.Printf("failed recording undo: %v\n", err)
+	}
+
+	select {
+	case s.commandChannel <- newCommandEvent(s.clock, cmd.Usage(), parameters, s.retainedEvent(botCtx.Event())):
+	default:
+		// full channel, dropped event, which only matters when someone reads the channel
+		if atomic.LoadInt32(&s.commandEventsRead) == 1 {
+			s.adminNotifier.notify(botCtx.Context(), adminDroppedKind, fmt.Sprintf(adminDroppedFormat, droppedCommandEvent))
+		}
+	}
+
+	// Each execution gets its own context so that cancelling one of its tasks stops the handler
+	ctx, cancel := context.WithCancel(botCtx.Context())
+	defer cancel()
+
+	exec := &execution{command: cmd, parameters: parameters, cancel: cancel}
+	defer exec.finishTasks()
+	botCtx = s.newBotContext(withExecution(ctx, exec), botCtx.Event())
+	response, outcome := s.withOutcome(cmd, s.withTranslation(botCtx, s.withFeedback(cmd, s.withBatch(botCtx, s.newResponse(botCtx)))))
+	request = s.newRequest(botCtx, parameters)
+	defer s.releaseRequest(request)
+
+	// Registered before the panic recovery so that the error it reports counts as a failure
+	defer s.recordExecution(botCtx, cmd, outcome, s.clock.Now())
+	defer s.breakers.record(cmd, outcome)
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(botCtx, response, cmd, r)
+		}
+	}()
+
+	defer s.observeHandler(botCtx, cmd, s.clock.Now())
+	s.execute(botCtx, request, response, cmd)
+}
+
+// reportPanic tells the user, the admin channel and the error reporter about the panic of the
+// command's handler, which was recovered so as not to crash the bot
+func (s *Slacker) reportPanic(botCtx BotContext, response ResponseWriter, cmd BotCommand, recovered interface{}) {
+	fmt.Printf("failed running command: panic: %v\n%s", recovered, debug.Stack())
+	s.adminNotifier.notify(botCtx.Context(), adminPanicKind, fmt.Sprintf(adminPanicFormat, cmd.Usage(), recovered, debug.Stack()))
+	reportHandlerError(botCtx, fmt.Errorf(panicErrorFormat, recovered), recovered)
+	response.ReportError(errHandlerPanic)
+}
+
+// findCommand returns the command registered with the usage, if any, including slash commands
+func (s *Slacker) findCommand(usage string) BotCommand {
+	for _, cmd := range s.commands() {
+		if cmd.Usage() == usage {
+			return cmd
+		}
+	}
+
+	s.mutex.RLock()
+	slashCommands := s.slashCommands
+	s.mutex.RUnlock()
+
+	for _, cmd := range slashCommands {
+		if cmd.Usage() == usage {
+			return cmd
+		}
+	}
+	return nil
 }
 
 func (s *Slacker) handleMessageEvent(ctx context.Context, evt interface{}, teamID string) {
-	ev := newMessageEvent(evt, teamID)
+	ev := s.acquireMessageEvent(evt, teamID)
 	if ev == nil {
 		// event doesn't appear to be a valid message type
 		return
 	}
+	defer s.releaseMessageEvent(ev)
+
+	if s.isEcho(ev) {
+		// ignore messages this bot posted, and edits of them
+		return
+	}
 
-	if ev.BotID == s.botID {
-		// ignore messages this bot posted
+	if !s.applyEdit(ev) {
 		return
 	}
 
-	botCtx := s.botContextConstructor(ctx, s.client, s.socketModeClient, ev)
-	response := s.responseConstructor(botCtx)
+	if s.filter.ignores(ctx, ev) {
+		s.tracef("message %s in %s is from an ignored sender", ev.TimeStamp, ev.Channel)
+		return
+	}
 
-	if linkEvt, ok := ev.Data.(*slackevents.LinkSharedEvent); ok {
-		for _, link := range s.botLinkShares {
-			for _, domain := range linkEvt.Links {
-				if link.Domain() == domain.Domain {
-					if value, err := url.Parse(domain.URL); err != nil {
-						// bad URL
-					} else {
-						link.Execute(botCtx, value, response)
-					}
-				}
-			}
+	s.runWatchers(ctx, ev)
+
+	if s.editedCommands {
+		ctx = withCommandClaim(ctx, ev)
+	}
+	botCtx := s.newBotContext(ctx, ev)
+	response := s.withTranslation(botCtx, s.newResponse(botCtx))
+
+	s.mutex.RLock()
+	linkShares := s.botLinkShares
+	messageHandler := s.messageHandler
+	s.mutex.RUnlock()
+
+	if linkEvt := ev.LinkShared(); linkEvt != nil {
+		s.handleLinks(ctx, ev, linkEvt, linkShares)
+	}
+
+	if isAddressedToBot(ev) {
+		text, ok := s.commandText(ev)
+		if !ok {
+			s.tracef("message %s in %s does not mention the bot where commands are expected", ev.TimeStamp, ev.Channel)
+		} else if s.executeBatch(botCtx, response, text) || s.executeCommand(botCtx, response, text) {
+			return
 		}
+	} else {
+		s.tracef("%s event %s in %s is not addressed to the bot", ev.Type, ev.TimeStamp, ev.Channel)
+	}
+
+	if s.executeAttachments(ctx, ev) {
+		return
 	}
 
-	if s.messageHandler != nil {
-		s.messageHandler(botCtx, response)
+	if messageHandler != nil {
+		messageHandler(botCtx, response)
+		return
+	}
+
+	if isAddressedToBot(ev) {
+		if err := s.recordUnrouted(ctx, ev); err != nil {
+			fmt.Printf("failed recording unrouted event: %v\n", err)
+		}
 	}
 }
 
 func newMessageEvent(evt interface{}, teamID string) *MessageEvent {
-	var me *MessageEvent
+	me := &MessageEvent{}
+	if !fillMessageEvent(me, evt, teamID) {
+		return nil
+	}
 
+	// Filter out other bots. At the very least this is needed for MessageEvent
+	// to prevent the bot from self-triggering and causing loops. However better
+	// logic should be in place to prevent repeated self-triggering / bot-storms
+	// if we want to enable this later.
+	//if me.IsBot() {
+	//	return nil
+	//}
+
+	return me
+}
+
+// fillMessageEvent sets the fields of the message event from the raw event, reporting whether it is a message
+func fillMessageEvent(me *MessageEvent, evt interface{}, teamID string) bool {
 	switch ev := evt.(type) {
 	case *slackevents.MessageEvent:
-		me = &MessageEvent{
+		*me = MessageEvent{
 			Channel:         ev.Channel,
 			User:            ev.User,
 			Text:            ev.Text,
@@ -393,7 +918,7 @@ func newMessageEvent(evt interface{}, teamID string) *MessageEvent {
 			TeamID:          teamID,
 		}
 	case *slackevents.AppMentionEvent:
-		me = &MessageEvent{
+		*me = MessageEvent{
 			Channel:         ev.Channel,
 			User:            ev.User,
 			Text:            ev.Text,
@@ -405,7 +930,7 @@ func newMessageEvent(evt interface{}, teamID string) *MessageEvent {
 			TeamID:          teamID,
 		}
 	case *slackevents.LinkSharedEvent:
-		me = &MessageEvent{
+		*me = MessageEvent{
 			Channel:         ev.Channel,
 			User:            ev.User,
 			Data:            evt,
@@ -414,15 +939,8 @@ func newMessageEvent(evt interface{}, teamID string) *MessageEvent {
 			ThreadTimeStamp: ev.ThreadTimeStamp,
 			TeamID:          teamID,
 		}
+	default:
+		return false
 	}
-
-	// Filter out other bots. At the very least this is needed for MessageEvent
-	// to prevent the bot from self-triggering and causing loops. However better
-	// logic should be in place to prevent repeated self-triggering / bot-storms
-	// if we want to enable this later.
-	//if me.IsBot() {
-	//	return nil
-	//}
-
-	return me
+	return true
 }
@@ -0,0 +1,195 @@
+package slackmock
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	defaultExpectTimeout = 2 * time.Second
+	defaultQuietPeriod   = 100 * time.Millisecond
+	diffSame             = "  "
+	diffWant             = "- "
+	diffGot              = "+ "
+	noneReceived         = "  (nothing)"
+	messageFormat        = "%s to %s"
+	ephemeralFormat      = "%s to %s for %s"
+)
+
+// The expectations wait up to ExpectTimeout for the bot to act, and consume what they matched, so
+// that the next expectation only looks at what came after. They fail the test with what the bot did
+// instead, as a diff against what was expected.
+
+// ExpectReply expects the bot to send a message whose text is exactly the text
+func (s *Server) ExpectReply(t testing.TB, text string) *Message {
+	t.Helper()
+
+	message, candidates := s.expectMessage(func(m *Message) bool { return m.Text == text })
+	if message == nil {
+		t.Fatalf("expected a reply %q, got:\n%s", text, diffCandidates(text, candidates))
+	}
+	return message
+}
+
+// ExpectReplyContains expects the bot to send a message whose text contains the text
+func (s *Server) ExpectReplyContains(t testing.TB, text string) *Message {
+	t.Helper()
+
+	message, candidates := s.expectMessage(func(m *Message) bool { return strings.Contains(m.Text, text) })
+	if message == nil {
+		t.Fatalf("expected a reply containing %q, got:\n%s", text, diffCandidates(text, candidates))
+	}
+	return message
+}
+
+// ExpectEphemeralTo expects the bot to send an ephemeral message only the user sees
+func (s *Server) ExpectEphemeralTo(t testing.TB, userID string) *Message {
+	t.Helper()
+
+	message, candidates := s.expectMessage(func(m *Message) bool { return m.Ephemeral() && m.User == userID })
+	if message == nil {
+		t.Fatalf("expected an ephemeral message to %s, got:\n%s", userID, listCandidates(candidates))
+	}
+	return message
+}
+
+// ExpectModalOpened expects the bot to open or push a modal with the callback ID
+func (s *Server) ExpectModalOpened(t testing.TB, callbackID string) *View {
+	t.Helper()
+
+	var view *View
+	var candidates []*View
+	_ = waitFor(s.ExpectTimeout, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		candidates = s.views[s.viewCursor:]
+		for i, v := range candidates {
+			if v.Method != methodViewUpdate && v.CallbackID == callbackID {
+				view, s.viewCursor = v, s.viewCursor+i+1
+				return true
+			}
+		}
+		return false
+	})
+
+	if view == nil {
+		lines := []string{}
+		for _, v := range candidates {
+			lines = append(lines, diffGot+v.Method+" "+v.CallbackID)
+		}
+		if len(lines) == 0 {
+			lines = append(lines, noneReceived)
+		}
+		t.Fatalf("expected a modal %q to open, got:\n%s", callbackID, strings.Join(lines, "\n"))
+	}
+	return view
+}
+
+// ExpectNoReply expects the bot not to send any message during QuietPeriod
+func (s *Server) ExpectNoReply(t testing.TB) {
+	t.Helper()
+
+	time.Sleep(s.QuietPeriod)
+
+	s.mutex.Lock()
+	unexpected := s.messages[s.messageCursor:]
+	s.messageCursor = len(s.messages)
+	s.mutex.Unlock()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("expected no reply, got:\n%s", listCandidates(unexpected))
+	}
+}
+
+// expectMessage waits for a message after the cursor to match, returning it or those that did not match
+func (s *Server) expectMessage(match func(m *Message) bool) (*Message, []*Message) {
+	var message *Message
+	var candidates []*Message
+	_ = waitFor(s.ExpectTimeout, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		candidates = s.messages[s.messageCursor:]
+		for i, m := range candidates {
+			if match(m) {
+				message, s.messageCursor = m, s.messageCursor+i+1
+				return true
+			}
+		}
+		return false
+	})
+	return message, candidates
+}
+
+func describeMessage(m *Message) string {
+	if m.Ephemeral() {
+		return fmt.Sprintf(ephemeralFormat, m.Method, m.Channel, m.User)
+	}
+	return fmt.Sprintf(messageFormat, m.Method, m.Channel)
+}
+
+func listCandidates(candidates []*Message) string {
+	if len(candidates) == 0 {
+		return noneReceived
+	}
+
+	lines := []string{}
+	for _, m := range candidates {
+		lines = append(lines, fmt.Sprintf("%s%s: %q", diffGot, describeMessage(m), m.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func diffCandidates(want string, candidates []*Message) string {
+	if len(candidates) == 0 {
+		return noneReceived
+	}
+
+	diffs := []string{}
+	for _, m := range candidates {
+		diffs = append(diffs, describeMessage(m)+":\n"+diffLines(want, m.Text))
+	}
+	return strings.Join(diffs, "\n")
+}
+
+// diffLines compares the texts line by line, marking the lines only wanted with - and only got with +
+func diffLines(want string, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffSame+a[i])
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lengths[i+1][j] >= lengths[i][j+1]):
+			lines = append(lines, diffWant+a[i])
+			i++
+		default:
+			lines = append(lines, diffGot+b[j])
+			j++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,227 @@
+package slackmock
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	loadChannelFormat   = "D%08d"
+	loadUserID          = "U99999999"
+	defaultLoadTimeout  = 10 * time.Second
+	percentile50        = 0.50
+	percentile90        = 0.90
+	percentile99        = 0.99
+	loadReportFormat    = "sent %d events in %s, %.1f acked per second\nacked %d, replied %d, dropped %d\nack latency: %s\nreply latency: %s\ngoroutines: %+d, heap: %+d bytes"
+	latencyReportFormat = "p50 %s, p90 %s, p99 %s, max %s"
+)
+
+// LoadEventType is the kind of envelope a load test sends
+type LoadEventType string
+
+const (
+	// LoadMessage sends a direct message with Text
+	LoadMessage LoadEventType = "message"
+	// LoadMention sends a message mentioning the bot with Text
+	LoadMention LoadEventType = "mention"
+	// LoadSlashCommand sends the Command with Text
+	LoadSlashCommand LoadEventType = "slash_command"
+	// LoadInteraction sends the Interaction
+	LoadInteraction LoadEventType = "interaction"
+)
+
+var (
+	// ErrInvalidLoad is returned when a load test has no events to send
+	ErrInvalidLoad = errors.New("a load test needs events and a count")
+)
+
+// LoadEvent is an envelope sent by a load test. Each one is sent in a channel of its own, so that
+// the replies of the bot can be told apart.
+type LoadEvent struct {
+	Type        LoadEventType
+	Text        string
+	Command     string
+	Interaction slack.InteractionCallback
+}
+
+// LoadDefinition structure contains the definition of a load test. Count envelopes are sent, going
+// through Events in turn so that recorded traffic can be replayed, at Rate per second or as fast as
+// possible when zero. The test then waits up to Timeout for the bot to acknowledge and answer them.
+type LoadDefinition struct {
+	Events  []LoadEvent
+	Count   int
+	Rate    float64
+	Timeout time.Duration
+}
+
+// Latency is the distribution of the latencies measured by a load test
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+func (l Latency) String() string {
+	return fmt.Sprintf(latencyReportFormat, l.P50, l.P90, l.P99, l.Max)
+}
+
+// LoadReport is the outcome of a load test. Dropped events were not acknowledged in time, and
+// replied ones got at least one message from the bot in their channel.
+type LoadReport struct {
+	Sent            int
+	Acked           int
+	Replied         int
+	Dropped         int
+	Duration        time.Duration
+	Throughput      float64
+	AckLatency      Latency
+	ReplyLatency    Latency
+	GoroutineGrowth int
+	HeapGrowth      int64
+}
+
+func (r *LoadReport) String() string {
+	return fmt.Sprintf(loadReportFormat, r.Sent, r.Duration.Round(time.Millisecond), r.Throughput,
+		r.Acked, r.Replied, r.Dropped, r.AckLatency, r.ReplyLatency, r.GoroutineGrowth, r.HeapGrowth)
+}
+
+// loadSent is an envelope sent by a load test
+type loadSent struct {
+	envelopeID string
+	channelID  string
+	at         time.Time
+}
+
+// Load sends the synthetic traffic to the connected bot and reports how it coped, including how
+// many goroutines and how much heap it left behind, the bot running in the same process
+func (s *Server) Load(definition *LoadDefinition) (*LoadReport, error) {
+	if definition == nil || len(definition.Events) == 0 || definition.Count <= 0 {
+		return nil, ErrInvalidLoad
+	}
+
+	timeout := definition.Timeout
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+
+	goroutines, heap := runtimeUsage()
+	started := time.Now()
+
+	var interval time.Duration
+	if definition.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / definition.Rate)
+	}
+
+	sent := make([]*loadSent, 0, definition.Count)
+	for i := 0; i < definition.Count; i++ {
+		if interval > 0 {
+			time.Sleep(time.Until(started.Add(time.Duration(i) * interval)))
+		}
+
+		channelID := fmt.Sprintf(loadChannelFormat, i)
+		at := time.Now()
+		envelopeID, err := s.sendLoadEvent(definition.Events[i%len(definition.Events)], channelID)
+		if err != nil {
+			return nil, err
+		}
+		sent = append(sent, &loadSent{envelopeID: envelopeID, channelID: channelID, at: at})
+	}
+
+	_ = waitFor(timeout, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for _, envelope := range sent {
+			if _, ok := s.ackTimes[envelope.envelopeID]; !ok {
+				return false
+			}
+		}
+		return true
+	})
+	duration := time.Since(started)
+
+	// Handlers finish after the ack, so their goroutines are given a moment to exit
+	time.Sleep(s.QuietPeriod)
+	goroutinesAfter, heapAfter := runtimeUsage()
+
+	report := s.loadReport(sent)
+	report.Duration = duration
+	report.Throughput = float64(report.Acked) / duration.Seconds()
+	report.GoroutineGrowth = goroutinesAfter - goroutines
+	report.HeapGrowth = int64(heapAfter) - int64(heap)
+	return report, nil
+}
+
+func (s *Server) sendLoadEvent(event LoadEvent, channelID string) (string, error) {
+	switch event.Type {
+	case LoadMention:
+		return s.SendMention(channelID, loadUserID, event.Text)
+	case LoadSlashCommand:
+		return s.SendSlashCommand(slack.SlashCommand{Command: event.Command, Text: event.Text, ChannelID: channelID, UserID: loadUserID})
+	case LoadInteraction:
+		callback := event.Interaction
+		callback.Channel.ID = channelID
+		if len(callback.User.ID) == 0 {
+			callback.User.ID = loadUserID
+		}
+		return s.SendInteraction(callback)
+	}
+	return s.SendMessage(channelID, loadUserID, event.Text)
+}
+
+func (s *Server) loadReport(sent []*loadSent) *LoadReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	replies := make(map[string]time.Time)
+	for _, message := range s.messages {
+		if _, ok := replies[message.Channel]; !ok {
+			replies[message.Channel] = message.Received
+		}
+	}
+
+	report := &LoadReport{Sent: len(sent)}
+	acks, answers := []time.Duration{}, []time.Duration{}
+	for _, envelope := range sent {
+		if acked, ok := s.ackTimes[envelope.envelopeID]; ok {
+			report.Acked++
+			acks = append(acks, acked.Sub(envelope.at))
+		} else {
+			report.Dropped++
+		}
+
+		if replied, ok := replies[envelope.channelID]; ok {
+			report.Replied++
+			answers = append(answers, replied.Sub(envelope.at))
+		}
+	}
+
+	report.AckLatency = distribution(acks)
+	report.ReplyLatency = distribution(answers)
+	return report
+}
+
+func distribution(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	at := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	return Latency{P50: at(percentile50), P90: at(percentile90), P99: at(percentile99), Max: latencies[len(latencies)-1]}
+}
+
+func runtimeUsage() (int, uint64) {
+	runtime.GC()
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	return runtime.NumGoroutine(), stats.HeapAlloc
+}
@@ -0,0 +1,350 @@
+// Package slackmock runs an in-process server emulating enough of the Slack Web API and socket mode
+// to run integration tests of a slacker bot in CI, without real tokens
+package slackmock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shomali11/slacker"
+)
+
+const (
+	apiPath          = "/api/"
+	socketPath       = "/socket"
+	socketURLFormat  = "ws://%s" + socketPath
+	timestampFormat  = "%d.%06d"
+	viewIDFormat     = "V%06d"
+	contentTypeJSON  = "application/json"
+	headerType       = "Content-Type"
+	pollInterval     = 10 * time.Millisecond
+	pingInterval     = 10 * time.Second
+	methodAuthTest   = "auth.test"
+	methodOpen       = "apps.connections.open"
+	methodPost       = "chat.postMessage"
+	methodEphemeral  = "chat.postEphemeral"
+	methodUpdate     = "chat.update"
+	methodViewsOpen  = "views.open"
+	methodViewsPush  = "views.push"
+	methodViewUpdate = "views.update"
+
+	// DefaultTeamID is the workspace the server pretends to be
+	DefaultTeamID = "T00000001"
+	// DefaultBotID is the ID of the bot authenticated by auth.test
+	DefaultBotID = "B00000001"
+	// DefaultBotUserID is the user of the bot authenticated by auth.test
+	DefaultBotUserID = "U00000001"
+)
+
+var (
+	// ErrTimeout is returned when what was waited for did not happen in time
+	ErrTimeout = errors.New("timed out waiting")
+	// ErrNotConnected is returned when sending an event before the bot connected with socket mode
+	ErrNotConnected = errors.New("the bot is not connected")
+)
+
+// Call is a request the bot sent to the Web API. Values holds form parameters, and Body the JSON
+// body of the methods posting JSON, such as views.open.
+type Call struct {
+	Method string
+	Values url.Values
+	Body   []byte
+}
+
+// Message is a message the bot posted, updated or sent ephemerally
+type Message struct {
+	Method    string
+	Channel   string
+	User      string
+	Text      string
+	ThreadTS  string
+	Timestamp string
+	Blocks    string
+	Received  time.Time
+}
+
+// Ephemeral reports whether the message was only shown to User
+func (m *Message) Ephemeral() bool {
+	return m.Method == methodEphemeral
+}
+
+// View is a modal the bot opened, pushed or updated
+type View struct {
+	Method     string
+	TriggerID  string
+	CallbackID string
+	View       json.RawMessage
+}
+
+// A MethodHandler answers a Web API call, its result being encoded as the JSON response
+type MethodHandler func(call *Call) interface{}
+
+// Server emulates the Slack Web API and socket mode. Methods it does not emulate answer with
+// {"ok": true}, or with the result of the handler set with Handle.
+type Server struct {
+	TeamID    string
+	BotID     string
+	BotUserID string
+
+	// ExpectTimeout is how long expectations wait for the bot, and QuietPeriod how long ExpectNoReply does
+	ExpectTimeout time.Duration
+	QuietPeriod   time.Duration
+
+	httpServer *httptest.Server
+	upgrader   websocket.Upgrader
+
+	mutex         sync.Mutex
+	handlers      map[string]MethodHandler
+	calls         []*Call
+	messages      []*Message
+	views         []*View
+	messageCursor int
+	viewCursor    int
+	acks          map[string]json.RawMessage
+	ackTimes      map[string]time.Time
+	conn          *websocket.Conn
+	connMutex     sync.Mutex
+	counter       int64
+	closed        chan struct{}
+}
+
+// NewServer starts a server listening on a local port
+func NewServer() *Server {
+	s := &Server{
+		TeamID:        DefaultTeamID,
+		BotID:         DefaultBotID,
+		BotUserID:     DefaultBotUserID,
+		ExpectTimeout: defaultExpectTimeout,
+		QuietPeriod:   defaultQuietPeriod,
+		upgrader:      websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		handlers:      make(map[string]MethodHandler),
+		acks:          make(map[string]json.RawMessage),
+		ackTimes:      make(map[string]time.Time),
+		closed:        make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath, s.handleAPI)
+	mux.HandleFunc(socketPath, s.handleSocket)
+	s.httpServer = httptest.NewServer(mux)
+	return s
+}
+
+// APIURL returns the URL of the Web API, to pass to slacker.WithAPIURL
+func (s *Server) APIURL() string {
+	return s.httpServer.URL + apiPath
+}
+
+// ClientOptions returns the options pointing a slacker client at the server
+func (s *Server) ClientOptions() []slacker.ClientOption {
+	return []slacker.ClientOption{slacker.WithAPIURL(s.APIURL())}
+}
+
+// Close disconnects the bot and stops the server
+func (s *Server) Close() {
+	close(s.closed)
+
+	s.connMutex.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.connMutex.Unlock()
+
+	s.httpServer.Close()
+}
+
+// Handle answers the Web API method with the handler instead of the emulation
+func (s *Server) Handle(method string, handler MethodHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.handlers[method] = handler
+}
+
+// Calls returns the calls to the method, or to every method when empty
+func (s *Server) Calls(method string) []*Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	calls := []*Call{}
+	for _, call := range s.calls {
+		if len(method) == 0 || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Messages returns the messages the bot posted, updated or sent ephemerally, in order
+func (s *Server) Messages() []*Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*Message{}, s.messages...)
+}
+
+// Views returns the modals the bot opened, pushed or updated, in order
+func (s *Server) Views() []*View {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*View{}, s.views...)
+}
+
+// Reset forgets the calls, messages, views and acks recorded so far
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.calls, s.messages, s.views = nil, nil, nil
+	s.messageCursor, s.viewCursor = 0, 0
+	s.acks = make(map[string]json.RawMessage)
+	s.ackTimes = make(map[string]time.Time)
+}
+
+// WaitForMessages waits until the bot sent at least count messages, and returns them
+func (s *Server) WaitForMessages(count int, timeout time.Duration) ([]*Message, error) {
+	var messages []*Message
+	err := waitFor(timeout, func() bool {
+		messages = s.Messages()
+		return len(messages) >= count
+	})
+	return messages, err
+}
+
+// WaitForViews waits until the bot opened, pushed or updated at least count modals, and returns them
+func (s *Server) WaitForViews(count int, timeout time.Duration) ([]*View, error) {
+	var views []*View
+	err := waitFor(timeout, func() bool {
+		views = s.Views()
+		return len(views) >= count
+	})
+	return views, err
+}
+
+// waitFor polls the condition until it holds or the timeout passes
+func waitFor(timeout time.Duration, condition func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// nextTimestamp returns a message timestamp, unique and increasing
+func (s *Server) nextTimestamp() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.counter++
+	return fmt.Sprintf(timestampFormat, time.Now().Unix(), s.counter%1000000)
+}
+
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	call := &Call{Method: strings.TrimPrefix(r.URL.Path, apiPath), Values: url.Values{}}
+	if strings.HasPrefix(r.Header.Get(headerType), contentTypeJSON) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		call.Body = body
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		call.Values = r.Form
+	}
+
+	s.mutex.Lock()
+	s.calls = append(s.calls, call)
+	handler, ok := s.handlers[call.Method]
+	s.mutex.Unlock()
+
+	var result interface{}
+	if ok {
+		result = handler(call)
+	} else {
+		result = s.emulate(call)
+	}
+
+	w.Header().Set(headerType, contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// emulate answers the call like Slack would, recording the messages and views
+func (s *Server) emulate(call *Call) interface{} {
+	switch call.Method {
+	case methodAuthTest:
+		return map[string]interface{}{"ok": true, "team_id": s.TeamID, "user_id": s.BotUserID, "bot_id": s.BotID}
+	case methodOpen:
+		return map[string]interface{}{"ok": true, "url": fmt.Sprintf(socketURLFormat, s.httpServer.Listener.Addr())}
+	case methodPost, methodEphemeral, methodUpdate:
+		return s.recordMessage(call)
+	case methodViewsOpen, methodViewsPush, methodViewUpdate:
+		return s.recordView(call)
+	}
+	return map[string]interface{}{"ok": true}
+}
+
+func (s *Server) recordMessage(call *Call) interface{} {
+	message := &Message{
+		Method:    call.Method,
+		Channel:   call.Values.Get("channel"),
+		User:      call.Values.Get("user"),
+		Text:      call.Values.Get("text"),
+		ThreadTS:  call.Values.Get("thread_ts"),
+		Timestamp: call.Values.Get("ts"),
+		Blocks:    call.Values.Get("blocks"),
+		Received:  time.Now(),
+	}
+	if len(message.Timestamp) == 0 {
+		message.Timestamp = s.nextTimestamp()
+	}
+
+	s.mutex.Lock()
+	s.messages = append(s.messages, message)
+	s.mutex.Unlock()
+
+	if call.Method == methodEphemeral {
+		return map[string]interface{}{"ok": true, "message_ts": message.Timestamp}
+	}
+	return map[string]interface{}{"ok": true, "channel": message.Channel, "ts": message.Timestamp, "text": message.Text}
+}
+
+func (s *Server) recordView(call *Call) interface{} {
+	request := struct {
+		TriggerID string          `json:"trigger_id"`
+		View      json.RawMessage `json:"view"`
+	}{}
+	if err := json.Unmarshal(call.Body, &request); err != nil {
+		return map[string]interface{}{"ok": false, "error": err.Error()}
+	}
+
+	modal := struct {
+		CallbackID string `json:"callback_id"`
+	}{}
+	_ = json.Unmarshal(request.View, &modal)
+
+	s.mutex.Lock()
+	s.views = append(s.views, &View{Method: call.Method, TriggerID: request.TriggerID, CallbackID: modal.CallbackID, View: request.View})
+	id := fmt.Sprintf(viewIDFormat, len(s.views))
+	s.mutex.Unlock()
+
+	return map[string]interface{}{"ok": true, "view": map[string]interface{}{"id": id, "callback_id": modal.CallbackID}}
+}
@@ -0,0 +1,166 @@
+package slackmock_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shomali11/slacker"
+	"github.com/shomali11/slacker/slackmock"
+	"github.com/slack-go/slack"
+)
+
+const connectTimeout = 5 * time.Second
+
+// startBot connects a bot configured by register to the server, until the test ends
+func startBot(t *testing.T, server *slackmock.Server, options []slacker.ClientOption, register func(bot *slacker.Slacker)) {
+	t.Helper()
+
+	bot, err := slacker.NewClient("xoxb-test", "xapp-test", append(server.ClientOptions(), options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	register(bot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go bot.Listen(ctx)
+
+	if err := server.WaitForConnection(connectTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirectMessageReply(t *testing.T) {
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	startBot(t, server, nil, func(bot *slacker.Slacker) {
+		bot.Command("ping", &slacker.CommandDefinition{
+			Handler: func(botCtx slacker.BotContext, request slacker.Request, response slacker.ResponseWriter) {
+				response.Reply("pong")
+			},
+		})
+	})
+
+	if _, err := server.SendMessage("D1", "U1", "ping"); err != nil {
+		t.Fatal(err)
+	}
+	reply := server.ExpectReply(t, "pong")
+	if reply.Channel != "D1" {
+		t.Fatalf("replied in %s, want the direct message channel", reply.Channel)
+	}
+}
+
+func TestMentionParametersAndThread(t *testing.T) {
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	startBot(t, server, nil, func(bot *slacker.Slacker) {
+		bot.Command("deploy <service>", &slacker.CommandDefinition{
+			Handler: func(botCtx slacker.BotContext, request slacker.Request, response slacker.ResponseWriter) {
+				header := slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Deployed "+request.Param("service"), false, false))
+				response.ReplyBlocks([]slack.Block{header}, slacker.WithThreadReply(true))
+			},
+		})
+	})
+
+	if _, err := server.SendMention("C1", "U1", "deploy api"); err != nil {
+		t.Fatal(err)
+	}
+	reply := server.ExpectReply(t, "Deployed api")
+	if len(reply.ThreadTS) == 0 {
+		t.Fatal("replied in the channel, want the thread of the mention")
+	}
+	if !strings.Contains(reply.Blocks, `"type":"header"`) {
+		t.Fatalf("got blocks %s, want the header", reply.Blocks)
+	}
+}
+
+func TestSlashCommand(t *testing.T) {
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	startBot(t, server, nil, func(bot *slacker.Slacker) {
+		bot.SlashCommand("/echo <text>", &slacker.CommandDefinition{
+			Handler: func(botCtx slacker.BotContext, request slacker.Request, response slacker.ResponseWriter) {
+				response.Reply(request.Param("text"))
+			},
+		})
+	})
+
+	envelopeID, err := server.SendSlashCommand(slack.SlashCommand{Command: "/echo", Text: "hello", ChannelID: "C1", UserID: "U1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.WaitForAck(envelopeID, connectTimeout); err != nil {
+		t.Fatalf("the slash command was not acknowledged: %v", err)
+	}
+	server.ExpectReply(t, "hello")
+}
+
+func TestModalValidationErrors(t *testing.T) {
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	startBot(t, server, nil, func(bot *slacker.Slacker) {
+		bot.ViewSubmission("signup", func(botCtx slacker.BotContext, response slacker.ResponseWriter, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error) {
+			return nil, slacker.ValidationErrors{"email": "Enter a valid email"}
+		})
+	})
+
+	callback := slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, User: slack.User{ID: "U1"}}
+	callback.View.CallbackID = "signup"
+	envelopeID, err := server.SendInteraction(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := server.WaitForAck(envelopeID, connectTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload), `"response_action":"errors"`) || !strings.Contains(string(payload), "Enter a valid email") {
+		t.Fatalf("got ack %s, want the validation errors", payload)
+	}
+}
+
+func TestEditedCommandRunsOnce(t *testing.T) {
+	server := slackmock.NewServer()
+	defer server.Close()
+
+	startBot(t, server, []slacker.ClientOption{slacker.WithEditedCommands(true)}, func(bot *slacker.Slacker) {
+		bot.Command("ping", &slacker.CommandDefinition{
+			Handler: func(botCtx slacker.BotContext, request slacker.Request, response slacker.ResponseWriter) {
+				response.Reply("pong")
+			},
+		})
+	})
+
+	const timestamp = "1700000000.000100"
+	_, err := server.SendEvent(map[string]interface{}{
+		"type":         "message",
+		"channel":      "D1",
+		"channel_type": "im",
+		"user":         "U1",
+		"text":         "ping",
+		"ts":           timestamp,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.ExpectReply(t, "pong")
+
+	// The edit changes the text, but the message already ran its command
+	if _, err := server.SendEdit("D1", "U1", timestamp, "ping", "ping "); err != nil {
+		t.Fatal(err)
+	}
+	server.ExpectNoReply(t)
+
+	// Fixing a message that ran no command runs it
+	if _, err := server.SendEdit("D1", "U1", "1700000000.000200", "pnig", "ping "); err != nil {
+		t.Fatal(err)
+	}
+	server.ExpectReply(t, "pong")
+}
@@ -0,0 +1,213 @@
+package slackmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/slack-go/slack"
+)
+
+const (
+	envelopeIDFormat    = "envelope-%d"
+	mentionFormat       = "<@%s> %s"
+	typeHello           = "hello"
+	typeEventsAPI       = "events_api"
+	typeSlashCommands   = "slash_commands"
+	typeInteractive     = "interactive"
+	typeEventCallback   = "event_callback"
+	eventMessage        = "message"
+	eventAppMention     = "app_mention"
+	subtypeChanged      = "message_changed"
+	channelTypeIM       = "im"
+	channelTypeChannel  = "channel"
+	directChannelMarker = "D"
+	writeTimeout        = 10 * time.Second
+)
+
+// envelope is a message sent to the bot over socket mode
+type envelope struct {
+	Type                   string      `json:"type"`
+	EnvelopeID             string      `json:"envelope_id,omitempty"`
+	Payload                interface{} `json:"payload,omitempty"`
+	AcceptsResponsePayload bool        `json:"accepts_response_payload"`
+}
+
+// ack is the acknowledgment of an envelope by the bot
+type ack struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+func (s *Server) handleSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.connMutex.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = conn
+	err = s.write(conn, &envelope{Type: typeHello})
+	s.connMutex.Unlock()
+	if err != nil {
+		return
+	}
+
+	go s.ping(conn)
+	for {
+		received := &ack{}
+		if err := conn.ReadJSON(received); err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.acks[received.EnvelopeID] = received.Payload
+		s.ackTimes[received.EnvelopeID] = time.Now()
+		s.mutex.Unlock()
+	}
+}
+
+// ping keeps the connection alive, socket mode clients reconnecting when they are not pinged
+func (s *Server) ping(conn *websocket.Conn) {
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-time.After(pingInterval):
+		}
+
+		if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+			return
+		}
+	}
+}
+
+// write sends the envelope, it is called with the connection lock held since writes cannot be concurrent
+func (s *Server) write(conn *websocket.Conn, message *envelope) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(message)
+}
+
+// send delivers the payload to the bot, returning the ID of its envelope
+func (s *Server) send(envelopeType string, payload interface{}) (string, error) {
+	s.mutex.Lock()
+	s.counter++
+	id := fmt.Sprintf(envelopeIDFormat, s.counter)
+	s.mutex.Unlock()
+
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+
+	if s.conn == nil {
+		return id, ErrNotConnected
+	}
+	return id, s.write(s.conn, &envelope{Type: envelopeType, EnvelopeID: id, Payload: payload, AcceptsResponsePayload: envelopeType == typeInteractive})
+}
+
+// WaitForConnection waits until the bot connected with socket mode
+func (s *Server) WaitForConnection(timeout time.Duration) error {
+	return waitFor(timeout, func() bool {
+		s.connMutex.Lock()
+		defer s.connMutex.Unlock()
+
+		return s.conn != nil
+	})
+}
+
+// WaitForAck waits until the bot acknowledged the envelope, and returns the payload of the ack
+func (s *Server) WaitForAck(envelopeID string, timeout time.Duration) (json.RawMessage, error) {
+	var payload json.RawMessage
+	err := waitFor(timeout, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		var ok bool
+		payload, ok = s.acks[envelopeID]
+		return ok
+	})
+	return payload, err
+}
+
+// SendEvent delivers an Events API event, such as a reaction_added event, to the bot
+func (s *Server) SendEvent(event map[string]interface{}) (string, error) {
+	return s.send(typeEventsAPI, map[string]interface{}{
+		"type":    typeEventCallback,
+		"team_id": s.TeamID,
+		"event":   event,
+	})
+}
+
+// SendMessage delivers a message from the user, which is addressed to the bot in a direct message
+// channel, whose ID starts with D
+func (s *Server) SendMessage(channelID string, userID string, text string) (string, error) {
+	channelType := channelTypeChannel
+	if strings.HasPrefix(channelID, directChannelMarker) {
+		channelType = channelTypeIM
+	}
+	return s.SendEvent(s.messageEvent(eventMessage, channelID, userID, text, channelType))
+}
+
+// SendMention delivers a message from the user mentioning the bot in the channel
+func (s *Server) SendMention(channelID string, userID string, text string) (string, error) {
+	text = fmt.Sprintf(mentionFormat, s.BotUserID, text)
+	return s.SendEvent(s.messageEvent(eventAppMention, channelID, userID, text, channelTypeChannel))
+}
+
+// SendEdit delivers the edit by the user of the message with the timestamp, from its previous text
+func (s *Server) SendEdit(channelID string, userID string, timestamp string, previous string, text string) (string, error) {
+	channelType := channelTypeChannel
+	if strings.HasPrefix(channelID, directChannelMarker) {
+		channelType = channelTypeIM
+	}
+
+	message := s.messageEvent(eventMessage, channelID, userID, text, channelType)
+	message["ts"] = timestamp
+	previousMessage := s.messageEvent(eventMessage, channelID, userID, previous, channelType)
+	previousMessage["ts"] = timestamp
+	return s.SendEvent(map[string]interface{}{
+		"type":             eventMessage,
+		"subtype":          subtypeChanged,
+		"channel":          channelID,
+		"channel_type":     channelType,
+		"hidden":           true,
+		"ts":               s.nextTimestamp(),
+		"message":          message,
+		"previous_message": previousMessage,
+	})
+}
+
+func (s *Server) messageEvent(eventType string, channelID string, userID string, text string, channelType string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":         eventType,
+		"channel":      channelID,
+		"channel_type": channelType,
+		"user":         userID,
+		"team":         s.TeamID,
+		"text":         text,
+		"ts":           s.nextTimestamp(),
+	}
+}
+
+// SendSlashCommand delivers a slash command, its team defaulting to the server's
+func (s *Server) SendSlashCommand(command slack.SlashCommand) (string, error) {
+	if len(command.TeamID) == 0 {
+		command.TeamID = s.TeamID
+	}
+	return s.send(typeSlashCommands, command)
+}
+
+// SendInteraction delivers an interaction, such as a button click or a modal submission
+func (s *Server) SendInteraction(callback slack.InteractionCallback) (string, error) {
+	if len(callback.Team.ID) == 0 {
+		callback.Team.ID = s.TeamID
+	}
+	return s.send(typeInteractive, callback)
+}
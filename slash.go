@@ -0,0 +1,99 @@
+package slacker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	slashPrefix      = "/"
+	slashUsageFormat = "Usage: %s"
+	slashUsageJoin   = ", "
+	slashUsageQuote  = "`%s`"
+)
+
+// slashCommand is a command run by a slash command, matching the text typed after its name
+type slashCommand struct {
+	BotCommand
+	name    string
+	usage   string
+	pattern BotCommand
+}
+
+// newSlashCommand splits the usage into the slash command's name and the usage of the text after it
+func newSlashCommand(usage string, definition *CommandDefinition) *slashCommand {
+	usage = strings.TrimSpace(usage)
+	name, rest := usage, empty
+	if i := strings.Index(usage, space); i >= 0 {
+		name, rest = usage[:i], strings.TrimSpace(usage[i+1:])
+	}
+	if !strings.HasPrefix(name, slashPrefix) {
+		name = slashPrefix + name
+	}
+
+	command := &slashCommand{
+		BotCommand: NewBotCommand(strings.TrimSpace(name+space+rest), definition),
+		name:       strings.ToLower(name),
+		usage:      rest,
+	}
+	if len(rest) > 0 {
+		command.pattern = &leadingCommand{BotCommand: NewBotCommand(rest, definition)}
+		if tokens := command.pattern.Tokenize(); len(tokens) > 0 && tokens[0].IsParameter() {
+			command.pattern = NewBotCommand(rest, definition)
+		}
+	}
+	return command
+}
+
+// Match matches the text typed after the name. Without a usage after it, the command takes any text.
+func (c *slashCommand) Match(text string) (*proper.Properties, bool) {
+	if c.pattern == nil {
+		return proper.NewProperties(map[string]string{}), true
+	}
+	return c.pattern.Match(text)
+}
+
+// SlashCommand defines a command run by a slash command such as `/deploy`, matched on its name
+// rather than the text of messages. The name can be followed by the usage of the text typed after
+// it, such as `/deploy <env> <version?>`, several usages of a name being tried in the order they
+// were added. Slash commands without any of them run the message commands their text matches.
+func (s *Slacker) SlashCommand(usage string, definition *CommandDefinition) error {
+	command := newSlashCommand(usage, definition)
+	return s.register(func() {
+		slashCommands := make([]*slashCommand, len(s.slashCommands), len(s.slashCommands)+1)
+		copy(slashCommands, s.slashCommands)
+		s.slashCommands = append(slashCommands, command)
+	})
+}
+
+// executeSlashCommand runs the command added with SlashCommand for the name whose usage the text
+// matches, and reports whether the name has any
+func (s *Slacker) executeSlashCommand(botCtx BotContext, response ResponseWriter, name string, text string) bool {
+	s.mutex.RLock()
+	slashCommands := s.slashCommands
+	s.mutex.RUnlock()
+
+	name = strings.ToLower(name)
+	usages := []string{}
+	for _, cmd := range slashCommands {
+		if cmd.name != name {
+			continue
+		}
+
+		if parameters, isMatch := cmd.Match(text); isMatch {
+			s.tracef("`%s` matched %q", cmd.Usage(), text)
+			s.dispatchCommand(botCtx, response, cmd, parameters)
+			return true
+		}
+		s.tracef("`%s` did not match %q", cmd.Usage(), text)
+		usages = append(usages, fmt.Sprintf(slashUsageQuote, cmd.Usage()))
+	}
+
+	if len(usages) == 0 {
+		return false
+	}
+	response.ReportError(fmt.Errorf(slashUsageFormat, strings.Join(usages, slashUsageJoin)))
+	return true
+}
@@ -0,0 +1,246 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shomali11/commander"
+)
+
+const (
+	executionKeyPrefix       = "executions"
+	executionHistorySize     = 50
+	statusCommand            = "status <command...>"
+	statusDescription        = "Shows the recent runs of a command, such as `status deploy`"
+	statusParam              = "command"
+	statusSummaryFormat      = "*`%s`* over the last %d runs"
+	statusLastRunFormat      = "\nLast run: %s by <@%s> %s ago, taking %s"
+	statusStreakFormat       = "\nFailures in a row: %d"
+	statusLatencyFormat      = "\nAverage latency: %s"
+	statusSuccessFormat      = "\nSucceeded: %d of %d"
+	statusErrorFormat        = "\nLast error: %s"
+	statusNeverRun           = "*`%s`* has not run yet"
+	statusSucceeded          = ":white_check_mark: succeeded"
+	statusFailed             = ":x: failed"
+	statusSudo               = " with sudo"
+	statusErrorTextLength    = 200
+	executionLatencyRounding = time.Millisecond
+)
+
+var (
+	errStatusUnknownCommand = errors.New("There is no such command")
+)
+
+// ExecutionEntry is a past run of a command
+type ExecutionEntry struct {
+	Timestamp time.Time     `json:"timestamp"`
+	User      string        `json:"user"`
+	Channel   string        `json:"channel"`
+	Duration  time.Duration `json:"duration"`
+	Failed    bool          `json:"failed"`
+	Error     string        `json:"error"`
+
+	// Sudo reports whether the user had admin privileges granted by sudo
+	Sudo bool `json:"sudo"`
+}
+
+// CommandStatus summarizes the recent runs of a command
+type CommandStatus struct {
+	Usage          string
+	Executions     []*ExecutionEntry
+	FailureStreak  int
+	Failures       int
+	AverageLatency time.Duration
+
+	// Circuit is the state of the command's CircuitBreaker, empty without one
+	Circuit        CircuitState
+	CircuitProbeAt time.Time
+}
+
+// LastRun returns the most recent run, or nil when the command has not run yet
+func (c *CommandStatus) LastRun() *ExecutionEntry {
+	if len(c.Executions) == 0 {
+		return nil
+	}
+	return c.Executions[0]
+}
+
+// outcomeResponse remembers the last error reported while a command runs
+type outcomeResponse struct {
+	ResponseWriter
+	mutex sync.Mutex
+	err   error
+}
+
+func (r *outcomeResponse) ReportError(err error, options ...ReportErrorOption) {
+	r.mutex.Lock()
+	r.err = err
+	r.mutex.Unlock()
+
+	r.ResponseWriter.ReportError(err, options...)
+}
+
+func (r *outcomeResponse) failure() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.err
+}
+
+// withOutcome tracks whether the command fails when the status command or its circuit breaker needs it
+func (s *Slacker) withOutcome(cmd BotCommand, response ResponseWriter) (ResponseWriter, *outcomeResponse) {
+	if !s.statusCommand && cmd.Definition().CircuitBreaker == nil {
+		return response, nil
+	}
+	outcome := &outcomeResponse{ResponseWriter: response}
+	return outcome, outcome
+}
+
+// recordExecution adds the run to the executions of the command, a run being a failure when it reported an error
+func (s *Slacker) recordExecution(botCtx BotContext, cmd BotCommand, outcome *outcomeResponse, started time.Time) {
+	if outcome == nil || !s.statusCommand {
+		return
+	}
+
+	ev := botCtx.Event()
+	entry := &ExecutionEntry{
+		Timestamp: started,
+		User:      ev.User,
+		Channel:   ev.Channel,
+		Duration:  since(s.clock, started),
+		Sudo:      s.Elevated(botCtx),
+	}
+	if err := outcome.failure(); err != nil {
+		entry.Failed = true
+		entry.Error = truncate(err.Error(), statusErrorTextLength)
+	}
+
+	// The handler's context may be cancelled already, while the run still needs recording
+	ctx := context.Background()
+	key := storeKey(executionKeyPrefix, ev.TeamID, cmd.Usage())
+	entries := []*ExecutionEntry{}
+	if _, err := s.loadValue(ctx, key, &entries); err != nil {
+		fmt.Printf("failed loading executions: %v\n", err)
+		return
+	}
+
+	entries = append([]*ExecutionEntry{entry}, entries...)
+	if len(entries) > executionHistorySize {
+		entries = entries[:executionHistorySize]
+	}
+	if err := s.saveValue(ctx, key, entries, 0); err != nil {
+		fmt.Printf("failed recording execution: %v\n", err)
+	}
+}
+
+// CommandStatus returns the summary of the command's recent runs in the workspace
+func (s *Slacker) CommandStatus(ctx context.Context, teamID string, usage string) (*CommandStatus, error) {
+	status := &CommandStatus{Usage: usage, Executions: []*ExecutionEntry{}}
+	if _, err := s.loadValue(ctx, storeKey(executionKeyPrefix, teamID, usage), &status.Executions); err != nil {
+		return nil, err
+	}
+
+	streak := true
+	var total time.Duration
+	for _, entry := range status.Executions {
+		total += entry.Duration
+		if entry.Failed {
+			status.Failures++
+		}
+		streak = streak && entry.Failed
+		if streak {
+			status.FailureStreak++
+		}
+	}
+	if len(status.Executions) > 0 {
+		status.AverageLatency = total / time.Duration(len(status.Executions))
+	}
+
+	if cmd := s.findCommand(usage); cmd != nil {
+		status.Circuit, status.CircuitProbeAt = s.breakers.state(cmd)
+	}
+	return status, nil
+}
+
+// appendStatusHandle adds the status command when it is enabled, it is called with the lock held
+func (s *Slacker) appendStatusHandle() {
+	if !s.statusCommand {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(statusCommand, &CommandDefinition{
+		Description:       statusDescription,
+		Example:           "status deploy",
+		Handler:           s.statusHandler,
+		AuthorizationFunc: s.setupAuthorization,
+		Scopes:            []string{scopeUsersRead},
+	}))
+}
+
+func (s *Slacker) statusHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	cmd := s.findStatusCommand(request.Param(statusParam))
+	if cmd == nil {
+		response.ReportError(errStatusUnknownCommand)
+		return
+	}
+
+	status, err := s.CommandStatus(botCtx.Context(), botCtx.Event().TeamID, cmd.Usage())
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	if err := response.Reply(formatStatus(status, s.clock)); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// findStatusCommand finds the command by its usage, or by the words of its usage that are not parameters
+func (s *Slacker) findStatusCommand(text string) BotCommand {
+	text = strings.Join(strings.Fields(text), space)
+	if cmd := s.findCommand(text); cmd != nil {
+		return cmd
+	}
+
+	for _, cmd := range s.commands() {
+		words := []string{}
+		for _, token := range commander.NewCommand(cmd.Usage()).Tokenize() {
+			if !token.IsParameter() {
+				words = append(words, token.Word)
+			}
+		}
+		if strings.EqualFold(strings.Join(words, space), text) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func formatStatus(status *CommandStatus, clock Clock) string {
+	last := status.LastRun()
+	if last == nil {
+		return fmt.Sprintf(statusNeverRun, status.Usage) + formatBreaker(status.Circuit, status.CircuitProbeAt, clock)
+	}
+
+	outcome := statusSucceeded
+	if last.Failed {
+		outcome = statusFailed
+	}
+	if last.Sudo {
+		outcome += statusSudo
+	}
+
+	text := fmt.Sprintf(statusSummaryFormat, status.Usage, len(status.Executions))
+	text += fmt.Sprintf(statusLastRunFormat, outcome, last.User, since(clock, last.Timestamp).Round(time.Second), last.Duration.Round(executionLatencyRounding))
+	text += fmt.Sprintf(statusStreakFormat, status.FailureStreak)
+	text += fmt.Sprintf(statusLatencyFormat, status.AverageLatency.Round(executionLatencyRounding))
+	text += fmt.Sprintf(statusSuccessFormat, len(status.Executions)-status.Failures, len(status.Executions))
+	if last.Failed && len(last.Error) > 0 {
+		text += fmt.Sprintf(statusErrorFormat, last.Error)
+	}
+	return text + formatBreaker(status.Circuit, status.CircuitProbeAt, clock)
+}
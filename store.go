@@ -0,0 +1,279 @@
+package slacker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	storeKeySeparator = ":"
+)
+
+var (
+	// ErrKeyNotFound is returned by a Store when the key does not exist or has expired
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// A Store interface is used to persist the state of slacker's features
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// A Sweeper interface is implemented by stores that keep expired values until they are removed.
+// A job sweeps them periodically while the bot is listening.
+type Sweeper interface {
+	Sweep(ctx context.Context) (int, error)
+}
+
+// A CompareAndSwapper interface is implemented by stores that can set a key only while it still
+// holds an expected value, a nil old value meaning the key must not exist. Stores shared by several
+// processes should implement it so that concurrent updates are detected.
+type CompareAndSwapper interface {
+	CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)
+}
+
+// NewMemoryStore creates a new Store that keeps its values in memory.
+// It is the default Store and is lost when the process exits.
+func NewMemoryStore() Store {
+	return NewMemoryStoreWithClock(systemClock{})
+}
+
+// NewMemoryStoreWithClock creates a new Store that keeps its values in memory, expiring them by the clock
+func NewMemoryStoreWithClock(clock Clock) Store {
+	return &memoryStore{items: make(map[string]*memoryItem), clock: clock}
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (i *memoryItem) isExpired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && now.After(i.expiresAt)
+}
+
+type memoryStore struct {
+	mutex sync.RWMutex
+	items map[string]*memoryItem
+	clock Clock
+}
+
+// Get returns the value of the key
+func (m *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	item, ok := m.items[key]
+	if !ok || item.isExpired(m.clock.Now()) {
+		return nil, ErrKeyNotFound
+	}
+	return item.value, nil
+}
+
+// Set sets the value of the key, a ttl of zero never expires
+func (m *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item := &memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = m.clock.Now().Add(ttl)
+	}
+	m.items[key] = item
+	return nil
+}
+
+// CompareAndSwap sets the value of the key if it still holds the old value
+func (m *memoryStore) CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.items[key]
+	exists := ok && !item.isExpired(m.clock.Now())
+	if exists != (old != nil) || (exists && !bytes.Equal(item.value, old)) {
+		return false, nil
+	}
+
+	item = &memoryItem{value: new}
+	if ttl > 0 {
+		item.expiresAt = m.clock.Now().Add(ttl)
+	}
+	m.items[key] = item
+	return true, nil
+}
+
+// Delete removes the key
+func (m *memoryStore) Delete(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.items, key)
+	return nil
+}
+
+// Keys returns the keys starting with the prefix
+func (m *memoryStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	now := m.clock.Now()
+	keys := []string{}
+	for key, item := range m.items {
+		if strings.HasPrefix(key, prefix) && !item.isExpired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Sweep removes the expired values, returning how many it removed
+func (m *memoryStore) Sweep(ctx context.Context) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.clock.Now()
+	swept := 0
+	for key, item := range m.items {
+		if item.isExpired(now) {
+			delete(m.items, key)
+			swept++
+		}
+	}
+	return swept, nil
+}
+
+// NewNamespacedStore creates a Store keeping its values in the store under the namespace, so that
+// several bots or applications can share a backend without their keys colliding
+func NewNamespacedStore(store Store, namespace string) Store {
+	return &namespacedStore{store: store, prefix: namespace + storeKeySeparator}
+}
+
+type namespacedStore struct {
+	mutex  sync.Mutex
+	store  Store
+	prefix string
+}
+
+// Get returns the value of the key in the namespace
+func (n *namespacedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return n.store.Get(ctx, n.prefix+key)
+}
+
+// Set sets the value of the key in the namespace
+func (n *namespacedStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return n.store.Set(ctx, n.prefix+key, value, ttl)
+}
+
+// Delete removes the key from the namespace
+func (n *namespacedStore) Delete(ctx context.Context, key string) error {
+	return n.store.Delete(ctx, n.prefix+key)
+}
+
+// Keys returns the keys of the namespace starting with the prefix, without the namespace
+func (n *namespacedStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := n.store.Keys(ctx, n.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, n.prefix)
+	}
+	return keys, nil
+}
+
+// CompareAndSwap sets the value of the key if it still holds the old value. When the underlying
+// store is not a CompareAndSwapper, it is only protected against concurrent updates from this process.
+func (n *namespacedStore) CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error) {
+	if swapper, ok := n.store.(CompareAndSwapper); ok {
+		return swapper.CompareAndSwap(ctx, n.prefix+key, old, new, ttl)
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	current, err := n.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		current, err = nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if (current == nil) != (old == nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+	return true, n.Set(ctx, key, new, ttl)
+}
+
+// Sweep removes the expired values of the underlying store, when it is a Sweeper
+func (n *namespacedStore) Sweep(ctx context.Context) (int, error) {
+	if sweeper, ok := n.store.(Sweeper); ok {
+		return sweeper.Sweep(ctx)
+	}
+	return 0, nil
+}
+
+// storeKey joins the parts of a key stored by one of slacker's features
+func storeKey(parts ...string) string {
+	return strings.Join(parts, storeKeySeparator)
+}
+
+// loadValue decodes the value of the key into value, reporting whether the key was found
+func (s *Slacker) loadValue(ctx context.Context, key string, value interface{}) (bool, error) {
+	return loadStoreValue(ctx, s.store, s.codec, key, value)
+}
+
+// saveValue encodes and stores the value under the key
+func (s *Slacker) saveValue(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return saveStoreValue(ctx, s.store, s.codec, key, value, ttl)
+}
+
+func loadStoreValue(ctx context.Context, store Store, codec Codec, key string, value interface{}) (bool, error) {
+	data, err := store.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, codec.Unmarshal(data, value)
+}
+
+func saveStoreValue(ctx context.Context, store Store, codec Codec, key string, value interface{}, ttl time.Duration) error {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.Set(ctx, key, data, ttl)
+}
+
+// compareAndSwap sets the value of the key if it still holds the old value. Stores that do not
+// implement CompareAndSwapper are only protected against concurrent updates from this process.
+func (s *Slacker) compareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error) {
+	if swapper, ok := s.store.(CompareAndSwapper); ok {
+		return swapper.CompareAndSwap(ctx, key, old, new, ttl)
+	}
+
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	current, err := s.store.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotFound) {
+		current, err = nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if (current == nil) != (old == nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+	return true, s.store.Set(ctx, key, new, ttl)
+}
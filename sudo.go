@@ -0,0 +1,111 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	sudoKeyPrefix      = "sudo"
+	sudoCommand        = "sudo <duration>"
+	sudoDurationParam  = "duration"
+	sudoDescription    = "Grants you admin privileges for a while, such as `sudo 15m`"
+	defaultSudoMaximum = time.Hour
+	sudoGrantedFormat  = "<@%s> has admin privileges until %s"
+)
+
+var (
+	// ErrInvalidSudo is returned when enabling sudo without a function authorizing its users
+	ErrInvalidSudo = errors.New("sudo needs an authorization function")
+
+	errSudoTooLong = errors.New("That is longer than sudo allows")
+)
+
+// SudoDefinition structure contains the definition of the sudo command, which grants admin
+// privileges to the users passing AuthorizationFunc for a duration of up to MaxDuration, an hour
+// by default. With RequireApproval, a different authorized user has to approve each grant.
+type SudoDefinition struct {
+	AuthorizationFunc func(botCtx BotContext, request Request) bool
+	MaxDuration       time.Duration
+	RequireApproval   bool
+}
+
+// SudoGrant is a window of admin privileges granted by sudo
+type SudoGrant struct {
+	GrantedAt time.Time `json:"granted_at"`
+	Until     time.Time `json:"until"`
+	Approver  string    `json:"approver"`
+}
+
+// Elevation returns the sudo grant of the user in the workspace, or nil when it has none
+func (s *Slacker) Elevation(ctx context.Context, teamID string, userID string) (*SudoGrant, error) {
+	if s.sudo == nil {
+		return nil, nil
+	}
+
+	grant := &SudoGrant{}
+	found, err := s.loadValue(ctx, storeKey(sudoKeyPrefix, teamID, userID), grant)
+	if err != nil || !found || !s.clock.Now().Before(grant.Until) {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// Elevated reports whether the event's user currently has admin privileges granted by sudo.
+// Custom authorization functions consult it to honor sudo.
+func (s *Slacker) Elevated(botCtx BotContext) bool {
+	ev := botCtx.Event()
+	grant, err := s.Elevation(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		fmt.Printf("failed loading sudo grant: %v\n", err)
+		return false
+	}
+	return grant != nil
+}
+
+// appendSudoHandle adds the sudo command when it is enabled, it is called with the lock held
+func (s *Slacker) appendSudoHandle() {
+	if s.sudo == nil {
+		return
+	}
+
+	s.addBuiltinCommand(NewBotCommand(sudoCommand, &CommandDefinition{
+		Description:           sudoDescription,
+		Example:               "sudo 15m",
+		Handler:               s.sudoHandler,
+		AuthorizationFunc:     s.sudo.AuthorizationFunc,
+		RequireSecondApprover: s.sudo.RequireApproval,
+	}))
+}
+
+func (s *Slacker) sudoHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	duration, err := time.ParseDuration(request.Param(sudoDurationParam))
+	if err != nil || duration <= 0 {
+		response.ReportError(fmt.Errorf(invalidTypeError, sudoDurationParam, durationType))
+		return
+	}
+
+	maximum := s.sudo.MaxDuration
+	if maximum <= 0 {
+		maximum = defaultSudoMaximum
+	}
+	if duration > maximum {
+		response.ReportError(errSudoTooLong)
+		return
+	}
+
+	ev := botCtx.Event()
+	now := s.clock.Now()
+	grant := &SudoGrant{GrantedAt: now, Until: now.Add(duration), Approver: Approver(botCtx)}
+	if err := s.saveValue(botCtx.Context(), storeKey(sudoKeyPrefix, ev.TeamID, ev.User), grant, duration); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	text := fmt.Sprintf(sudoGrantedFormat, ev.User, fmt.Sprintf(muteTimeFormat, grant.Until.Unix(), grant.Until.Format(time.RFC1123)))
+	if err := response.Reply(text, WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
@@ -0,0 +1,96 @@
+package slacker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	maxStaticChoices = 100
+	maxSuggestions   = 100
+)
+
+// handleBlockSuggestion answers the options requests of the menus in the modal fallback with the
+// choices of the parameter matching what the user typed, followed by those of its Suggestions.
+// Slack has no options requests for the arguments of slash commands, only for external select
+// menus, so slash commands get autocomplete through the menus of their modal fallback.
+func (s *Slacker) handleBlockSuggestion(botCtx BotContext, callback *slack.InteractionCallback) interface{} {
+	if callback.View.CallbackID != modalFallbackID {
+		return nil
+	}
+
+	metadata := &modalFallbackMetadata{}
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), metadata); err != nil {
+		return nil
+	}
+
+	cmd := s.findCommand(metadata.Usage)
+	if cmd == nil {
+		return nil
+	}
+
+	for i := range cmd.Definition().Parameters {
+		definition := &cmd.Definition().Parameters[i]
+		if definition.Name != callback.ActionID {
+			continue
+		}
+
+		options := []*slack.OptionBlockObject{}
+		for _, value := range s.parameterSuggestions(botCtx, definition, callback.Value) {
+			options = append(options, NewOption(value, value))
+		}
+		return &slack.OptionsResponse{Options: options}
+	}
+	return nil
+}
+
+// parameterSuggestions returns the choices and provided values containing the query, then the
+// values suggested for it
+func (s *Slacker) parameterSuggestions(botCtx BotContext, definition *ParameterDefinition, query string) []string {
+	seen := make(map[string]bool)
+	values := []string{}
+	add := func(value string) {
+		if !seen[value] && len(values) < maxSuggestions {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+
+	for _, choice := range definition.Choices {
+		if strings.Contains(strings.ToLower(choice), strings.ToLower(query)) {
+			add(choice)
+		}
+	}
+
+	if definition.Provider != nil {
+		provided, err := s.providers.values(botCtx, definition)
+		if err != nil {
+			fmt.Printf("failed providing values: %v\n", err)
+		}
+		for _, value := range provided {
+			if strings.Contains(strings.ToLower(value), strings.ToLower(query)) {
+				add(value)
+			}
+		}
+	}
+
+	if definition.Suggestions != nil {
+		suggestions, err := definition.Suggestions(botCtx, query)
+		if err != nil {
+			fmt.Printf("failed suggesting values: %v\n", err)
+		}
+		for _, suggestion := range suggestions {
+			add(suggestion)
+		}
+	}
+	return values
+}
+
+// suggestsValues reports whether the parameter's menu loads its options as the user types,
+// which is needed for suggestions, providers and more choices than a menu can hold
+func suggestsValues(definition *ParameterDefinition) bool {
+	return definition.Suggestions != nil || definition.Provider != nil || len(definition.Choices) > maxStaticChoices
+}
@@ -0,0 +1,127 @@
+package slacker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"unicode/utf8"
+)
+
+const (
+	tableSnippetMaxSize = 3000
+	tableSnippetFormat  = "*%s*\n```\n%s```"
+	tableTag            = "table"
+	tableTagSkip        = "-"
+	csvExtension        = ".csv"
+	csvFiletype         = "csv"
+)
+
+var (
+	errNotStructSlice = errors.New("rows must be a slice of structs")
+)
+
+// A TableEncoder interface writes rows in a file format such as CSV or Excel
+type TableEncoder interface {
+	Encode(w io.Writer, rows [][]string) error
+	Extension() string
+	Filetype() string
+}
+
+// NewCSVEncoder creates a TableEncoder writing comma separated values
+func NewCSVEncoder() TableEncoder {
+	return &csvEncoder{}
+}
+
+type csvEncoder struct{}
+
+// Encode writes the rows as CSV
+func (e *csvEncoder) Encode(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// Extension returns the extension of CSV files
+func (e *csvEncoder) Extension() string {
+	return csvExtension
+}
+
+// Filetype returns the Slack file type of CSV files
+func (e *csvEncoder) Filetype() string {
+	return csvFiletype
+}
+
+// StructRows converts a slice of structs into rows, the first one holding the headers.
+// Headers default to the field names and can be set with a `table:"name"` tag, `table:"-"` skips a field.
+func StructRows(slice interface{}) ([][]string, error) {
+	value := reflect.ValueOf(slice)
+	if value.Kind() != reflect.Slice {
+		return nil, errNotStructSlice
+	}
+
+	elementType := value.Type().Elem()
+	if elementType.Kind() == reflect.Ptr {
+		elementType = elementType.Elem()
+	}
+	if elementType.Kind() != reflect.Struct {
+		return nil, errNotStructSlice
+	}
+
+	fields := []int{}
+	headers := []string{}
+	for i := 0; i < elementType.NumField(); i++ {
+		field := elementType.Field(i)
+		tag := field.Tag.Get(tableTag)
+		if len(field.PkgPath) > 0 || tag == tableTagSkip {
+			continue
+		}
+
+		header := field.Name
+		if len(tag) > 0 {
+			header = tag
+		}
+		fields = append(fields, i)
+		headers = append(headers, header)
+	}
+
+	rows := [][]string{headers}
+	for i := 0; i < value.Len(); i++ {
+		element := value.Index(i)
+		if element.Kind() == reflect.Ptr {
+			if element.IsNil() {
+				continue
+			}
+			element = element.Elem()
+		}
+
+		row := make([]string, 0, len(fields))
+		for _, field := range fields {
+			row = append(row, fmt.Sprint(element.Field(field).Interface()))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReplyTable sends the rows to the current channel, as a snippet when they are short text
+// and as a file named after the table otherwise
+func (r *response) ReplyTable(name string, rows [][]string, options ...ReplyOption) error {
+	defaults := NewReplyDefaults(options...)
+
+	data := &bytes.Buffer{}
+	if err := defaults.TableEncoder.Encode(data, rows); err != nil {
+		return err
+	}
+
+	if data.Len() <= tableSnippetMaxSize && utf8.Valid(data.Bytes()) {
+		return r.Reply(fmt.Sprintf(tableSnippetFormat, name, data.String()), options...)
+	}
+
+	filename := name + defaults.TableEncoder.Extension()
+	return r.FileUpload(name, empty, filename, defaults.TableEncoder.Filetype(), data, options...)
+}
@@ -0,0 +1,401 @@
+package slacker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	taskCancelID        = "slacker_task_cancel"
+	taskCancelText      = "Cancel"
+	taskRunningFormat   = ":hourglass_flowing_sand: *%s*\n`%s` %d%%\n%s_%s elapsed_"
+	taskDoneFormat      = ":white_check_mark: *%s* finished in %s"
+	taskFailedFormat    = ":x: *%s* failed after %s: _%s_"
+	taskCancelledFormat = ":no_entry_sign: *%s* was cancelled by <@%s> after %s"
+	taskStatusFormat    = "_%s_ · "
+	taskProgressFull    = "▓"
+	taskProgressEmpty   = "░"
+	taskProgressCells   = 10
+)
+
+var (
+	// ErrTaskNotFound is returned when the task does not exist or has already finished
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskFinished is returned when reporting on a task that is done, failed or cancelled
+	ErrTaskFinished = errors.New("task already finished")
+)
+
+// A Task interface is used to report the progress of a long-running handler
+// through a single message that is edited as the task advances. Tasks the handler
+// leaves open are marked done once it returns.
+type Task interface {
+	ID() string
+	Progress(percent int, status string) error
+	Done(result string) error
+	Fail(err error) error
+	Cancelled() <-chan struct{}
+}
+
+// TaskInfo describes a running task
+type TaskInfo struct {
+	ID        string
+	Title     string
+	Status    string
+	Percent   int
+	Channel   string
+	User      string
+	StartedAt time.Time
+}
+
+type taskTrackerKey struct{}
+
+type executionKey struct{}
+
+// execution is a running command handler, whose context is cancelled along with its tasks.
+// The parameters are kept rather than the request, which is released once the handler returns.
+type execution struct {
+	command    BotCommand
+	parameters *proper.Properties
+	cancel     context.CancelFunc
+
+	mutex sync.Mutex
+	tasks []*task
+}
+
+// track keeps the task started by the handler, to finish it if the handler does not
+func (e *execution) track(task *task) {
+	if e == nil {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.tasks = append(e.tasks, task)
+}
+
+// finishTasks marks the tasks the handler left open as done, once it returned
+func (e *execution) finishTasks() {
+	e.mutex.Lock()
+	tasks := e.tasks
+	e.tasks = nil
+	e.mutex.Unlock()
+
+	for _, task := range tasks {
+		if err := task.Done(empty); err != nil && !errors.Is(err, ErrTaskFinished) {
+			fmt.Printf("failed finishing task: %v\n", err)
+		}
+	}
+}
+
+func withExecution(ctx context.Context, exec *execution) context.Context {
+	return context.WithValue(ctx, executionKey{}, exec)
+}
+
+func executionFromContext(ctx context.Context) *execution {
+	exec, _ := ctx.Value(executionKey{}).(*execution)
+	return exec
+}
+
+// taskTracker keeps the tasks that are currently running
+type taskTracker struct {
+	mutex sync.RWMutex
+	tasks map[string]*task
+}
+
+func newTaskTracker() *taskTracker {
+	return &taskTracker{tasks: make(map[string]*task)}
+}
+
+func withTaskTracker(ctx context.Context, tracker *taskTracker) context.Context {
+	return context.WithValue(ctx, taskTrackerKey{}, tracker)
+}
+
+func taskTrackerFromContext(ctx context.Context) *taskTracker {
+	tracker, _ := ctx.Value(taskTrackerKey{}).(*taskTracker)
+	return tracker
+}
+
+func (t *taskTracker) add(task *task) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.tasks[task.id] = task
+}
+
+func (t *taskTracker) remove(id string) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.tasks, id)
+}
+
+func (t *taskTracker) get(id string) (*task, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	task, ok := t.tasks[id]
+	return task, ok
+}
+
+func (t *taskTracker) list() []*TaskInfo {
+	t.mutex.RLock()
+	tasks := make([]*task, 0, len(t.tasks))
+	for _, task := range t.tasks {
+		tasks = append(tasks, task)
+	}
+	t.mutex.RUnlock()
+
+	infos := []*TaskInfo{}
+	for _, task := range tasks {
+		infos = append(infos, task.info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// Tasks returns the tasks that are currently running, the oldest first
+func (s *Slacker) Tasks() []*TaskInfo {
+	return s.tasks.list()
+}
+
+// CancelTask cancels a running task on behalf of the user
+func (s *Slacker) CancelTask(id string, userID string) error {
+	task, ok := s.tasks.get(id)
+	if !ok {
+		return ErrTaskNotFound
+	}
+	return task.cancel(userID)
+}
+
+// handleTaskCancel cancels the task when its Cancel button is clicked by the user who started it,
+// or by a user authorized to execute the command that started it
+func (s *Slacker) handleTaskCancel(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	task, ok := s.tasks.get(action.Value)
+	if !ok {
+		s.replaceInteractionMessage(botCtx, callback, ErrTaskNotFound.Error())
+		return
+	}
+
+	if !task.canCancel(botCtx) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	if err := task.cancel(callback.User.ID); err != nil {
+		s.replaceInteractionMessage(botCtx, callback, err.Error())
+	}
+}
+
+// newTask posts the message tracking the task's progress
+func newTask(botCtx BotContext, title string, options ...ReplyOption) *task {
+	defaults := NewReplyDefaults(options...)
+	ev := botCtx.Event()
+
+	clock := clockFromContext(botCtx.Context())
+	task := &task{
+		id:        newTaskID(),
+		title:     title,
+		client:    botCtx.Client(),
+		channel:   ev.Channel,
+		user:      ev.User,
+		clock:     clock,
+		startedAt: clock.Now(),
+		cancelled: make(chan struct{}),
+		tracker:   taskTrackerFromContext(botCtx.Context()),
+		execution: executionFromContext(botCtx.Context()),
+	}
+
+	opts := task.messageOptions()
+	if defaults.ThreadResponse {
+		opts = append(opts, slack.MsgOptionTS(ev.MakeThreadTimestamp()))
+	}
+
+	_, timestamp, err := task.client.PostMessageContext(context.Background(), task.channel, opts...)
+	if err != nil {
+		fmt.Printf("failed posting task: %v\n", err)
+	}
+	task.timestamp = timestamp
+
+	task.tracker.add(task)
+	task.execution.track(task)
+	return task
+}
+
+type task struct {
+	mutex     sync.Mutex
+	id        string
+	title     string
+	client    *slack.Client
+	channel   string
+	timestamp string
+	user      string
+	clock     Clock
+	startedAt time.Time
+	percent   int
+	status    string
+	finished  bool
+	cancelled chan struct{}
+	tracker   *taskTracker
+	execution *execution
+}
+
+// ID returns the task identifier
+func (t *task) ID() string {
+	return t.id
+}
+
+// Cancelled returns a channel that is closed when the task is cancelled.
+// Cancelling a task also cancels the context of the handler that started it.
+func (t *task) Cancelled() <-chan struct{} {
+	return t.cancelled
+}
+
+// Progress updates the completion percentage and status of the task
+func (t *task) Progress(percent int, status string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.finished {
+		return ErrTaskFinished
+	}
+
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	t.percent = percent
+	t.status = status
+	return t.update(t.messageOptions()...)
+}
+
+// Done marks the task as finished with the result
+func (t *task) Done(result string) error {
+	text := fmt.Sprintf(taskDoneFormat, t.title, t.elapsed())
+	if len(result) > 0 {
+		text += newLine + result
+	}
+	return t.finish(text)
+}
+
+// Fail marks the task as failed with the error
+func (t *task) Fail(err error) error {
+	return t.finish(fmt.Sprintf(taskFailedFormat, t.title, t.elapsed(), err.Error()))
+}
+
+func (t *task) cancel(userID string) error {
+	if err := t.finish(fmt.Sprintf(taskCancelledFormat, t.title, userID, t.elapsed())); err != nil {
+		return err
+	}
+
+	close(t.cancelled)
+	if t.execution != nil {
+		t.execution.cancel()
+	}
+	return nil
+}
+
+func (t *task) canCancel(botCtx BotContext) bool {
+	if botCtx.Event().User == t.user {
+		return true
+	}
+
+	if t.execution == nil {
+		return false
+	}
+
+	authorizationFunc := t.execution.command.Definition().AuthorizationFunc
+	return authorizationFunc != nil && authorizationFunc(botCtx, NewRequest(botCtx, t.execution.parameters))
+}
+
+func (t *task) finish(text string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.finished {
+		return ErrTaskFinished
+	}
+
+	t.finished = true
+	t.tracker.remove(t.id)
+	return t.update(slack.MsgOptionText(text, false), slack.MsgOptionBlocks([]slack.Block{}...))
+}
+
+func (t *task) update(options ...slack.MsgOption) error {
+	if len(t.timestamp) == 0 {
+		return nil
+	}
+
+	_, _, _, err := t.client.UpdateMessageContext(context.Background(), t.channel, t.timestamp, options...)
+	return err
+}
+
+func (t *task) info() *TaskInfo {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return &TaskInfo{
+		ID:        t.id,
+		Title:     t.title,
+		Status:    t.status,
+		Percent:   t.percent,
+		Channel:   t.channel,
+		User:      t.user,
+		StartedAt: t.startedAt,
+	}
+}
+
+func (t *task) elapsed() time.Duration {
+	return since(t.clock, t.startedAt).Round(time.Second)
+}
+
+func (t *task) messageOptions() []slack.MsgOption {
+	status := empty
+	if len(t.status) > 0 {
+		status = fmt.Sprintf(taskStatusFormat, t.status)
+	}
+
+	cells := t.percent * taskProgressCells / 100
+	bar := strings.Repeat(taskProgressFull, cells) + strings.Repeat(taskProgressEmpty, taskProgressCells-cells)
+	text := fmt.Sprintf(taskRunningFormat, t.title, bar, t.percent, status, t.elapsed())
+
+	cancel := slack.NewButtonBlockElement(taskCancelID, t.id, slack.NewTextBlockObject(slack.PlainTextType, taskCancelText, false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewActionBlock(taskCancelID, cancel),
+	}
+
+	return []slack.MsgOption{
+		slack.MsgOptionText(t.title, false),
+		slack.MsgOptionBlocks(blocks...),
+	}
+}
+
+func newTaskID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
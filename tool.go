@@ -0,0 +1,179 @@
+package slacker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shomali11/proper"
+)
+
+const (
+	toolNameSeparator   = "_"
+	toolDefaultName     = "command"
+	toolTypeObject      = "object"
+	toolTypeString      = "string"
+	toolTypeInteger     = "integer"
+	toolTypeNumber      = "number"
+	toolTypeBoolean     = "boolean"
+	unknownToolArgument = "Unknown argument `%s` for tool `%s`"
+)
+
+var (
+	// ErrToolNotFound is returned when a tool call names no registered command
+	ErrToolNotFound = errors.New("tool not found")
+
+	toolNameInvalidCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+)
+
+// Tool describes a command as a function that a language model can call
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  *ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON schema of the arguments of a tool
+type ToolParameters struct {
+	Type       string                   `json:"type"`
+	Properties map[string]*ToolProperty `json:"properties"`
+	Required   []string                 `json:"required,omitempty"`
+}
+
+// ToolProperty is the JSON schema of a single argument of a tool
+type ToolProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ToolCall is a language model's request to call a tool with arguments encoded as a JSON object
+type ToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// Tools returns the registered commands as tools, leaving out the built-in commands
+func (s *Slacker) Tools() []*Tool {
+	tools, _ := s.toolIndex()
+	return tools
+}
+
+// CallTool executes the command matching the tool call on behalf of the event's user.
+// The command goes through the same authorization and validation as when typed in Slack.
+func (s *Slacker) CallTool(botCtx BotContext, response ResponseWriter, call *ToolCall) error {
+	_, commands := s.toolIndex()
+	cmd, ok := commands[call.Name]
+	if !ok {
+		return ErrToolNotFound
+	}
+
+	arguments := map[string]interface{}{}
+	if len(call.Arguments) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(call.Arguments))
+		decoder.UseNumber()
+		if err := decoder.Decode(&arguments); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, token := range cmd.Tokenize() {
+		if token.IsParameter() {
+			names[strings.TrimSuffix(token.Word, multiLineSuffix)] = true
+		}
+	}
+
+	values := make(map[string]string)
+	for name, argument := range arguments {
+		if !names[name] {
+			return fmt.Errorf(unknownToolArgument, name, call.Name)
+		}
+		if argument != nil {
+			values[name] = fmt.Sprint(argument)
+		}
+	}
+
+	s.runCommand(botCtx, response, cmd, proper.NewProperties(values))
+	return nil
+}
+
+// toolIndex builds the tools of the registered commands along with the command of each tool name
+func (s *Slacker) toolIndex() ([]*Tool, map[string]BotCommand) {
+	tools := []*Tool{}
+	commands := make(map[string]BotCommand)
+	for _, cmd := range s.commands() {
+		if isBuiltinCommand(cmd) {
+			continue
+		}
+
+		tool := newTool(cmd)
+		name := tool.Name
+		for i := 2; commands[tool.Name] != nil; i++ {
+			tool.Name = fmt.Sprintf("%s%s%d", name, toolNameSeparator, i)
+		}
+
+		commands[tool.Name] = cmd
+		tools = append(tools, tool)
+	}
+	return tools, commands
+}
+
+// newTool describes the command, named after its words and taking its parameters as arguments
+func newTool(cmd BotCommand) *Tool {
+	definitions := make(map[string]*ParameterDefinition)
+	if cmd.Definition() != nil {
+		for i := range cmd.Definition().Parameters {
+			definitions[cmd.Definition().Parameters[i].Name] = &cmd.Definition().Parameters[i]
+		}
+	}
+
+	words := []string{}
+	parameters := &ToolParameters{Type: toolTypeObject, Properties: make(map[string]*ToolProperty)}
+	for _, token := range cmd.Tokenize() {
+		if !token.IsParameter() {
+			words = append(words, token.Word)
+			continue
+		}
+
+		name := strings.TrimSuffix(token.Word, multiLineSuffix)
+		property := &ToolProperty{Type: toolTypeString}
+		if definition, ok := definitions[name]; ok {
+			property.Type = toolType(definition.Type)
+			property.Description = definition.Description
+			property.Enum = definition.Choices
+			if definition.Required {
+				parameters.Required = append(parameters.Required, name)
+			}
+		}
+		parameters.Properties[name] = property
+	}
+
+	tool := &Tool{
+		Name:       toolNameInvalidCharacters.ReplaceAllString(strings.Join(words, toolNameSeparator), toolNameSeparator),
+		Parameters: parameters,
+	}
+	if len(tool.Name) == 0 {
+		tool.Name = toolDefaultName
+	}
+	if cmd.Definition() != nil {
+		tool.Description = cmd.Definition().Description
+	}
+	return tool
+}
+
+// toolType converts a parameter type to its JSON schema type
+func toolType(parameterType ParameterType) string {
+	switch parameterType {
+	case IntegerParameter:
+		return toolTypeInteger
+	case FloatParameter:
+		return toolTypeNumber
+	case BooleanParameter:
+		return toolTypeBoolean
+	}
+	return toolTypeString
+}
@@ -0,0 +1,101 @@
+package slacker
+
+import (
+	"bytes"
+	"errors"
+	"text/template"
+	"time"
+)
+
+const (
+	topicJobPrefix       = "topic"
+	topicKeyPrefix       = "topic"
+	topicDefaultTemplate = "{{.Value}}"
+)
+
+var (
+	// ErrInvalidTopicRotation is returned when a topic rotation has no channel, schedule or values
+	ErrInvalidTopicRotation = errors.New("a topic rotation needs a channel, a schedule and values")
+)
+
+// TopicRotation structure contains the definition of a channel topic that rotates
+// through a list of values, such as the weekly on-call engineer
+type TopicRotation struct {
+	Channel  string
+	Schedule Schedule
+	Values   []string
+
+	// Template is a text/template rendered with a TopicData, it defaults to the current value
+	Template string
+}
+
+// TopicData is passed to the template of a topic rotation
+type TopicData struct {
+	Value string
+	Next  string
+	Index int
+	Time  time.Time
+}
+
+// topicState is the position of a rotation, kept in the store so it survives restarts
+type topicState struct {
+	Index int `json:"index"`
+}
+
+// RotateTopic sets the topic of the channel to the next value of the rotation on its schedule
+func (s *Slacker) RotateTopic(rotation *TopicRotation) error {
+	if rotation == nil || len(rotation.Channel) == 0 || rotation.Schedule == nil || len(rotation.Values) == 0 {
+		return ErrInvalidTopicRotation
+	}
+
+	text := rotation.Template
+	if len(text) == 0 {
+		text = topicDefaultTemplate
+	}
+
+	tmpl, err := template.New(rotation.Channel).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	err = s.Job(storeKey(topicJobPrefix, rotation.Channel), &JobDefinition{
+		Description: "Rotates the topic of " + rotation.Channel,
+		Schedule:    rotation.Schedule,
+		Handler: func(jobCtx JobContext) error {
+			return s.rotateTopic(jobCtx, rotation, tmpl)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.register(func() {
+		s.topicChannels = append(s.topicChannels, rotation.Channel)
+	})
+}
+
+func (s *Slacker) rotateTopic(jobCtx JobContext, rotation *TopicRotation, tmpl *template.Template) error {
+	key := storeKey(topicKeyPrefix, rotation.Channel)
+	state := &topicState{}
+	if _, err := s.loadValue(jobCtx.Context(), key, state); err != nil {
+		return err
+	}
+
+	index := state.Index % len(rotation.Values)
+	data := &TopicData{
+		Value: rotation.Values[index],
+		Next:  rotation.Values[(index+1)%len(rotation.Values)],
+		Index: index,
+		Time:  s.clock.Now(),
+	}
+
+	topic := &bytes.Buffer{}
+	if err := tmpl.Execute(topic, data); err != nil {
+		return err
+	}
+
+	if err := jobCtx.Channels().SetTopic(rotation.Channel, topic.String()); err != nil {
+		return err
+	}
+	return s.saveValue(jobCtx.Context(), key, &topicState{Index: index + 1}, 0)
+}
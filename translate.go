@@ -0,0 +1,300 @@
+package slacker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shomali11/commander"
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	localeKeyPrefix = "locale"
+	localeTTL       = 24 * time.Hour
+	localeSeparator = "-"
+)
+
+// A Translator interface translates text into a language, given as an ISO 639-1 code such as "en"
+type Translator interface {
+	Translate(ctx context.Context, text string, language string) (string, error)
+}
+
+// translatedCommands keeps the commands whose words were translated, by language and usage
+type translatedCommands struct {
+	mutex    sync.Mutex
+	commands map[string]BotCommand
+}
+
+// matchTranslated returns the first command the user registered whose words, translated to the
+// user's language, match the text. Only the words of the usages are translated, so that the values
+// of parameters are kept as typed.
+func (s *Slacker) matchTranslated(botCtx BotContext, commands []BotCommand, text string) (BotCommand, *proper.Properties) {
+	if s.translator == nil || botCtx.Event() == nil || len(text) == 0 {
+		return nil, nil
+	}
+
+	ctx := botCtx.Context()
+	language, err := s.userLanguage(ctx, botCtx.Event())
+	if err != nil {
+		fmt.Printf("failed getting user locale: %v\n", err)
+		return nil, nil
+	}
+	if len(language) == 0 || strings.EqualFold(language, s.language) {
+		return nil, nil
+	}
+
+	for _, cmd := range userCommands(commands) {
+		translated := s.translatedCommand(ctx, cmd, language)
+		if translated == nil {
+			continue
+		}
+		if parameters, isMatch := translated.Match(text); isMatch {
+			return cmd, parameters
+		}
+	}
+	return nil, nil
+}
+
+// translatedCommand returns the command with the words of its usage translated to the language,
+// or nil when they could not be translated
+func (s *Slacker) translatedCommand(ctx context.Context, cmd BotCommand, language string) BotCommand {
+	key := storeKey(language, cmd.Usage())
+	s.translations.mutex.Lock()
+	translated, ok := s.translations.commands[key]
+	s.translations.mutex.Unlock()
+	if ok {
+		return translated
+	}
+
+	words := strings.Fields(cmd.Usage())
+	for i, token := range commander.NewCommand(cmd.Usage()).Tokenize() {
+		if token.IsParameter() {
+			continue
+		}
+
+		word, err := s.translator.Translate(ctx, words[i], language)
+		if err != nil {
+			fmt.Printf("failed translating command: %v\n", err)
+			return nil
+		}
+		words[i] = strings.TrimSpace(word)
+	}
+	translated = NewBotCommand(strings.Join(words, space), cmd.Definition())
+
+	s.translations.mutex.Lock()
+	defer s.translations.mutex.Unlock()
+
+	if s.translations.commands == nil {
+		s.translations.commands = make(map[string]BotCommand)
+	}
+	s.translations.commands[key] = translated
+	return translated
+}
+
+// withTranslation wraps the response so that replies are translated to the user's language
+func (s *Slacker) withTranslation(botCtx BotContext, response ResponseWriter) ResponseWriter {
+	if s.translator == nil || botCtx.Event() == nil {
+		return response
+	}
+
+	language, err := s.userLanguage(botCtx.Context(), botCtx.Event())
+	if err != nil {
+		fmt.Printf("failed getting user locale: %v\n", err)
+		return response
+	}
+
+	if len(language) == 0 || strings.EqualFold(language, s.language) {
+		return response
+	}
+	return &translatedResponse{ResponseWriter: response, ctx: botCtx.Context(), translator: s.translator, language: language}
+}
+
+// userLanguage returns the language of the user's Slack locale, keeping it in the store for a day
+func (s *Slacker) userLanguage(ctx context.Context, ev *MessageEvent) (string, error) {
+	key := storeKey(localeKeyPrefix, ev.TeamID, ev.User)
+
+	var locale string
+	found, err := s.loadValue(ctx, key, &locale)
+	if err != nil {
+		return empty, err
+	}
+
+	if !found {
+		user, err := s.client.GetUserInfoContext(ctx, ev.User)
+		if err != nil {
+			return empty, scopeError(ctx, err, featureUsers, scopeUsersRead)
+		}
+		locale = user.Locale
+
+		if err := s.saveValue(ctx, key, locale, localeTTL); err != nil {
+			return empty, err
+		}
+	}
+	return strings.Split(locale, localeSeparator)[0], nil
+}
+
+// translatedResponse translates replies and errors before sending them
+type translatedResponse struct {
+	ResponseWriter
+	ctx        context.Context
+	translator Translator
+	language   string
+}
+
+// Reply sends the message translated to the user's language
+func (r *translatedResponse) Reply(message string, options ...ReplyOption) error {
+	return r.ResponseWriter.Reply(r.translate(message), r.translateOptions(options)...)
+}
+
+// PostTo sends the message translated to the user's language to another channel
+func (r *translatedResponse) PostTo(channelID string, message string, options ...ReplyOption) error {
+	return r.ResponseWriter.PostTo(channelID, r.translate(message), r.translateOptions(options)...)
+}
+
+// ReplyBlocks sends the blocks with their text translated to the user's language
+func (r *translatedResponse) ReplyBlocks(blocks []slack.Block, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyBlocks(r.translateBlocks(blocks), options...)
+}
+
+// PostBlocks sends the blocks with their text translated to the user's language to another channel
+func (r *translatedResponse) PostBlocks(channelID string, blocks []slack.Block, options ...ReplyOption) error {
+	return r.ResponseWriter.PostBlocks(channelID, r.translateBlocks(blocks), options...)
+}
+
+// FileUpload uploads the file with its title and comment translated to the user's language
+func (r *translatedResponse) FileUpload(title string, comment string, filename string, filetype string, reader io.Reader, options ...ReplyOption) error {
+	return r.ResponseWriter.FileUpload(r.translate(title), r.translate(comment), filename, filetype, reader, options...)
+}
+
+// StartTask starts the task with its title translated to the user's language
+func (r *translatedResponse) StartTask(title string, options ...ReplyOption) Task {
+	return r.ResponseWriter.StartTask(r.translate(title), options...)
+}
+
+// StartTimer starts the timer with its title translated to the user's language
+func (r *translatedResponse) StartTimer(title string, until time.Time, options ...ReplyOption) Timer {
+	return r.ResponseWriter.StartTimer(r.translate(title), until, options...)
+}
+
+// ReplyTable sends the table with its name translated to the user's language
+func (r *translatedResponse) ReplyTable(name string, rows [][]string, options ...ReplyOption) error {
+	return r.ResponseWriter.ReplyTable(r.translate(name), rows, options...)
+}
+
+// ReplyChart sends the chart with its title translated to the user's language
+func (r *translatedResponse) ReplyChart(spec *ChartSpec, options ...ReplyOption) error {
+	if spec != nil {
+		translated := *spec
+		translated.Title = r.translate(spec.Title)
+		spec = &translated
+	}
+	return r.ResponseWriter.ReplyChart(spec, options...)
+}
+
+// OpenModal opens the modal with its title, buttons and blocks translated to the user's language
+func (r *translatedResponse) OpenModal(view slack.ModalViewRequest) error {
+	view.Title = r.translateText(view.Title)
+	view.Submit = r.translateText(view.Submit)
+	view.Close = r.translateText(view.Close)
+	view.Blocks.BlockSet = r.translateBlocks(view.Blocks.BlockSet)
+	return r.ResponseWriter.OpenModal(view)
+}
+
+// ReportError sends the error translated to the user's language
+func (r *translatedResponse) ReportError(err error, options ...ReportErrorOption) {
+	r.ResponseWriter.ReportError(&translatedError{error: err, text: r.translate(err.Error())}, options...)
+}
+
+// translatedError keeps the original error in the chain so that errors.Is and errors.As still match it
+type translatedError struct {
+	error
+	text string
+}
+
+// Error returns the translated text
+func (e *translatedError) Error() string {
+	return e.text
+}
+
+// Unwrap returns the original error
+func (e *translatedError) Unwrap() error {
+	return e.error
+}
+
+// translateOptions translates the blocks set WithBlocks, if any
+func (r *translatedResponse) translateOptions(options []ReplyOption) []ReplyOption {
+	defaults := NewReplyDefaults(options...)
+	if len(defaults.Blocks) == 0 {
+		return options
+	}
+	return append(options, WithBlocks(r.translateBlocks(defaults.Blocks)))
+}
+
+// translateBlocks returns copies of the blocks with the text shown to the user translated, leaving
+// the blocks of the handler unchanged
+func (r *translatedResponse) translateBlocks(blocks []slack.Block) []slack.Block {
+	translated := make([]slack.Block, 0, len(blocks))
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *slack.HeaderBlock:
+			header := *block
+			header.Text = r.translateText(block.Text)
+			translated = append(translated, &header)
+		case *slack.SectionBlock:
+			section := *block
+			section.Text = r.translateText(block.Text)
+			section.Fields = make([]*slack.TextBlockObject, 0, len(block.Fields))
+			for _, field := range block.Fields {
+				section.Fields = append(section.Fields, r.translateText(field))
+			}
+			translated = append(translated, &section)
+		case *slack.ContextBlock:
+			contextBlock := *block
+			contextBlock.ContextElements.Elements = make([]slack.MixedElement, 0, len(block.ContextElements.Elements))
+			for _, element := range block.ContextElements.Elements {
+				if text, ok := element.(*slack.TextBlockObject); ok {
+					element = r.translateText(text)
+				}
+				contextBlock.ContextElements.Elements = append(contextBlock.ContextElements.Elements, element)
+			}
+			translated = append(translated, &contextBlock)
+		case *slack.InputBlock:
+			input := *block
+			input.Label = r.translateText(block.Label)
+			input.Hint = r.translateText(block.Hint)
+			translated = append(translated, &input)
+		default:
+			translated = append(translated, block)
+		}
+	}
+	return translated
+}
+
+// translateText returns a copy of the text object with its text translated
+func (r *translatedResponse) translateText(text *slack.TextBlockObject) *slack.TextBlockObject {
+	if text == nil || len(text.Text) == 0 {
+		return text
+	}
+
+	translated := *text
+	translated.Text = r.translate(text.Text)
+	return &translated
+}
+
+func (r *translatedResponse) translate(text string) string {
+	if len(text) == 0 {
+		return text
+	}
+
+	translated, err := r.translator.Translate(r.ctx, text, r.language)
+	if err != nil {
+		fmt.Printf("failed translating reply: %v\n", err)
+		return text
+	}
+	return translated
+}
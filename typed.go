@@ -0,0 +1,248 @@
+package slacker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	paramTag          = "param"
+	validateTag       = "validate"
+	descriptionTag    = "desc"
+	ruleSeparator     = ","
+	ruleAssignment    = "="
+	durationType      = "duration"
+	ruleRequired      = "required"
+	ruleSecret        = "secret"
+	ruleOneOf         = "oneof"
+	ruleMin           = "min"
+	ruleMax           = "max"
+	belowMinimumError = "Parameter `%s` must be at least %s"
+	aboveMaximumError = "Parameter `%s` must be at most %s"
+	tooShortError     = "Parameter `%s` must be at least %s characters long"
+	tooLongError      = "Parameter `%s` must be at most %s characters long"
+	outOfRangeError   = "Parameter `%s` is out of range"
+	unsupportedField  = "unsupported type %s of parameter field %s"
+	unknownRule       = "unknown validation rule %s of parameter field %s"
+	invalidRuleBound  = "invalid bound %s of validation rule %s of parameter field %s"
+)
+
+// Typed creates a command whose parameters are decoded into the fields of a struct, for use as
+// s.Command(slacker.Typed("deploy <env> <replicas>", handler)). Fields are named after the
+// lowercased field name or their `param` tag, and described by their `desc` tag. The `validate`
+// tag lists comma separated rules: required, secret, oneof=a b c, min=n and max=n, where min
+// and max bound numbers and the length of strings. Fields may be strings, booleans, numbers
+// or durations. Invalid values are reported to the user before the handler runs. Command returns
+// an error when a field has another type or an unknown rule.
+func Typed[T any](usage string, handler func(botCtx BotContext, params T, response ResponseWriter)) (string, *CommandDefinition) {
+	definition, err := typedDefinition(reflect.TypeOf((*T)(nil)).Elem(), func(botCtx BotContext, params reflect.Value, response ResponseWriter) {
+		handler(botCtx, params.Interface().(T), response)
+	})
+	if err != nil {
+		return usage, &CommandDefinition{err: err}
+	}
+	return usage, definition
+}
+
+// typedDefinition creates a command whose handler receives a struct of the type decoded from the request
+func typedDefinition(paramsType reflect.Type, handler func(botCtx BotContext, params reflect.Value, response ResponseWriter)) (*CommandDefinition, error) {
+	fields, err := typedFields(paramsType)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]ParameterDefinition, 0, len(fields))
+	for _, field := range fields {
+		parameters = append(parameters, field.definition)
+	}
+
+	return &CommandDefinition{
+		Parameters: parameters,
+		Handler: func(botCtx BotContext, request Request, response ResponseWriter) {
+			params := reflect.New(paramsType).Elem()
+			if err := decodeTyped(params, fields, request); err != nil {
+				response.ReportError(err)
+				return
+			}
+			handler(botCtx, params, response)
+		},
+	}, nil
+}
+
+// typedField is a struct field holding a parameter
+type typedField struct {
+	index      int
+	definition ParameterDefinition
+	min        string
+	max        string
+}
+
+func typedFields(structType reflect.Type) ([]*typedField, error) {
+	fields := []*typedField{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		parameterType, err := typedParameterType(field)
+		if err != nil {
+			return nil, err
+		}
+
+		name := field.Tag.Get(paramTag)
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+
+		typed := &typedField{
+			index: i,
+			definition: ParameterDefinition{
+				Name:        name,
+				Description: field.Tag.Get(descriptionTag),
+				Type:        parameterType,
+			},
+		}
+
+		for _, rule := range strings.Split(field.Tag.Get(validateTag), ruleSeparator) {
+			key, value := rule, empty
+			if index := strings.Index(rule, ruleAssignment); index >= 0 {
+				key, value = rule[:index], rule[index+1:]
+			}
+
+			key = strings.TrimSpace(key)
+			switch key {
+			case empty:
+			case ruleRequired:
+				typed.definition.Required = true
+			case ruleSecret:
+				typed.definition.Secret = true
+			case ruleOneOf:
+				typed.definition.Choices = strings.Fields(value)
+			case ruleMin, ruleMax:
+				value = strings.TrimSpace(value)
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					return nil, fmt.Errorf(invalidRuleBound, value, key, field.Name)
+				}
+				if key == ruleMin {
+					typed.min = value
+				} else {
+					typed.max = value
+				}
+			default:
+				return nil, fmt.Errorf(unknownRule, key, field.Name)
+			}
+		}
+		fields = append(fields, typed)
+	}
+	return fields, nil
+}
+
+func typedParameterType(field reflect.StructField) (ParameterType, error) {
+	if field.Type == reflect.TypeOf(time.Duration(0)) {
+		return DurationParameter, nil
+	}
+
+	switch field.Type.Kind() {
+	case reflect.String:
+		return StringParameter, nil
+	case reflect.Bool:
+		return BooleanParameter, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return IntegerParameter, nil
+	case reflect.Float32, reflect.Float64:
+		return FloatParameter, nil
+	}
+	return empty, fmt.Errorf(unsupportedField, field.Type, field.Name)
+}
+
+// decodeTyped sets the fields of the struct from the request, checking their bounds.
+// Types, choices and required parameters were checked against the definitions already.
+func decodeTyped(target reflect.Value, fields []*typedField, request Request) error {
+	for _, field := range fields {
+		name := field.definition.Name
+		if !hasParameter(request.Properties(), name) {
+			continue
+		}
+
+		raw := request.StringParam(name, empty)
+		value := target.Field(field.index)
+		switch {
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			duration, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf(invalidTypeError, name, durationType)
+			}
+			value.SetInt(int64(duration))
+		case value.Kind() == reflect.String:
+			if err := checkBounds(name, float64(len([]rune(raw))), field, tooShortError, tooLongError); err != nil {
+				return err
+			}
+			value.SetString(raw)
+		case value.Kind() == reflect.Bool:
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf(invalidTypeError, name, field.definition.Type)
+			}
+			value.SetBool(parsed)
+		case value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64:
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf(invalidTypeError, name, field.definition.Type)
+			}
+			if err := checkBounds(name, parsed, field, belowMinimumError, aboveMaximumError); err != nil {
+				return err
+			}
+			if value.OverflowFloat(parsed) {
+				return fmt.Errorf(outOfRangeError, name)
+			}
+			value.SetFloat(parsed)
+		case value.Kind() >= reflect.Uint && value.Kind() <= reflect.Uint64:
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf(outOfRangeError, name)
+			}
+			if err != nil {
+				return fmt.Errorf(invalidTypeError, name, field.definition.Type)
+			}
+			if err := checkBounds(name, float64(parsed), field, belowMinimumError, aboveMaximumError); err != nil {
+				return err
+			}
+			if value.OverflowUint(parsed) {
+				return fmt.Errorf(outOfRangeError, name)
+			}
+			value.SetUint(parsed)
+		default:
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf(outOfRangeError, name)
+			}
+			if err != nil {
+				return fmt.Errorf(invalidTypeError, name, field.definition.Type)
+			}
+			if err := checkBounds(name, float64(parsed), field, belowMinimumError, aboveMaximumError); err != nil {
+				return err
+			}
+			if value.OverflowInt(parsed) {
+				return fmt.Errorf(outOfRangeError, name)
+			}
+			value.SetInt(parsed)
+		}
+	}
+	return nil
+}
+
+func checkBounds(name string, measure float64, field *typedField, belowFormat string, aboveFormat string) error {
+	if minimum, err := strconv.ParseFloat(field.min, 64); err == nil && measure < minimum {
+		return fmt.Errorf(belowFormat, name, field.min)
+	}
+	if maximum, err := strconv.ParseFloat(field.max, 64); err == nil && measure > maximum {
+		return fmt.Errorf(aboveFormat, name, field.max)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shomali11/proper"
+	"github.com/slack-go/slack"
+)
+
+const (
+	undoCommand       = "undo"
+	undoDescription   = "Reverts your last undoable command"
+	undoKeyPrefix     = "undo"
+	undoConfirmID     = "slacker_undo_confirm"
+	undoCancelID      = "slacker_undo_cancel"
+	undoPromptFormat  = "Undo `%s`?"
+	undoConfirmText   = "Undo"
+	undoCancelText    = "Cancel"
+	undoDoneFormat    = "Undoing `%s`"
+	undoCancelledText = "Undo cancelled"
+	undoExpiredText   = "This command can no longer be undone"
+)
+
+var (
+	errNothingToUndo = errors.New("There is no command to undo")
+)
+
+// undoEntry is the last undoable command executed by a user
+type undoEntry struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Usage      string            `json:"usage"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+// recordUndo remembers the command as the user's last undoable command for the undo window
+func (s *Slacker) recordUndo(botCtx BotContext, cmd BotCommand, values map[string]string) error {
+	if cmd.Definition().UndoFunc == nil {
+		return nil
+	}
+
+	ev := botCtx.Event()
+	entry := &undoEntry{
+		Timestamp:  s.clock.Now(),
+		Usage:      cmd.Usage(),
+		Parameters: values,
+	}
+	return s.saveValue(botCtx.Context(), storeKey(undoKeyPrefix, ev.TeamID, ev.User), entry, s.undoWindow)
+}
+
+func (s *Slacker) loadUndo(ctx context.Context, teamID string, userID string) (*undoEntry, error) {
+	entry := &undoEntry{}
+	found, err := s.loadValue(ctx, storeKey(undoKeyPrefix, teamID, userID), entry)
+	if err != nil || !found {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// undoHandler asks the user to confirm reverting their last undoable command
+func (s *Slacker) undoHandler(botCtx BotContext, request Request, response ResponseWriter) {
+	ev := botCtx.Event()
+	entry, err := s.loadUndo(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	var cmd BotCommand
+	if entry != nil {
+		cmd = s.findCommand(entry.Usage)
+	}
+	if cmd == nil {
+		response.ReportError(errNothingToUndo)
+		return
+	}
+
+	// The buttons only carry the timestamp and the prompt redacts Secret parameters, so their
+	// values never leave the store
+	value := strconv.FormatInt(entry.Timestamp.UnixNano(), 10)
+	prompt := fmt.Sprintf(undoPromptFormat, formatInvocation(entry.Usage, redactedValues(cmd, entry.Parameters)))
+	confirm := slack.NewButtonBlockElement(undoConfirmID, value, slack.NewTextBlockObject(slack.PlainTextType, undoConfirmText, false, false))
+	confirm.Style = slack.StyleDanger
+	cancel := slack.NewButtonBlockElement(undoCancelID, value, slack.NewTextBlockObject(slack.PlainTextType, undoCancelText, false, false))
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, prompt, false, false), nil, nil),
+		slack.NewActionBlock(undoConfirmID, confirm, cancel),
+	}
+
+	if err := response.Reply(prompt, WithBlocks(blocks), WithThreadReply(ev.IsThread())); err != nil {
+		response.ReportError(err)
+	}
+}
+
+// handleUndoAction reverts or cancels the undo once the user answers the confirmation
+func (s *Slacker) handleUndoAction(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	ev := botCtx.Event()
+	if action.ActionID == undoCancelID {
+		s.replaceInteractionMessage(botCtx, callback, undoCancelledText)
+		return
+	}
+
+	entry, err := s.loadUndo(botCtx.Context(), ev.TeamID, ev.User)
+	if err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	// Only the invoker's own, still current entry can be undone
+	if entry == nil || strconv.FormatInt(entry.Timestamp.UnixNano(), 10) != action.Value {
+		s.replaceInteractionMessage(botCtx, callback, undoExpiredText)
+		return
+	}
+
+	cmd := s.findCommand(entry.Usage)
+	if cmd == nil || cmd.Definition().UndoFunc == nil {
+		s.replaceInteractionMessage(botCtx, callback, undoExpiredText)
+		return
+	}
+
+	request := s.newRequest(botCtx, proper.NewProperties(entry.Parameters))
+	if cmd.Definition().AuthorizationFunc != nil && !cmd.Definition().AuthorizationFunc(botCtx, request) {
+		response.ReportError(s.authorizationError())
+		return
+	}
+
+	if err := s.store.Delete(botCtx.Context(), storeKey(undoKeyPrefix, ev.TeamID, ev.User)); err != nil {
+		response.ReportError(err)
+		return
+	}
+
+	s.replaceInteractionMessage(botCtx, callback, fmt.Sprintf(undoDoneFormat, formatInvocation(entry.Usage, redactedValues(cmd, entry.Parameters))))
+	go s.runUndo(botCtx, request, response, cmd)
+}
+
+// runUndo runs the undo function of the command, reporting its panics as runCommand does
+func (s *Slacker) runUndo(botCtx BotContext, request Request, response ResponseWriter, cmd BotCommand) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(botCtx, response, cmd, r)
+		}
+	}()
+
+	cmd.Definition().UndoFunc(botCtx, request, response)
+}
+
+// appendUndoHandle adds the undo command once a command is undoable, it is called with the lock held
+func (s *Slacker) appendUndoHandle() {
+	hasUndo := false
+	hasUndoable := false
+	for _, cmd := range s.botCommands {
+		hasUndo = hasUndo || cmd.Usage() == undoCommand
+		hasUndoable = hasUndoable || (cmd.Definition() != nil && cmd.Definition().UndoFunc != nil)
+	}
+
+	if hasUndoable && !hasUndo {
+		s.addBuiltinCommand(NewBotCommand(undoCommand, &CommandDefinition{Description: undoDescription, Handler: s.undoHandler}))
+	}
+}
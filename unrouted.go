@@ -0,0 +1,82 @@
+package slacker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	unroutedKeyPrefix = "unrouted"
+)
+
+// UnroutedEvent is a message addressed to the bot that matched no command or handler
+type UnroutedEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	TeamID    string    `json:"team_id"`
+	Channel   string    `json:"channel"`
+	User      string    `json:"user"`
+	Text      string    `json:"text"`
+	Type      string    `json:"type"`
+}
+
+// recordUnrouted persists a sample of the messages that matched no command or handler
+func (s *Slacker) recordUnrouted(ctx context.Context, ev *MessageEvent) error {
+	if s.unroutedSampleRate <= 0 || rand.Float64() >= s.unroutedSampleRate {
+		return nil
+	}
+
+	event := &UnroutedEvent{
+		Timestamp: s.clock.Now(),
+		TeamID:    ev.TeamID,
+		Channel:   ev.Channel,
+		User:      ev.User,
+		Text:      ev.Text,
+		Type:      ev.Type,
+	}
+	return s.saveValue(ctx, storeKey(unroutedKeyPrefix, ev.TeamID, ev.Channel, ev.TimeStamp), event, s.unroutedTTL)
+}
+
+// UnroutedEvents returns the sampled messages that matched no command or handler, the oldest first
+func (s *Slacker) UnroutedEvents(ctx context.Context) ([]*UnroutedEvent, error) {
+	keys, err := s.store.Keys(ctx, unroutedKeyPrefix+storeKeySeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*UnroutedEvent{}
+	for _, key := range keys {
+		event := &UnroutedEvent{}
+		found, err := s.loadValue(ctx, key, event)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// ExportUnroutedEvents writes the sampled unrouted messages as JSON lines, one event per line
+func (s *Slacker) ExportUnroutedEvents(ctx context.Context, writer io.Writer) error {
+	events, err := s.UnroutedEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
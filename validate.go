@@ -0,0 +1,266 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	scopesHeader          = "X-OAuth-Scopes"
+	scopesSeparator       = ","
+	authTestMethod        = "auth.test"
+	authorizationHeader   = "Authorization"
+	bearerFormat          = "Bearer %s"
+	featureMentions       = "responding to mentions"
+	featureDirectMessages = "responding to direct messages"
+	featureLinks          = "handling shared links"
+	featureTopics         = "rotating channel topics"
+	featureTranslation    = "translating replies"
+	featureCommandFormat  = "the `%s` command"
+	featureLinkFormat     = "links to %s"
+	scopeMentions         = "app_mentions:read"
+	scopeDirectMessages   = "im:history"
+	scopeReactionsRead    = "reactions:read"
+	scopeLinksRead        = "links:read"
+	validationAuthFormat  = "auth: %v"
+	validationSocketMode  = "socket mode: %v"
+	validationScopes      = "scopes: %v"
+	validationChannel     = "channel %s: %v"
+	validationOK          = "configuration is valid"
+	validationLineFormat  = "\n- %s"
+)
+
+var (
+	// ErrInvalidConfiguration is returned by Validate when the report found a problem
+	ErrInvalidConfiguration = errors.New("invalid configuration")
+)
+
+// ValidationReport lists the problems found by Validate
+type ValidationReport struct {
+	BotUserID     string
+	TeamID        string
+	GrantedScopes []string
+	MissingScopes []*ScopeError
+	Errors        []error
+}
+
+// OK reports whether no problem was found
+func (r *ValidationReport) OK() bool {
+	return len(r.MissingScopes) == 0 && len(r.Errors) == 0
+}
+
+// String lists the problems, one per line
+func (r *ValidationReport) String() string {
+	if r.OK() {
+		return validationOK
+	}
+
+	text := ErrInvalidConfiguration.Error()
+	for _, scopeErr := range r.MissingScopes {
+		text += fmt.Sprintf(validationLineFormat, scopeErr.Error())
+	}
+	for _, err := range r.Errors {
+		text += fmt.Sprintf(validationLineFormat, err.Error())
+	}
+	return text
+}
+
+// Validate checks the tokens, the OAuth scopes needed by the registered features and the channels
+// they post to, so misconfiguration is found before Listen rather than at the first failure. The
+// app token is only checked in Socket Mode.
+// It returns ErrInvalidConfiguration along with the report when a problem was found.
+func (s *Slacker) Validate(ctx context.Context) (*ValidationReport, error) {
+	report := &ValidationReport{}
+
+	auth, err := s.client.AuthTestContext(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf(validationAuthFormat, err))
+		return report, ErrInvalidConfiguration
+	}
+	report.BotUserID = auth.UserID
+	report.TeamID = auth.TeamID
+
+	// Clients created with NewHTTPClient have no app token, Slack sending them requests instead
+	if s.httpHandler == nil {
+		if _, _, err := s.client.StartSocketModeContext(ctx); err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf(validationSocketMode, err))
+		}
+	}
+
+	scopes, err := s.grantedScopes(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf(validationScopes, err))
+	} else {
+		report.GrantedScopes = scopes
+		report.MissingScopes = missingScopes(scopes, s.requiredScopes())
+	}
+
+	for _, channelID := range s.configuredChannels() {
+		if _, err := s.client.GetConversationInfoContext(ctx, channelID, false); err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf(validationChannel, channelID, channelError(err)))
+		}
+	}
+
+	if !report.OK() {
+		return report, ErrInvalidConfiguration
+	}
+	return report, nil
+}
+
+// grantedScopes returns the scopes of the bot token, which Slack only sends as a response header.
+// It goes through the HTTP client and API URL of the canvas client, those the bot was configured with.
+func (s *Slacker) grantedScopes(ctx context.Context) ([]string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.canvases.apiURL+authTestMethod, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(authorizationHeader, fmt.Sprintf(bearerFormat, s.botToken))
+
+	response, err := s.canvases.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, slack.StatusCodeError{Code: response.StatusCode, Status: response.Status}
+	}
+
+	scopes := []string{}
+	for _, scope := range strings.Split(response.Header.Get(scopesHeader), scopesSeparator) {
+		if scope = strings.TrimSpace(scope); len(scope) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// requiredScopes returns the scopes needed by the features that are configured
+func (s *Slacker) requiredScopes() []*ScopeError {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	required := []*ScopeError{
+		{Scope: scopeChatWrite, Feature: featureMessages},
+		{Scope: scopeMentions, Feature: featureMentions},
+		{Scope: scopeDirectMessages, Feature: featureDirectMessages},
+	}
+
+	if len(s.botLinkShares) > 0 {
+		required = append(required, &ScopeError{Scope: scopeLinksRead, Feature: featureLinks})
+	}
+	if len(s.reactionCommands) > 0 {
+		required = append(required,
+			&ScopeError{Scope: scopeReactionsRead, Feature: featureReactions},
+			&ScopeError{Scope: scopeHistory, Feature: featureReactions},
+		)
+	}
+	if len(s.watchers) > 0 {
+		required = append(required, &ScopeError{Scope: scopeHistory, Feature: featureWatchers})
+	}
+	if len(s.topicChannels) > 0 {
+		required = append(required, &ScopeError{Scope: scopeChannels, Feature: featureTopics})
+	}
+	if s.translator != nil {
+		required = append(required, &ScopeError{Scope: scopeUsersRead, Feature: featureTranslation})
+	}
+	if len(s.filter.ignoredApps) > 0 || len(s.filter.allowedApps) > 0 {
+		required = append(required, &ScopeError{Scope: scopeBotsRead, Feature: featureFilter})
+	}
+
+	// Scopes declared by the handlers themselves
+	commands := append([]BotCommand{}, s.botCommands...)
+	for _, cmd := range s.reactionCommands {
+		commands = append(commands, cmd)
+	}
+	for _, cmd := range s.slashCommands {
+		commands = append(commands, cmd)
+	}
+	for _, cmd := range commands {
+		feature := fmt.Sprintf(featureCommandFormat, cmd.Usage())
+		for _, scope := range cmd.Definition().Scopes {
+			required = append(required, &ScopeError{Scope: scope, Feature: feature})
+		}
+	}
+	for _, link := range s.botLinkShares {
+		feature := fmt.Sprintf(featureLinkFormat, link.Domain())
+		for _, scope := range link.Definition().Scopes {
+			required = append(required, &ScopeError{Scope: scope, Feature: feature})
+		}
+	}
+	return required
+}
+
+// ScopeAnalysis compares the scopes granted to the bot token with those its features need
+type ScopeAnalysis struct {
+	Granted  []string
+	Required []*ScopeError
+	Missing  []*ScopeError
+	Unused   []string
+}
+
+// AnalyzeScopes reports the scopes needed by the registered features, including those declared
+// by handlers, which of them are missing, and which granted scopes no feature needs and could be
+// removed to run the app with the least privilege
+func (s *Slacker) AnalyzeScopes(ctx context.Context) (*ScopeAnalysis, error) {
+	granted, err := s.grantedScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	required := s.requiredScopes()
+	needed := make(map[string]bool)
+	for _, requirement := range required {
+		needed[requirement.Scope] = true
+	}
+
+	unused := []string{}
+	for _, scope := range granted {
+		if !needed[scope] {
+			unused = append(unused, scope)
+		}
+	}
+
+	return &ScopeAnalysis{
+		Granted:  granted,
+		Required: required,
+		Missing:  missingScopes(granted, required),
+		Unused:   unused,
+	}, nil
+}
+
+// configuredChannels returns the channels that the registered features post to
+func (s *Slacker) configuredChannels() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	channels := append([]string{}, s.topicChannels...)
+	for _, report := range s.reports {
+		if len(report.definition.Channel) > 0 {
+			channels = append(channels, report.definition.Channel)
+		}
+	}
+	return channels
+}
+
+func missingScopes(granted []string, required []*ScopeError) []*ScopeError {
+	scopes := make(map[string]bool)
+	for _, scope := range granted {
+		scopes[scope] = true
+	}
+
+	missing := []*ScopeError{}
+	for _, requirement := range required {
+		if !scopes[requirement.Scope] {
+			missing = append(missing, requirement)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,57 @@
+package slacker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	validationErrorSeparator = ": "
+	validationErrorsJoin     = "; "
+	submissionErrorText      = "Something went wrong, please try again"
+)
+
+// ValidationErrors is returned by modal submission handlers to show an error below each
+// invalid input, by block ID, instead of closing the modal
+type ValidationErrors map[string]string
+
+// Error lists the invalid inputs and their errors
+func (e ValidationErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for blockID, message := range e {
+		fields = append(fields, blockID+validationErrorSeparator+message)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, validationErrorsJoin)
+}
+
+// validationResponse converts validation errors into the response that shows them in the modal.
+// It returns nil when the error holds no validation errors.
+func validationResponse(err error) *slack.ViewSubmissionResponse {
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) == 0 {
+		return nil
+	}
+	return slack.NewErrorsViewSubmissionResponse(validationErrors)
+}
+
+// submissionResponse converts the error of a modal submission into the response showing it in the
+// modal, ValidationErrors below their inputs and other errors, which are logged, as a generic error
+// below its first input. It returns nil for modals without inputs, which close instead.
+func submissionResponse(view slack.View, err error) *slack.ViewSubmissionResponse {
+	if payload := validationResponse(err); payload != nil {
+		return payload
+	}
+
+	fmt.Printf("failed handling view submission: %v\n", err)
+	for _, block := range view.Blocks.BlockSet {
+		if input, ok := block.(*slack.InputBlock); ok && len(input.BlockID) > 0 {
+			return slack.NewErrorsViewSubmissionResponse(map[string]string{input.BlockID: submissionErrorText})
+		}
+	}
+	return nil
+}
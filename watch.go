@@ -0,0 +1,140 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	featureWatchers      = "keyword watchers"
+	eventChannelMessages = "message.channels"
+)
+
+var (
+	// ErrInvalidWatch is returned when a watch has neither keywords nor a pattern, or no handler
+	ErrInvalidWatch = errors.New("a watch needs keywords or a pattern, and a handler")
+)
+
+// WatchDefinition structure contains the definition of a keyword watch. It triggers on any message
+// containing one of the keywords, ignoring case, or matching the pattern, whether or not the bot is
+// mentioned. Channels limits it to some channels, and Interval to one trigger per channel at most
+// that often. The handler receives the text that matched.
+type WatchDefinition struct {
+	Description string
+	Keywords    []string
+	Pattern     *regexp.Regexp
+	Channels    []string
+	Interval    time.Duration
+	Handler     func(botCtx BotContext, match string, response ResponseWriter)
+}
+
+// watcher is a registered watch, with the time it last triggered in each channel
+type watcher struct {
+	name       string
+	definition *WatchDefinition
+	channels   map[string]bool
+	keywords   []string
+
+	mutex     sync.Mutex
+	triggered map[string]time.Time
+}
+
+// Watch runs the handler on the channel messages matching the definition
+func (s *Slacker) Watch(name string, definition *WatchDefinition) error {
+	if definition == nil || definition.Handler == nil || (len(definition.Keywords) == 0 && definition.Pattern == nil) {
+		return ErrInvalidWatch
+	}
+
+	w := &watcher{
+		name:       name,
+		definition: definition,
+		channels:   stringSet(definition.Channels),
+		triggered:  make(map[string]time.Time),
+	}
+	for _, keyword := range definition.Keywords {
+		w.keywords = append(w.keywords, strings.ToLower(keyword))
+	}
+
+	return s.register(func() {
+		watchers := make([]*watcher, len(s.watchers), len(s.watchers)+1)
+		copy(watchers, s.watchers)
+		s.watchers = append(watchers, w)
+	})
+}
+
+// match returns the text of the message the watch triggers on, if any
+func (w *watcher) match(ev *MessageEvent) (string, bool) {
+	if len(w.channels) > 0 && !w.channels[ev.Channel] {
+		return empty, false
+	}
+
+	if len(w.keywords) > 0 {
+		text := strings.ToLower(ev.Text)
+		for i, keyword := range w.keywords {
+			if strings.Contains(text, keyword) {
+				return w.definition.Keywords[i], true
+			}
+		}
+	}
+
+	if w.definition.Pattern != nil {
+		if match := w.definition.Pattern.FindString(ev.Text); len(match) > 0 {
+			return match, true
+		}
+	}
+	return empty, false
+}
+
+// allow reports whether the watch may trigger in the channel now, recording it if so
+func (w *watcher) allow(channelID string, now time.Time) bool {
+	if w.definition.Interval <= 0 {
+		return true
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if last, ok := w.triggered[channelID]; ok && now.Sub(last) < w.definition.Interval {
+		return false
+	}
+	w.triggered[channelID] = now
+	return true
+}
+
+// runWatchers runs the watches triggered by the message, alongside the commands it may also run
+func (s *Slacker) runWatchers(ctx context.Context, ev *MessageEvent) {
+	s.mutex.RLock()
+	watchers := s.watchers
+	s.mutex.RUnlock()
+
+	if len(watchers) == 0 || len(ev.Text) == 0 {
+		return
+	}
+
+	// A channel message mentioning the bot also arrives as an app_mention event, watched once as a message
+	if _, ok := ev.Data.(*slackevents.MessageEvent); !ok {
+		return
+	}
+
+	now := s.clock.Now()
+	for _, w := range watchers {
+		match, ok := w.match(ev)
+		if !ok {
+			continue
+		}
+
+		if !w.allow(ev.Channel, now) {
+			s.tracef("watch %s was triggered in %s less than %s ago", w.name, ev.Channel, w.definition.Interval)
+			continue
+		}
+
+		botCtx := s.newBotContext(ctx, ev)
+		w.definition.Handler(botCtx, match, s.withTranslation(botCtx, s.newResponse(botCtx)))
+	}
+}
@@ -0,0 +1,297 @@
+package slacker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	wizardID           = "slacker_wizard"
+	wizardBackID       = "slacker_wizard_back"
+	wizardKeyPrefix    = "wizard"
+	wizardTTL          = time.Hour
+	wizardNext         = "Next"
+	wizardFinish       = "Submit"
+	wizardConfirm      = "Confirm"
+	wizardClose        = "Cancel"
+	wizardBack         = "Back"
+	wizardConfirmTitle = "Please confirm the following:"
+	wizardSummaryLine  = "\n• *%s*: %s"
+	wizardStepFormat   = "%s (%d/%d)"
+)
+
+var (
+	// ErrWizardNotFound is returned when opening a wizard that was not registered
+	ErrWizardNotFound = errors.New("wizard not found")
+)
+
+// WizardStep is a single view of a multi-step modal. Blocks receives the values collected so far,
+// by block ID, so that inputs can show them again when the user navigates back.
+// Validate receives the values of the step, and any error it returns keeps the user on the step, with
+// ValidationErrors below their inputs and other errors as a generic error below the first input.
+type WizardStep struct {
+	Title    string
+	Blocks   func(values map[string]string) []slack.Block
+	Validate func(values map[string]string) error
+}
+
+// WizardDefinition structure contains the definition of a multi-step modal. The steps are shown
+// one after the other, with a Back button, and the values of every step are passed to the handler.
+// When Confirm is set, a summary of the values is shown for confirmation before the handler runs.
+type WizardDefinition struct {
+	Title   string
+	Steps   []*WizardStep
+	Confirm bool
+	Handler func(botCtx BotContext, response ResponseWriter, values map[string]string)
+}
+
+// wizardState is the position of a user in a wizard, kept in the store while the modal is open
+type wizardState struct {
+	Name    string            `json:"name"`
+	Channel string            `json:"channel"`
+	Stack   []int             `json:"stack"`
+	Values  map[string]string `json:"values"`
+}
+
+// Wizard registers a multi-step modal that can then be opened with OpenWizard
+func (s *Slacker) Wizard(name string, definition *WizardDefinition) error {
+	return s.register(func() {
+		s.wizards[name] = definition
+	})
+}
+
+// OpenWizard opens the first step of the wizard for the event's user.
+// The trigger ID comes from the slash command or interaction that opens it.
+func (s *Slacker) OpenWizard(botCtx BotContext, name string, triggerID string) error {
+	return s.openWizard(botCtx, name, triggerID, make(map[string]string))
+}
+
+// openWizard opens the wizard with values already filled in
+func (s *Slacker) openWizard(botCtx BotContext, name string, triggerID string, values map[string]string) error {
+	definition := s.wizard(name)
+	if definition == nil || len(definition.Steps) == 0 {
+		return ErrWizardNotFound
+	}
+
+	ev := botCtx.Event()
+	state := &wizardState{Name: name, Channel: ev.Channel, Stack: []int{0}, Values: values}
+	if err := s.saveWizard(botCtx.Context(), ev, state); err != nil {
+		return err
+	}
+
+	view := definition.view(state)
+	_, err := s.client.OpenViewContext(botCtx.Context(), triggerID, *view)
+	return err
+}
+
+// PushView pushes a view onto the stack of the modal the trigger ID comes from
+func PushView(botCtx BotContext, triggerID string, view slack.ModalViewRequest) error {
+	return withRateLimitRetry(botCtx.Context(), func() error {
+		_, err := botCtx.Client().PushViewContext(botCtx.Context(), triggerID, view)
+		return err
+	})
+}
+
+// UpdateView replaces a view that is open. The hash, when set, makes the update fail
+// if the view changed since it was read.
+func UpdateView(botCtx BotContext, viewID string, hash string, view slack.ModalViewRequest) error {
+	return withRateLimitRetry(botCtx.Context(), func() error {
+		_, err := botCtx.Client().UpdateViewContext(botCtx.Context(), view, empty, hash, viewID)
+		return err
+	})
+}
+
+func (s *Slacker) wizard(name string) *WizardDefinition {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.wizards[name]
+}
+
+// handleWizardSubmission moves the wizard to its next step, or runs its handler after the last one
+func (s *Slacker) handleWizardSubmission(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	state, definition := s.loadWizard(botCtx, callback.View.PrivateMetadata)
+	if definition == nil {
+		return nil
+	}
+
+	values := viewValues(callback.View)
+	current := state.Stack[len(state.Stack)-1]
+	if current < len(definition.Steps) && definition.Steps[current].Validate != nil {
+		if err := definition.Steps[current].Validate(values); err != nil {
+			return submissionResponse(callback.View, err)
+		}
+	}
+
+	for name, value := range values {
+		state.Values[name] = value
+	}
+
+	next := current + 1
+	if next < definition.steps() {
+		state.Stack = append(state.Stack, next)
+		if err := s.saveWizard(botCtx.Context(), botCtx.Event(), state); err != nil {
+			fmt.Printf("failed saving wizard: %v\n", err)
+			return nil
+		}
+		return slack.NewUpdateViewSubmissionResponse(definition.view(state))
+	}
+
+	if err := s.store.Delete(botCtx.Context(), wizardKey(botCtx.Event(), state.Name)); err != nil {
+		fmt.Printf("failed deleting wizard: %v\n", err)
+	}
+
+	ev := &MessageEvent{
+		Channel: state.Channel,
+		User:    callback.User.ID,
+		Data:    callback,
+		Type:    string(callback.Type),
+		TeamID:  callback.Team.ID,
+	}
+
+	wizardCtx := s.newBotContext(botCtx.Context(), ev)
+	go s.runWizard(wizardCtx, s.newResponse(wizardCtx), state.Name, definition, state.Values)
+	return slack.NewClearViewSubmissionResponse()
+}
+
+// runWizard runs the handler of the completed wizard, recovering from its panics so they are
+// reported rather than crash the bot
+func (s *Slacker) runWizard(botCtx BotContext, response ResponseWriter, name string, definition *WizardDefinition, values map[string]string) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("failed running wizard %s: panic: %v\n%s", name, r, debug.Stack())
+			s.adminNotifier.notify(botCtx.Context(), adminPanicKind, fmt.Sprintf(adminWizardPanicFormat, name, r, debug.Stack()))
+			reportHandlerError(botCtx, fmt.Errorf(panicErrorFormat, r), r)
+			response.ReportError(errHandlerPanic)
+		}
+	}()
+
+	definition.Handler(botCtx, response, values)
+}
+
+// handleWizardBack shows the previous step of the wizard again
+func (s *Slacker) handleWizardBack(botCtx BotContext, response ResponseWriter, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	state, definition := s.loadWizard(botCtx, callback.View.PrivateMetadata)
+	if definition == nil || len(state.Stack) < 2 {
+		return
+	}
+
+	// Values typed in the current step are kept, so going forward again shows them
+	for name, value := range viewValues(callback.View) {
+		state.Values[name] = value
+	}
+	state.Stack = state.Stack[:len(state.Stack)-1]
+
+	if err := s.saveWizard(botCtx.Context(), botCtx.Event(), state); err != nil {
+		fmt.Printf("failed saving wizard: %v\n", err)
+		return
+	}
+
+	if err := UpdateView(botCtx, callback.View.ID, callback.View.Hash, *definition.view(state)); err != nil {
+		fmt.Printf("failed updating wizard: %v\n", err)
+	}
+}
+
+func (s *Slacker) loadWizard(botCtx BotContext, name string) (*wizardState, *WizardDefinition) {
+	definition := s.wizard(name)
+	if definition == nil {
+		return nil, nil
+	}
+
+	state := &wizardState{}
+	found, err := s.loadValue(botCtx.Context(), wizardKey(botCtx.Event(), name), state)
+	if err != nil {
+		fmt.Printf("failed loading wizard: %v\n", err)
+		return nil, nil
+	}
+	if !found || len(state.Stack) == 0 {
+		return nil, nil
+	}
+
+	if state.Values == nil {
+		state.Values = make(map[string]string)
+	}
+	return state, definition
+}
+
+func (s *Slacker) saveWizard(ctx context.Context, ev *MessageEvent, state *wizardState) error {
+	return s.saveValue(ctx, wizardKey(ev, state.Name), state, wizardTTL)
+}
+
+func wizardKey(ev *MessageEvent, name string) string {
+	return storeKey(wizardKeyPrefix, ev.TeamID, ev.User, name)
+}
+
+// steps returns the number of views of the wizard, including the confirmation
+func (d *WizardDefinition) steps() int {
+	if d.Confirm {
+		return len(d.Steps) + 1
+	}
+	return len(d.Steps)
+}
+
+// view renders the step at the top of the stack
+func (d *WizardDefinition) view(state *wizardState) *slack.ModalViewRequest {
+	step := state.Stack[len(state.Stack)-1]
+
+	submit := wizardNext
+	if step == d.steps()-1 {
+		submit = wizardFinish
+	}
+
+	title := d.Title
+	blocks := []slack.Block{}
+	if step < len(d.Steps) {
+		if len(d.Steps[step].Title) > 0 {
+			title = d.Steps[step].Title
+		}
+		blocks = append(blocks, d.Steps[step].Blocks(state.Values)...)
+	} else {
+		submit = wizardConfirm
+		blocks = append(blocks, d.summary(state))
+	}
+
+	if d.steps() > 1 {
+		title = fmt.Sprintf(wizardStepFormat, title, step+1, d.steps())
+	}
+
+	if len(state.Stack) > 1 {
+		back := slack.NewButtonBlockElement(wizardBackID, empty, slack.NewTextBlockObject(slack.PlainTextType, wizardBack, false, false))
+		blocks = append(blocks, slack.NewActionBlock(wizardBackID, back))
+	}
+
+	return &slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      wizardID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, title, false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, submit, false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, wizardClose, false, false),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+		PrivateMetadata: state.Name,
+	}
+}
+
+// summary lists the collected values in the confirmation step
+func (d *WizardDefinition) summary(state *wizardState) slack.Block {
+	text := wizardConfirmTitle
+	for _, step := range d.Steps {
+		for _, block := range step.Blocks(state.Values) {
+			input, ok := block.(*slack.InputBlock)
+			if !ok {
+				continue
+			}
+
+			label := input.BlockID
+			if input.Label != nil {
+				label = input.Label.Text
+			}
+			text += fmt.Sprintf(wizardSummaryLine, label, state.Values[input.BlockID])
+		}
+	}
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}